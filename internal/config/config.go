@@ -14,67 +14,79 @@ var defaultsJSON []byte
 
 // Defaults holds all default settings values
 type Defaults struct {
-	UpdateInterval           int    `json:"update_interval"`
-	RefreshMode              string `json:"refresh_mode"`
-	Language                 string `json:"language"`
-	Theme                    string `json:"theme"`
-	DefaultViewMode          string `json:"default_view_mode"`
-	StartupOnBoot            bool   `json:"startup_on_boot"`
-	CloseToTray              bool   `json:"close_to_tray"`
-	ShowHiddenArticles       bool   `json:"show_hidden_articles"`
-	HoverMarkAsRead          bool   `json:"hover_mark_as_read"`
-	TranslationEnabled       bool   `json:"translation_enabled"`
-	TargetLanguage           string `json:"target_language"`
-	TranslationProvider      string `json:"translation_provider"`
-	DeepLAPIKey              string `json:"deepl_api_key"`
-	DeepLEndpoint            string `json:"deepl_endpoint"`
-	BaiduAppID               string `json:"baidu_app_id"`
-	BaiduSecretKey           string `json:"baidu_secret_key"`
-	AIAPIKey                 string `json:"ai_api_key"`
-	AIEndpoint               string `json:"ai_endpoint"`
-	AIModel                  string `json:"ai_model"`
-	AITranslationPrompt      string `json:"ai_translation_prompt"`
-	AISummaryPrompt          string `json:"ai_summary_prompt"`
-	AICustomHeaders          string `json:"ai_custom_headers"`
-	AIUsageTokens            string `json:"ai_usage_tokens"`
-	AIUsageLimit             string `json:"ai_usage_limit"`
-	AIChatEnabled            bool   `json:"ai_chat_enabled"`
-	SummaryEnabled           bool   `json:"summary_enabled"`
-	SummaryLength            string `json:"summary_length"`
-	SummaryProvider          string `json:"summary_provider"`
-	SummaryTriggerMode       string `json:"summary_trigger_mode"`
-	AutoCleanupEnabled       bool   `json:"auto_cleanup_enabled"`
-	MaxCacheSizeMB           int    `json:"max_cache_size_mb"`
-	MaxArticleAgeDays        int    `json:"max_article_age_days"`
-	MediaCacheEnabled        bool   `json:"media_cache_enabled"`
-	MediaCacheMaxSizeMB      int    `json:"media_cache_max_size_mb"`
-	MediaCacheMaxAgeDays     int    `json:"media_cache_max_age_days"`
-	ProxyEnabled             bool   `json:"proxy_enabled"`
-	ProxyType                string `json:"proxy_type"`
-	ProxyHost                string `json:"proxy_host"`
-	ProxyPort                string `json:"proxy_port"`
-	ProxyUsername            string `json:"proxy_username"`
-	ProxyPassword            string `json:"proxy_password"`
-	Shortcuts                string `json:"shortcuts"`
-	Rules                    string `json:"rules"`
-	LastArticleUpdate        string `json:"last_article_update"`
-	GoogleTranslateEndpoint  string `json:"google_translate_endpoint"`
-	ShowArticlePreviewImages bool   `json:"show_article_preview_images"`
-	ObsidianEnabled          bool   `json:"obsidian_enabled"`
-	ObsidianVault            string `json:"obsidian_vault"`
-	ObsidianVaultPath        string `json:"obsidian_vault_path"`
-	WindowX                  string `json:"window_x"`
-	WindowY                  string `json:"window_y"`
-	WindowWidth              string `json:"window_width"`
-	WindowHeight             string `json:"window_height"`
-	WindowMaximized          string `json:"window_maximized"`
-	ImageGalleryEnabled      bool   `json:"image_gallery_enabled"`
-	FreshRSSSyncEnabled      bool   `json:"freshrss_enabled"`
-	FreshRSSServerURL        string `json:"freshrss_server_url"`
-	FreshRSSUsername         string `json:"freshrss_username"`
-	FreshRSSAPIPassword      string `json:"freshrss_api_password"`
-	FullTextFetchEnabled     bool   `json:"full_text_fetch_enabled"`
-	AutoShowAllContent       bool   `json:"auto_show_all_content"`
+	UpdateInterval            int    `json:"update_interval"`
+	RefreshMode               string `json:"refresh_mode"`
+	Language                  string `json:"language"`
+	Theme                     string `json:"theme"`
+	DefaultViewMode           string `json:"default_view_mode"`
+	StartupOnBoot             bool   `json:"startup_on_boot"`
+	CloseToTray               bool   `json:"close_to_tray"`
+	ShowHiddenArticles        bool   `json:"show_hidden_articles"`
+	HoverMarkAsRead           bool   `json:"hover_mark_as_read"`
+	TranslationEnabled        bool   `json:"translation_enabled"`
+	TargetLanguage            string `json:"target_language"`
+	TranslationProvider       string `json:"translation_provider"`
+	DeepLAPIKey               string `json:"deepl_api_key"`
+	DeepLEndpoint             string `json:"deepl_endpoint"`
+	BaiduAppID                string `json:"baidu_app_id"`
+	BaiduSecretKey            string `json:"baidu_secret_key"`
+	AIAPIKey                  string `json:"ai_api_key"`
+	AIEndpoint                string `json:"ai_endpoint"`
+	AIModel                   string `json:"ai_model"`
+	AITranslationPrompt       string `json:"ai_translation_prompt"`
+	AISummaryPrompt           string `json:"ai_summary_prompt"`
+	AICustomHeaders           string `json:"ai_custom_headers"`
+	AIUsageTokens             string `json:"ai_usage_tokens"`
+	AIUsageLimit              string `json:"ai_usage_limit"`
+	AIChatEnabled             bool   `json:"ai_chat_enabled"`
+	SummaryEnabled            bool   `json:"summary_enabled"`
+	SummaryLength             string `json:"summary_length"`
+	SummaryProvider           string `json:"summary_provider"`
+	SummaryTriggerMode        string `json:"summary_trigger_mode"`
+	AutoCleanupEnabled        bool   `json:"auto_cleanup_enabled"`
+	MaxCacheSizeMB            int    `json:"max_cache_size_mb"`
+	MaxArticleAgeDays         int    `json:"max_article_age_days"`
+	MediaCacheEnabled         bool   `json:"media_cache_enabled"`
+	MediaCacheMaxSizeMB       int    `json:"media_cache_max_size_mb"`
+	MediaCacheMaxAgeDays      int    `json:"media_cache_max_age_days"`
+	ProxyEnabled              bool   `json:"proxy_enabled"`
+	ProxyType                 string `json:"proxy_type"`
+	ProxyHost                 string `json:"proxy_host"`
+	ProxyPort                 string `json:"proxy_port"`
+	ProxyUsername             string `json:"proxy_username"`
+	ProxyPassword             string `json:"proxy_password"`
+	Shortcuts                 string `json:"shortcuts"`
+	Rules                     string `json:"rules"`
+	LastArticleUpdate         string `json:"last_article_update"`
+	GoogleTranslateEndpoint   string `json:"google_translate_endpoint"`
+	ShowArticlePreviewImages  bool   `json:"show_article_preview_images"`
+	ObsidianEnabled           bool   `json:"obsidian_enabled"`
+	ObsidianVault             string `json:"obsidian_vault"`
+	ObsidianVaultPath         string `json:"obsidian_vault_path"`
+	WindowX                   string `json:"window_x"`
+	WindowY                   string `json:"window_y"`
+	WindowWidth               string `json:"window_width"`
+	WindowHeight              string `json:"window_height"`
+	WindowMaximized           string `json:"window_maximized"`
+	ImageGalleryEnabled       bool   `json:"image_gallery_enabled"`
+	FreshRSSSyncEnabled       bool   `json:"freshrss_enabled"`
+	FreshRSSServerURL         string `json:"freshrss_server_url"`
+	FreshRSSUsername          string `json:"freshrss_username"`
+	FreshRSSAPIPassword       string `json:"freshrss_api_password"`
+	FullTextFetchEnabled      bool   `json:"full_text_fetch_enabled"`
+	AutoShowAllContent        bool   `json:"auto_show_all_content"`
+	FeedPauseAfterFailures    int    `json:"feed_pause_after_failures"`
+	FeedRedirectConfirmations int    `json:"feed_redirect_confirmations"`
+	MaxConcurrentPerHost      int    `json:"max_concurrent_per_host"`
+	WebSubEnabled             bool   `json:"websub_enabled"`
+	WebSubPublicURL           string `json:"websub_public_url"`
+	DuplicateHandling         string `json:"duplicate_handling"`
+	ScriptTimeoutSeconds      int    `json:"script_timeout_seconds"`
+	ScriptMaxOutputKB         int    `json:"script_max_output_kb"`
+	PodcastDownloadMaxSizeMB  int    `json:"podcast_download_max_size_mb"`
+	RSSHubInstance            string `json:"rsshub_instance"`
+	FullTextWorkers           int    `json:"full_text_workers"`
+	FullTextHostIntervalMs    int    `json:"full_text_host_interval_ms"`
 }
 
 var defaults Defaults
@@ -215,6 +227,30 @@ func GetString(key string) string {
 		return strconv.FormatBool(defaults.FullTextFetchEnabled)
 	case "auto_show_all_content":
 		return strconv.FormatBool(defaults.AutoShowAllContent)
+	case "feed_pause_after_failures":
+		return strconv.Itoa(defaults.FeedPauseAfterFailures)
+	case "feed_redirect_confirmations":
+		return strconv.Itoa(defaults.FeedRedirectConfirmations)
+	case "max_concurrent_per_host":
+		return strconv.Itoa(defaults.MaxConcurrentPerHost)
+	case "websub_enabled":
+		return strconv.FormatBool(defaults.WebSubEnabled)
+	case "websub_public_url":
+		return defaults.WebSubPublicURL
+	case "duplicate_handling":
+		return defaults.DuplicateHandling
+	case "script_timeout_seconds":
+		return strconv.Itoa(defaults.ScriptTimeoutSeconds)
+	case "script_max_output_kb":
+		return strconv.Itoa(defaults.ScriptMaxOutputKB)
+	case "podcast_download_max_size_mb":
+		return strconv.Itoa(defaults.PodcastDownloadMaxSizeMB)
+	case "rsshub_instance":
+		return defaults.RSSHubInstance
+	case "full_text_workers":
+		return strconv.Itoa(defaults.FullTextWorkers)
+	case "full_text_host_interval_ms":
+		return strconv.Itoa(defaults.FullTextHostIntervalMs)
 	default:
 		return ""
 	}
@@ -2,21 +2,73 @@ package database
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
 )
 
+// insertArticleQuery inserts an article unless one with the same (feed_id, guid) already exists.
+const insertArticleQuery = `INSERT OR IGNORE INTO articles (feed_id, title, url, guid, image_url, audio_url, video_url, published_at, translated_title, is_read, is_favorite, is_hidden, is_read_later, summary, content, author, categories, content_hash, item_updated_at, url_key) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// insertEnclosureQuery stores one enclosure of a newly inserted article.
+const insertEnclosureQuery = `INSERT OR IGNORE INTO article_enclosures (article_id, url, type, length) VALUES (?, ?, ?, ?)`
+
+// adoptArticleGUIDQuery upgrades a row saved under a legacy key (its normalized URL from
+// before GUIDs were tracked, or its title) to the item's current key, so the following
+// insert doesn't duplicate it.
+const adoptArticleGUIDQuery = `UPDATE OR IGNORE articles SET guid = ? WHERE feed_id = ? AND guid = ?`
+
+// articleGUID returns the feed-scoped identity key of an article:
+// the item GUID if present, otherwise the normalized URL, otherwise its ItemKey.
+func articleGUID(article *models.Article) string {
+	if guid := strings.TrimSpace(article.GUID); guid != "" {
+		return guid
+	}
+	if article.URL != "" {
+		return utils.NormalizeURL(article.URL)
+	}
+	return ItemKey(article.Title, article.Content, article.PublishedAt)
+}
+
+// ItemKey returns the identity key of a feed item that has neither a GUID nor a link,
+// so items that only share a title stay apart. A zero published time is left out.
+func ItemKey(title, content string, published time.Time) string {
+	date := ""
+	if !published.IsZero() {
+		date = strconv.FormatInt(published.Unix(), 10)
+	}
+	sum := sha256.Sum256([]byte(title + "\x00" + strings.TrimSpace(content) + "\x00" + date))
+	return "item:" + hex.EncodeToString(sum[:])
+}
+
+// legacyArticleGUID returns the key an article was saved under before GUIDs were tracked,
+// or before items without a link were keyed by their ItemKey, "" if there is none.
+func legacyArticleGUID(article *models.Article) string {
+	if article.URL != "" {
+		return utils.NormalizeURL(article.URL)
+	}
+	if strings.TrimSpace(article.GUID) == "" {
+		return article.Title
+	}
+	return ""
+}
+
 // SaveArticle saves a single article to the database.
 func (db *DB) SaveArticle(article *models.Article) error {
 	db.WaitForReady()
-	query := `INSERT OR IGNORE INTO articles (feed_id, title, url, image_url, audio_url, video_url, published_at, translated_title, is_read, is_favorite, is_hidden, is_read_later, summary) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := db.Exec(query, article.FeedID, article.Title, article.URL, article.ImageURL, article.AudioURL, article.VideoURL, article.PublishedAt, article.TranslatedTitle, article.IsRead, article.IsFavorite, article.IsHidden, article.IsReadLater, article.Summary)
-	return err
+	return saveArticle(context.Background(), db.DB, article)
 }
 
 // SaveArticles saves multiple articles in a transaction.
+// Articles are unique per feed by GUID, so the same URL may exist in several feeds.
 func (db *DB) SaveArticles(ctx context.Context, articles []*models.Article) error {
 	db.WaitForReady()
 	tx, err := db.BeginTx(ctx, nil)
@@ -25,12 +77,6 @@ func (db *DB) SaveArticles(ctx context.Context, articles []*models.Article) erro
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO articles (feed_id, title, url, image_url, audio_url, video_url, published_at, translated_title, is_read, is_favorite, is_hidden, is_read_later, summary) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
 	for _, article := range articles {
 		// Check context before each insert
 		select {
@@ -39,8 +85,7 @@ func (db *DB) SaveArticles(ctx context.Context, articles []*models.Article) erro
 		default:
 		}
 
-		_, err := stmt.ExecContext(ctx, article.FeedID, article.Title, article.URL, article.ImageURL, article.AudioURL, article.VideoURL, article.PublishedAt, article.TranslatedTitle, article.IsRead, article.IsFavorite, article.IsHidden, article.IsReadLater, article.Summary)
-		if err != nil {
+		if err := saveArticle(ctx, tx, article); err != nil {
 			log.Println("Error saving article in batch:", err)
 			// Continue even if one fails
 		}
@@ -49,11 +94,137 @@ func (db *DB) SaveArticles(ctx context.Context, articles []*models.Article) erro
 	return tx.Commit()
 }
 
+// saveArticle inserts an article unless the feed already has one with the same GUID.
+// A feed item already saved is compared with the stored article and, if it changed, recorded as a new revision.
+func saveArticle(ctx context.Context, exec articleExecer, article *models.Article) error {
+	guid := articleGUID(article)
+	if legacyKey := legacyArticleGUID(article); legacyKey != "" && legacyKey != guid {
+		if _, err := exec.ExecContext(ctx, adoptArticleGUIDQuery, guid, article.FeedID, legacyKey); err != nil {
+			return err
+		}
+	}
+	result, err := exec.ExecContext(ctx, insertArticleQuery, article.FeedID, article.Title, article.URL, guid, article.ImageURL, article.AudioURL, article.VideoURL, article.PublishedAt, article.TranslatedTitle, article.IsRead, article.IsFavorite, article.IsHidden, article.IsReadLater, article.Summary, article.Content, article.Author, encodeCategories(article.Categories), contentHash(article.Content), nullTime(article.ItemUpdatedAt), duplicateURLKey(article.URL))
+	if err != nil {
+		return err
+	}
+
+	// Enclosures are only written with a new row; an ignored insert means the article already exists
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return recordArticleRevision(ctx, exec, article, guid)
+	}
+	articleID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	// Lets callers tell newly inserted articles apart
+	article.ID = articleID
+	for _, enc := range article.Enclosures {
+		if enc.URL == "" {
+			continue
+		}
+		if _, err := exec.ExecContext(ctx, insertEnclosureQuery, articleID, enc.URL, enc.Type, enc.Length); err != nil {
+			return err
+		}
+	}
+	if article.Podcast != nil {
+		return savePodcastEpisode(ctx, exec, articleID, article.Podcast)
+	}
+	return nil
+}
+
+// encodeCategories serializes article categories for the categories column.
+func encodeCategories(categories []string) string {
+	if len(categories) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(categories)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// decodeCategories parses the categories column written by encodeCategories.
+func decodeCategories(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var categories []string
+	if err := json.Unmarshal([]byte(value), &categories); err != nil {
+		return nil
+	}
+	return categories
+}
+
+// GetArticleEnclosures returns the enclosures of the given articles keyed by article ID.
+func (db *DB) GetArticleEnclosures(articleIDs []int64) (map[int64][]models.Enclosure, error) {
+	db.WaitForReady()
+	result := make(map[int64][]models.Enclosure)
+
+	// Query in chunks to stay below SQLite's bound parameter limit
+	const chunkSize = 500
+	for start := 0; start < len(articleIDs); start += chunkSize {
+		end := start + chunkSize
+		if end > len(articleIDs) {
+			end = len(articleIDs)
+		}
+		chunk := articleIDs[start:end]
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ",")
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		rows, err := db.Query(`SELECT article_id, url, COALESCE(type, ''), COALESCE(length, 0) FROM article_enclosures WHERE article_id IN (`+placeholders+`) ORDER BY id`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var articleID int64
+			var enc models.Enclosure
+			if err := rows.Scan(&articleID, &enc.URL, &enc.Type, &enc.Length); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			result[articleID] = append(result[articleID], enc)
+		}
+		rows.Close()
+	}
+	return result, nil
+}
+
+// AttachEnclosures loads the enclosures of the given articles into their Enclosures field.
+func (db *DB) AttachEnclosures(articles []models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+	enclosures, err := db.GetArticleEnclosures(ids)
+	if err != nil {
+		return err
+	}
+	for i := range articles {
+		articles[i].Enclosures = enclosures[articles[i].ID]
+	}
+	return nil
+}
+
+// deleteOrphanedEnclosures removes enclosures whose article no longer exists.
+func (db *DB) deleteOrphanedEnclosures() error {
+	_, err := db.Exec(`DELETE FROM article_enclosures WHERE article_id NOT IN (SELECT id FROM articles)`)
+	return err
+}
+
 // GetArticles retrieves articles with filtering, pagination, and sorting.
 func (db *DB) GetArticles(filter string, feedID int64, category string, showHidden bool, limit, offset int) ([]models.Article, error) {
 	db.WaitForReady()
 	baseQuery := `
-		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title
+		SELECT a.id, a.feed_id, a.title, a.url, a.guid, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, a.author, a.categories, f.title, COALESCE(a.canonical_id, 0),
+			(SELECT COUNT(*) FROM articles d WHERE d.canonical_id = a.id)
 		FROM articles a
 		JOIN feeds f ON a.feed_id = f.id
 	`
@@ -117,16 +288,19 @@ func (db *DB) GetArticles(filter string, feedID int64, category string, showHidd
 	var articles []models.Article
 	for rows.Next() {
 		var a models.Article
-		var imageURL, audioURL, videoURL, translatedTitle, summary sql.NullString
-		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle); err != nil {
+		var guid, imageURL, audioURL, videoURL, translatedTitle, summary, author, categories sql.NullString
+		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &guid, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &author, &categories, &a.FeedTitle, &a.CanonicalID, &a.DuplicateCount); err != nil {
 			log.Println("Error scanning article:", err)
 			continue
 		}
+		a.GUID = guid.String
 		a.ImageURL = imageURL.String
 		a.AudioURL = audioURL.String
 		a.VideoURL = videoURL.String
 		a.TranslatedTitle = translatedTitle.String
 		a.Summary = summary.String
+		a.Author = author.String
+		a.Categories = decodeCategories(categories.String)
 		articles = append(articles, a)
 	}
 	return articles, nil
@@ -137,7 +311,8 @@ func (db *DB) GetArticles(filter string, feedID int64, category string, showHidd
 func (db *DB) GetArticleByID(id int64) (*models.Article, error) {
 	db.WaitForReady()
 	query := `
-		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title
+		SELECT a.id, a.feed_id, a.title, a.url, a.guid, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, a.author, a.categories, f.title, COALESCE(a.canonical_id, 0),
+			(SELECT COUNT(*) FROM articles d WHERE d.canonical_id = a.id)
 		FROM articles a
 		JOIN feeds f ON a.feed_id = f.id
 		WHERE a.id = ?
@@ -145,15 +320,32 @@ func (db *DB) GetArticleByID(id int64) (*models.Article, error) {
 	row := db.QueryRow(query, id)
 
 	var a models.Article
-	var imageURL, audioURL, videoURL, translatedTitle, summary sql.NullString
-	if err := row.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle); err != nil {
+	var guid, imageURL, audioURL, videoURL, translatedTitle, summary, author, categories sql.NullString
+	if err := row.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &guid, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &author, &categories, &a.FeedTitle, &a.CanonicalID, &a.DuplicateCount); err != nil {
 		return nil, err
 	}
+	a.GUID = guid.String
 	a.ImageURL = imageURL.String
 	a.AudioURL = audioURL.String
 	a.VideoURL = videoURL.String
 	a.TranslatedTitle = translatedTitle.String
 	a.Summary = summary.String
+	a.Author = author.String
+	a.Categories = decodeCategories(categories.String)
+
+	enclosures, err := db.GetArticleEnclosures([]int64{a.ID})
+	if err != nil {
+		return nil, err
+	}
+	a.Enclosures = enclosures[a.ID]
+	if a.AudioURL != "" {
+		if a.Podcast, err = db.GetPodcastEpisode(a.ID); err != nil {
+			return nil, err
+		}
+	}
+	if a.FullText, err = db.GetFullTextStatus(a.ID); err != nil {
+		return nil, err
+	}
 	return &a, nil
 }
 
@@ -215,14 +407,14 @@ func (db *DB) ToggleArticleHidden(id int64) error {
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec("UPDATE articles SET is_hidden = ? WHERE id = ?", !isHidden, id)
+	_, err = db.Exec("UPDATE articles SET is_hidden = ?, hidden_as_duplicate = 0 WHERE id = ?", !isHidden, id)
 	return err
 }
 
 // SetArticleHidden sets the hidden status of an article.
 func (db *DB) SetArticleHidden(id int64, hidden bool) error {
 	db.WaitForReady()
-	_, err := db.Exec("UPDATE articles SET is_hidden = ? WHERE id = ?", hidden, id)
+	_, err := db.Exec("UPDATE articles SET is_hidden = ?, hidden_as_duplicate = 0 WHERE id = ?", hidden, id)
 	return err
 }
 
@@ -266,6 +458,39 @@ func (db *DB) UpdateArticleContent(id int64, content string) error {
 	return err
 }
 
+// GetArticleContent returns the content stored for an article at fetch time.
+// An empty string means no content has been stored yet.
+func (db *DB) GetArticleContent(id int64) (string, error) {
+	db.WaitForReady()
+	var content sql.NullString
+	err := db.QueryRow("SELECT content FROM articles WHERE id = ?", id).Scan(&content)
+	if err != nil {
+		return "", err
+	}
+	return content.String, nil
+}
+
+// GetFeedPublishTimes returns when the latest articles of a feed were published, newest first.
+func (db *DB) GetFeedPublishTimes(feedID int64, limit int) ([]time.Time, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT published_at FROM articles WHERE feed_id = ? AND published_at IS NOT NULL
+		ORDER BY published_at DESC LIMIT ?`, feedID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
 // GetTotalUnreadCount returns the total number of unread articles.
 func (db *DB) GetTotalUnreadCount() (int, error) {
 	db.WaitForReady()
@@ -360,7 +585,8 @@ func (db *DB) ClearReadLater() error {
 func (db *DB) GetImageGalleryArticles(feedID int64, showHidden bool, limit, offset int) ([]models.Article, error) {
 	db.WaitForReady()
 	baseQuery := `
-		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, f.title
+		SELECT a.id, a.feed_id, a.title, a.url, a.guid, a.image_url, a.audio_url, a.video_url, a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later, a.translated_title, a.summary, a.author, a.categories, f.title, COALESCE(a.canonical_id, 0),
+			(SELECT COUNT(*) FROM articles d WHERE d.canonical_id = a.id)
 		FROM articles a
 		JOIN feeds f ON a.feed_id = f.id
 		WHERE COALESCE(f.is_image_mode, 0) = 1
@@ -392,16 +618,19 @@ func (db *DB) GetImageGalleryArticles(feedID int64, showHidden bool, limit, offs
 	var articles []models.Article
 	for rows.Next() {
 		var a models.Article
-		var imageURL, audioURL, videoURL, translatedTitle, summary sql.NullString
-		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &a.FeedTitle); err != nil {
+		var guid, imageURL, audioURL, videoURL, translatedTitle, summary, author, categories sql.NullString
+		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &guid, &imageURL, &audioURL, &videoURL, &a.PublishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater, &translatedTitle, &summary, &author, &categories, &a.FeedTitle, &a.CanonicalID, &a.DuplicateCount); err != nil {
 			log.Println("Error scanning article:", err)
 			continue
 		}
+		a.GUID = guid.String
 		a.ImageURL = imageURL.String
 		a.AudioURL = audioURL.String
 		a.VideoURL = videoURL.String
 		a.TranslatedTitle = translatedTitle.String
 		a.Summary = summary.String
+		a.Author = author.String
+		a.Categories = decodeCategories(categories.String)
 		articles = append(articles, a)
 	}
 	return articles, nil
@@ -3,6 +3,7 @@ package database_test
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -223,3 +224,267 @@ func TestSaveArticlesBatchContextCancel(t *testing.T) {
 		t.Fatalf("expected error due to canceled context")
 	}
 }
+
+func TestSaveArticlesPersistsContent(t *testing.T) {
+	db := setupDBWithFeed(t)
+
+	var feedID int64
+	_ = db.QueryRow(`SELECT id FROM feeds WHERE url = ?`, "https://example.com/feed").Scan(&feedID)
+
+	articles := []*models.Article{
+		{FeedID: feedID, Title: "with body", URL: "https://example.com/a/1", Content: "<p>stored body</p>", PublishedAt: time.Now()},
+		{FeedID: feedID, Title: "no body", URL: "https://example.com/a/2", PublishedAt: time.Now()},
+	}
+	if err := db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+
+	var withBodyID, noBodyID int64
+	_ = db.QueryRow(`SELECT id FROM articles WHERE url = ?`, "https://example.com/a/1").Scan(&withBodyID)
+	_ = db.QueryRow(`SELECT id FROM articles WHERE url = ?`, "https://example.com/a/2").Scan(&noBodyID)
+
+	content, err := db.GetArticleContent(withBodyID)
+	if err != nil {
+		t.Fatalf("GetArticleContent error: %v", err)
+	}
+	if content != "<p>stored body</p>" {
+		t.Fatalf("expected stored content, got %q", content)
+	}
+
+	content, err = db.GetArticleContent(noBodyID)
+	if err != nil {
+		t.Fatalf("GetArticleContent error: %v", err)
+	}
+	if content != "" {
+		t.Fatalf("expected empty content, got %q", content)
+	}
+
+	if _, err := db.GetArticleContent(99999); err == nil {
+		t.Fatalf("expected error for missing article")
+	}
+}
+
+func TestSaveArticlesGUIDIdentity(t *testing.T) {
+	db := setupTestDB(t)
+
+	feed1, err := db.AddFeed(&models.Feed{Title: "One", URL: "https://one.example.com/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+	feed2, err := db.AddFeed(&models.Feed{Title: "Two", URL: "https://two.example.com/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+
+	now := time.Now()
+	articles := []*models.Article{
+		// Same URL carried by two feeds
+		{FeedID: feed1, Title: "Shared", URL: "https://example.com/shared", PublishedAt: now},
+		{FeedID: feed2, Title: "Shared", URL: "https://example.com/shared", PublishedAt: now},
+		// Podcast-style items sharing one link but with distinct GUIDs
+		{FeedID: feed1, Title: "Ep 1", URL: "https://example.com/show", GUID: "ep-1", PublishedAt: now},
+		{FeedID: feed1, Title: "Ep 2", URL: "https://example.com/show", GUID: "ep-2", PublishedAt: now},
+		// Item without a link
+		{FeedID: feed1, Title: "No link", GUID: "nolink-1", PublishedAt: now},
+		// Items without a GUID or link that share a title
+		{FeedID: feed1, Title: "Status update", Content: "All systems up", PublishedAt: now},
+		{FeedID: feed1, Title: "Status update", Content: "Degraded service", PublishedAt: now.Add(time.Hour)},
+	}
+	if err := db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+
+	// Re-saving with a changed tracking parameter must not duplicate
+	again := []*models.Article{
+		{FeedID: feed1, Title: "Shared", URL: "https://example.com/shared?utm_source=rss", PublishedAt: now},
+		{FeedID: feed1, Title: "Ep 1", URL: "https://example.com/show?utm_medium=feed", GUID: "ep-1", PublishedAt: now},
+		{FeedID: feed1, Title: "Status update", Content: "All systems up", PublishedAt: now},
+	}
+	if err := db.SaveArticles(context.Background(), again); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+
+	list1, _ := db.GetArticles("", feed1, "", true, 100, 0)
+	if len(list1) != 6 {
+		t.Errorf("expected 6 articles in feed 1, got %d", len(list1))
+	}
+	list2, _ := db.GetArticles("", feed2, "", true, 100, 0)
+	if len(list2) != 1 {
+		t.Errorf("expected 1 article in feed 2, got %d", len(list2))
+	}
+}
+
+func TestArticleIdentityMigration(t *testing.T) {
+	db, err := dbpkg.NewDB(filepath.Join(t.TempDir(), "legacy.db"))
+	if err != nil {
+		t.Fatalf("NewDB error: %v", err)
+	}
+	defer db.Close()
+
+	// Legacy schema where the URL was globally unique
+	legacy := []string{
+		`CREATE TABLE feeds (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, url TEXT UNIQUE, description TEXT, category TEXT DEFAULT '', image_url TEXT DEFAULT '', last_updated DATETIME, last_error TEXT DEFAULT '')`,
+		`CREATE TABLE articles (id INTEGER PRIMARY KEY AUTOINCREMENT, feed_id INTEGER, title TEXT, url TEXT UNIQUE, image_url TEXT, translated_title TEXT, published_at DATETIME, is_read BOOLEAN DEFAULT 0, is_favorite BOOLEAN DEFAULT 0, is_hidden BOOLEAN DEFAULT 0, is_read_later BOOLEAN DEFAULT 0)`,
+		`INSERT INTO feeds (id, title, url) VALUES (1, 'Legacy', 'https://legacy.example.com/feed')`,
+		`INSERT INTO articles (feed_id, title, url, published_at, is_favorite) VALUES (1, 'A', 'https://example.com/a?utm_source=x', CURRENT_TIMESTAMP, 1)`,
+		`INSERT INTO articles (feed_id, title, url, published_at) VALUES (1, 'B', 'https://example.com/b', CURRENT_TIMESTAMP)`,
+	}
+	for _, stmt := range legacy {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("legacy setup: %v", err)
+		}
+	}
+
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init error: %v", err)
+	}
+
+	var guid string
+	if err := db.QueryRow(`SELECT guid FROM articles WHERE title = 'A'`).Scan(&guid); err != nil {
+		t.Fatalf("scan guid: %v", err)
+	}
+	if guid != "https://example.com/a" {
+		t.Errorf("expected backfilled guid to be the normalized URL, got %q", guid)
+	}
+
+	// The real GUID arrives on the next fetch and takes over the legacy row
+	err = db.SaveArticles(context.Background(), []*models.Article{
+		{FeedID: 1, Title: "A", URL: "https://example.com/a", GUID: "tag:a", PublishedAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+
+	var count int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM articles`).Scan(&count)
+	if count != 2 {
+		t.Errorf("expected 2 articles after re-fetch, got %d", count)
+	}
+	var isFavorite bool
+	if err := db.QueryRow(`SELECT is_favorite FROM articles WHERE guid = 'tag:a'`).Scan(&isFavorite); err != nil {
+		t.Fatalf("expected legacy row to adopt the GUID: %v", err)
+	}
+	if !isFavorite {
+		t.Errorf("expected legacy row state to be preserved")
+	}
+
+	// Another feed may now carry the same URL
+	feed2, err := db.AddFeed(&models.Feed{Title: "Other", URL: "https://other.example.com/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+	if err := db.SaveArticle(&models.Article{FeedID: feed2, Title: "B", URL: "https://example.com/b", PublishedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveArticle error: %v", err)
+	}
+	_ = db.QueryRow(`SELECT COUNT(*) FROM articles WHERE url = 'https://example.com/b'`).Scan(&count)
+	if count != 2 {
+		t.Errorf("expected the URL in both feeds, got %d rows", count)
+	}
+}
+
+func TestSaveArticlesPersistsMetadataAndEnclosures(t *testing.T) {
+	db := setupTestDB(t)
+
+	feedID, err := db.AddFeed(&models.Feed{Title: "Podcast", URL: "https://pod.example.com/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+
+	article := &models.Article{
+		FeedID:      feedID,
+		Title:       "Episode",
+		URL:         "https://pod.example.com/ep1",
+		PublishedAt: time.Now(),
+		Author:      "Alice",
+		Categories:  []string{"Tech", "News"},
+		Enclosures: []models.Enclosure{
+			{URL: "https://pod.example.com/ep1.mp3", Type: "audio/mpeg", Length: 1000},
+			{URL: "https://pod.example.com/ep1.jpg", Type: "image/jpeg"},
+		},
+	}
+	if err := db.SaveArticles(context.Background(), []*models.Article{article}); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+	// Saving again must not duplicate enclosures
+	if err := db.SaveArticles(context.Background(), []*models.Article{article}); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+
+	list, err := db.GetArticles("", feedID, "", true, 10, 0)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("GetArticles: %v, %d articles", err, len(list))
+	}
+	if list[0].Author != "Alice" || len(list[0].Categories) != 2 || list[0].Categories[1] != "News" {
+		t.Errorf("unexpected metadata: author=%q categories=%v", list[0].Author, list[0].Categories)
+	}
+
+	got, err := db.GetArticleByID(list[0].ID)
+	if err != nil {
+		t.Fatalf("GetArticleByID error: %v", err)
+	}
+	if len(got.Enclosures) != 2 || got.Enclosures[0].Length != 1000 || got.Enclosures[1].Type != "image/jpeg" {
+		t.Errorf("unexpected enclosures: %+v", got.Enclosures)
+	}
+
+	// Enclosures go away with the feed
+	if err := db.DeleteFeed(feedID); err != nil {
+		t.Fatalf("DeleteFeed error: %v", err)
+	}
+	var count int
+	_ = db.QueryRow(`SELECT COUNT(*) FROM article_enclosures`).Scan(&count)
+	if count != 0 {
+		t.Errorf("expected enclosures removed with feed, got %d", count)
+	}
+}
+
+func TestDeleteFeedRelinksDuplicates(t *testing.T) {
+	db := setupTestDB(t)
+	var ids []int64
+	for _, name := range []string{"a", "b", "c"} {
+		feedID, err := db.AddFeed(&models.Feed{Title: name, URL: "https://" + name + ".example/feed"})
+		if err != nil {
+			t.Fatalf("AddFeed error: %v", err)
+		}
+		article := &models.Article{FeedID: feedID, Title: "Same story", URL: "https://" + name + ".example/story", PublishedAt: time.Now()}
+		if err := db.SaveArticle(article); err != nil {
+			t.Fatalf("SaveArticle error: %v", err)
+		}
+		ids = append(ids, article.ID)
+	}
+	canonical, _ := db.GetArticleByID(ids[0])
+	for _, id := range ids[1:] {
+		_ = db.SetArticleCanonical(id, canonical.ID)
+		_ = db.HideDuplicateArticle(id)
+	}
+
+	if err := db.DeleteFeed(canonical.FeedID); err != nil {
+		t.Fatalf("DeleteFeed error: %v", err)
+	}
+
+	got, _ := db.GetArticleByID(ids[1])
+	if got.CanonicalID != 0 || got.IsHidden {
+		t.Errorf("oldest remaining copy: canonical %d hidden %v, want canonical and shown", got.CanonicalID, got.IsHidden)
+	}
+	got, _ = db.GetArticleByID(ids[2])
+	if got.CanonicalID != ids[1] || !got.IsHidden {
+		t.Errorf("other copy: canonical %d hidden %v, want canonical %d and hidden", got.CanonicalID, got.IsHidden, ids[1])
+	}
+	copies, err := db.GetArticleCopies(ids[2])
+	if err != nil {
+		t.Fatalf("GetArticleCopies error: %v", err)
+	}
+	if len(copies) != 2 || copies[0].ArticleID != ids[1] || !copies[0].IsCanonical {
+		t.Errorf("copies = %+v", copies)
+	}
+
+	// An article the user hid stays hidden when it becomes canonical
+	_ = db.SetArticleHidden(ids[2], true)
+	next, _ := db.GetArticleByID(ids[1])
+	if err := db.DeleteFeed(next.FeedID); err != nil {
+		t.Fatalf("DeleteFeed error: %v", err)
+	}
+	got, _ = db.GetArticleByID(ids[2])
+	if got.CanonicalID != 0 || !got.IsHidden {
+		t.Errorf("user-hidden copy: canonical %d hidden %v, want canonical and hidden", got.CanonicalID, got.IsHidden)
+	}
+}
@@ -0,0 +1,201 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// articleExecer is implemented by both *sql.DB and *sql.Tx.
+type articleExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// contentHash returns the hash used to notice changed article content, "" for no content.
+func contentHash(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// recordArticleRevision compares a feed item that was already saved with the stored article.
+// If its title or content changed, the new version is stored as a revision (the first time
+// together with the original version) and replaces the article's title and content.
+// Feeds that mark unread on update get the article marked unread again. Articles whose full
+// text was fetched are compared by the feed's version and queued for their full text again
+// when the content changed.
+func recordArticleRevision(ctx context.Context, exec articleExecer, article *models.Article, guid string) error {
+	var id int64
+	var title, content, hash, translatedTitle string
+	var itemUpdatedAt sql.NullTime
+	var publishedAt time.Time
+	err := exec.QueryRowContext(ctx, `SELECT id, title, COALESCE(content, ''), COALESCE(content_hash, ''), COALESCE(translated_title, ''), item_updated_at, published_at FROM articles WHERE feed_id = ? AND guid = ?`,
+		article.FeedID, guid).Scan(&id, &title, &content, &hash, &translatedTitle, &itemUpdatedAt, &publishedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The feed says when the item last changed; an update time that did not move means no new version
+	if itemUpdatedAt.Valid && !article.ItemUpdatedAt.IsZero() && !article.ItemUpdatedAt.After(itemUpdatedAt.Time) {
+		return nil
+	}
+
+	// Articles whose content was replaced by their full text compare the feed's version
+	storedContent := content
+	var feedContent sql.NullString
+	err = exec.QueryRowContext(ctx, `SELECT feed_content FROM article_full_text WHERE article_id = ? AND status = ?`, id, models.FullTextOK).Scan(&feedContent)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	hasFullText := err == nil
+	if hasFullText {
+		content = feedContent.String
+	}
+
+	newHash := contentHash(article.Content)
+	if content == "" && newHash != "" && title == article.Title {
+		// Saved before article content was stored: fill it in rather than calling it a revision
+		if hasFullText {
+			if _, err := exec.ExecContext(ctx, `UPDATE article_full_text SET feed_content = ? WHERE article_id = ?`, article.Content, id); err != nil {
+				return err
+			}
+			_, err := exec.ExecContext(ctx, `UPDATE articles SET content_hash = ?, item_updated_at = ? WHERE id = ?`,
+				newHash, nullTime(article.ItemUpdatedAt), id)
+			return err
+		}
+		_, err := exec.ExecContext(ctx, `UPDATE articles SET content = ?, content_hash = ?, item_updated_at = ? WHERE id = ?`,
+			article.Content, newHash, nullTime(article.ItemUpdatedAt), id)
+		return err
+	}
+	if hash == "" {
+		hash = contentHash(content)
+	}
+
+	titleChanged := article.Title != title
+	// Feeds that drop the content of older items did not change it
+	contentChanged := newHash != "" && newHash != hash
+	if !titleChanged && !contentChanged {
+		return nil
+	}
+
+	newContent := content
+	// A title change keeps the full text, a content change shows the feed's version until the
+	// full text is fetched again
+	articleContent := storedContent
+	if contentChanged {
+		newContent = article.Content
+		articleContent = article.Content
+	} else {
+		newHash = hash
+	}
+	if titleChanged {
+		translatedTitle = article.TranslatedTitle
+	}
+
+	var revisions int
+	if err := exec.QueryRowContext(ctx, `SELECT COUNT(*) FROM article_revisions WHERE article_id = ?`, id).Scan(&revisions); err != nil {
+		return err
+	}
+	const insertRevision = `INSERT INTO article_revisions (article_id, revision, title, content, content_hash, item_updated_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if revisions == 0 {
+		// Keep the version first saved as revision 1
+		if _, err := exec.ExecContext(ctx, insertRevision, id, 1, title, content, hash, itemUpdatedAt, publishedAt); err != nil {
+			return err
+		}
+		revisions = 1
+	}
+	if _, err := exec.ExecContext(ctx, insertRevision, id, revisions+1, article.Title, newContent, newHash, nullTime(article.ItemUpdatedAt), time.Now()); err != nil {
+		return err
+	}
+
+	// A cached AI summary describes the old content
+	updateQuery := `UPDATE articles SET title = ?, translated_title = ?, content = ?, content_hash = ?, item_updated_at = ? WHERE id = ?`
+	if contentChanged {
+		updateQuery = `UPDATE articles SET title = ?, translated_title = ?, content = ?, content_hash = ?, item_updated_at = ?, summary = '' WHERE id = ?`
+	}
+	if _, err := exec.ExecContext(ctx, updateQuery, article.Title, translatedTitle, articleContent, newHash, nullTime(article.ItemUpdatedAt), id); err != nil {
+		return err
+	}
+	if hasFullText && contentChanged {
+		now := time.Now()
+		if _, err := exec.ExecContext(ctx, `UPDATE article_full_text SET status = ?, attempts = 0, error = '', next_attempt_at = ?, feed_content = NULL, updated_at = ? WHERE article_id = ?`,
+			models.FullTextPending, now, now, id); err != nil {
+			return err
+		}
+	}
+	_, err = exec.ExecContext(ctx, `UPDATE articles SET is_read = 0 WHERE id = ? AND EXISTS (SELECT 1 FROM feeds WHERE id = ? AND mark_unread_on_update = 1)`, id, article.FeedID)
+	return err
+}
+
+// GetArticleRevisions returns the revisions of an article, oldest first, without their content.
+// Articles that never changed have none.
+func (db *DB) GetArticleRevisions(articleID int64) ([]models.ArticleRevision, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT id, article_id, revision, title, item_updated_at, created_at FROM article_revisions WHERE article_id = ? ORDER BY revision`, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.ArticleRevision{}
+	for rows.Next() {
+		var rev models.ArticleRevision
+		var itemUpdatedAt sql.NullTime
+		if err := rows.Scan(&rev.ID, &rev.ArticleID, &rev.Revision, &rev.Title, &itemUpdatedAt, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		if itemUpdatedAt.Valid {
+			rev.ItemUpdatedAt = itemUpdatedAt.Time
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// GetArticleRevision returns one revision of an article including its content, or nil if it does not exist.
+func (db *DB) GetArticleRevision(articleID int64, revision int) (*models.ArticleRevision, error) {
+	db.WaitForReady()
+	var rev models.ArticleRevision
+	var itemUpdatedAt sql.NullTime
+	err := db.QueryRow(`SELECT id, article_id, revision, title, COALESCE(content, ''), item_updated_at, created_at FROM article_revisions WHERE article_id = ? AND revision = ?`, articleID, revision).
+		Scan(&rev.ID, &rev.ArticleID, &rev.Revision, &rev.Title, &rev.Content, &itemUpdatedAt, &rev.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if itemUpdatedAt.Valid {
+		rev.ItemUpdatedAt = itemUpdatedAt.Time
+	}
+	return &rev, nil
+}
+
+// SetFeedMarkUnreadOnUpdate sets whether a feed's articles are marked unread again when they are revised.
+func (db *DB) SetFeedMarkUnreadOnUpdate(feedID int64, enabled bool) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET mark_unread_on_update = ? WHERE id = ?`, enabled, feedID)
+	return err
+}
+
+// deleteOrphanedRevisions removes revisions whose article no longer exists.
+func (db *DB) deleteOrphanedRevisions() error {
+	_, err := db.Exec(`DELETE FROM article_revisions WHERE article_id NOT IN (SELECT id FROM articles)`)
+	return err
+}
@@ -0,0 +1,155 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestSaveArticle_RecordsRevisions(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, err := db.AddFeed(&models.Feed{Title: "Blog", URL: "https://blog.example/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+
+	published := time.Now().Add(-time.Hour)
+	item := func(title, content string, updated time.Time) *models.Article {
+		return &models.Article{FeedID: feedID, GUID: "post-1", Title: title, URL: "https://blog.example/post-1", Content: content, PublishedAt: published, ItemUpdatedAt: updated}
+	}
+
+	first := item("Launch day", "<p>We launched.</p>", published)
+	if err := db.SaveArticle(first); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	db.MarkArticleRead(first.ID, true)
+	db.UpdateArticleSummary(first.ID, "old summary")
+
+	// Unchanged item: no revision
+	if err := db.SaveArticle(item("Launch day", "<p>We launched.</p>", published)); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	if revs, _ := db.GetArticleRevisions(first.ID); len(revs) != 0 {
+		t.Fatalf("got %d revisions for an unchanged item", len(revs))
+	}
+
+	// Changed content but the update time did not move: still the same version
+	if err := db.SaveArticle(item("Launch day", "<p>We launched. 3 comments</p>", published)); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	if revs, _ := db.GetArticleRevisions(first.ID); len(revs) != 0 {
+		t.Fatalf("got %d revisions although the update time did not change", len(revs))
+	}
+
+	// A correction
+	if err := db.SaveArticle(item("Launch day (updated)", "<p>We launched on Tuesday.</p>", published.Add(30*time.Minute))); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	revs, err := db.GetArticleRevisions(first.ID)
+	if err != nil {
+		t.Fatalf("GetArticleRevisions: %v", err)
+	}
+	if len(revs) != 2 || revs[0].Title != "Launch day" || revs[1].Title != "Launch day (updated)" || revs[1].Revision != 2 {
+		t.Fatalf("revisions = %+v", revs)
+	}
+	rev, _ := db.GetArticleRevision(first.ID, 1)
+	if rev == nil || rev.Content != "<p>We launched.</p>" {
+		t.Errorf("revision 1 = %+v", rev)
+	}
+
+	article, _ := db.GetArticleByID(first.ID)
+	content, _ := db.GetArticleContent(first.ID)
+	if article.Title != "Launch day (updated)" || content != "<p>We launched on Tuesday.</p>" || article.Summary != "" {
+		t.Errorf("article not updated: title %q content %q summary %q", article.Title, content, article.Summary)
+	}
+	if !article.IsRead {
+		t.Error("article marked unread although the feed does not ask for it")
+	}
+
+	// Feeds may ask for revised articles to become unread again
+	db.SetFeedMarkUnreadOnUpdate(feedID, true)
+	if err := db.SaveArticle(item("Launch day (updated)", "<p>We launched on Tuesday, 9am.</p>", published.Add(time.Hour))); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	if revs, _ := db.GetArticleRevisions(first.ID); len(revs) != 3 {
+		t.Errorf("got %d revisions, want 3", len(revs))
+	}
+	if article, _ := db.GetArticleByID(first.ID); article.IsRead {
+		t.Error("revised article was not marked unread")
+	}
+
+	// A feed dropping the content of older items did not change them
+	if err := db.SaveArticle(item("Launch day (updated)", "", time.Time{})); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	if revs, _ := db.GetArticleRevisions(first.ID); len(revs) != 3 {
+		t.Errorf("got %d revisions after the content was dropped, want 3", len(revs))
+	}
+}
+
+func TestSaveArticle_FillsMissingContentWithoutRevision(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Blog", URL: "https://blog.example/feed"})
+
+	// Saved before article content was stored
+	old := &models.Article{FeedID: feedID, GUID: "g", Title: "Old", URL: "https://blog.example/old", PublishedAt: time.Now()}
+	if err := db.SaveArticle(old); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	if err := db.SaveArticle(&models.Article{FeedID: feedID, GUID: "g", Title: "Old", URL: old.URL, Content: "<p>Body</p>", PublishedAt: old.PublishedAt}); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+
+	if revs, _ := db.GetArticleRevisions(old.ID); len(revs) != 0 {
+		t.Errorf("got %d revisions, want none", len(revs))
+	}
+	if content, _ := db.GetArticleContent(old.ID); content != "<p>Body</p>" {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestSaveArticle_RevisionRefetchesFullText(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Excerpts", URL: "https://news.example/feed"})
+
+	published := time.Now().Add(-time.Hour)
+	item := func(title, content string, updated time.Time) *models.Article {
+		return &models.Article{FeedID: feedID, GUID: "story", Title: title, URL: "https://news.example/story", Content: content, PublishedAt: published, ItemUpdatedAt: updated}
+	}
+	first := item("Story", "<p>Excerpt</p>", published)
+	if err := db.SaveArticle(first); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	db.QueueFullTextFetch([]int64{first.ID})
+	if err := db.SetFullTextContent(first.ID, "<p>The whole story</p>"); err != nil {
+		t.Fatalf("SetFullTextContent: %v", err)
+	}
+
+	// A title change keeps the full text
+	if err := db.SaveArticle(item("Story (updated)", "<p>Excerpt</p>", published.Add(time.Minute))); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	if content, _ := db.GetArticleContent(first.ID); content != "<p>The whole story</p>" {
+		t.Errorf("content after a title change = %q", content)
+	}
+	if status, _ := db.GetFullTextStatus(first.ID); status.Status != models.FullTextOK {
+		t.Errorf("status after a title change = %+v", status)
+	}
+
+	// A content change compares the feed's versions and fetches the full text again
+	if err := db.SaveArticle(item("Story (updated)", "<p>Corrected excerpt</p>", published.Add(2*time.Minute))); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	rev, _ := db.GetArticleRevision(first.ID, 1)
+	if rev == nil || rev.Content != "<p>Excerpt</p>" {
+		t.Errorf("revision 1 = %+v, want the feed's excerpt", rev)
+	}
+	status, _ := db.GetFullTextStatus(first.ID)
+	if status.Status != models.FullTextPending || status.Attempts != 0 {
+		t.Errorf("status after a content change = %+v", status)
+	}
+	if jobs, _ := db.GetDueFullTextJobs(10); len(jobs) != 1 || jobs[0].ArticleID != first.ID {
+		t.Errorf("due jobs = %+v", jobs)
+	}
+}
@@ -35,6 +35,13 @@ func (db *DB) CleanupOldArticles() (int64, error) {
 
 	count, _ := result.RowsAffected()
 
+	// Remove enclosures, revisions and episode details of the deleted articles and relink their duplicates
+	_ = db.deleteOrphanedEnclosures()
+	_ = db.deleteOrphanedRevisions()
+	_ = db.deleteOrphanedPodcastEpisodes()
+	_ = db.deleteOrphanedFullText()
+	_ = repairDuplicateCanonicals(db)
+
 	// Also cleanup translation cache with the same age limit
 	_, _ = db.CleanupTranslationCache(maxAgeDays)
 
@@ -60,6 +67,13 @@ func (db *DB) CleanupUnimportantArticles() (int64, error) {
 
 	count, _ := result.RowsAffected()
 
+	// Remove enclosures, revisions and episode details of the deleted articles and relink their duplicates
+	_ = db.deleteOrphanedEnclosures()
+	_ = db.deleteOrphanedRevisions()
+	_ = db.deleteOrphanedPodcastEpisodes()
+	_ = db.deleteOrphanedFullText()
+	_ = repairDuplicateCanonicals(db)
+
 	// Also cleanup translation cache (remove entries older than 7 days)
 	_, _ = db.CleanupTranslationCache(7)
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"MrRSS/internal/config"
+	"MrRSS/internal/models"
 
 	_ "modernc.org/sqlite"
 )
@@ -79,7 +80,7 @@ func (db *DB) Init() error {
 			"window_x", "window_y", "window_width", "window_height", "window_maximized",
 			"network_speed", "network_bandwidth_mbps", "network_latency_ms", "max_concurrent_refreshes", "last_network_test",
 			"image_gallery_enabled", "freshrss_enabled", "freshrss_server_url", "freshrss_username", "freshrss_api_password",
-			"full_text_fetch_enabled", "auto_show_all_content",
+			"full_text_fetch_enabled", "auto_show_all_content", "feed_pause_after_failures", "feed_redirect_confirmations", "max_concurrent_per_host", "websub_enabled", "websub_public_url", "duplicate_handling", "script_timeout_seconds", "script_max_output_kb", "podcast_download_max_size_mb", "rsshub_instance", "full_text_workers", "full_text_host_interval_ms",
 		}
 		for _, key := range settingsKeys {
 			defaultVal := config.GetString(key)
@@ -148,7 +149,8 @@ func initSchema(db *sql.DB) error {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		feed_id INTEGER,
 		title TEXT,
-		url TEXT UNIQUE,
+		url TEXT,
+		guid TEXT,
 		image_url TEXT,
 		audio_url TEXT DEFAULT '',
 		video_url TEXT DEFAULT '',
@@ -161,6 +163,17 @@ func initSchema(db *sql.DB) error {
 		FOREIGN KEY(feed_id) REFERENCES feeds(id)
 	);
 
+	-- Media files attached to articles (all enclosures, not only the first audio one)
+	CREATE TABLE IF NOT EXISTS article_enclosures (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		article_id INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		type TEXT DEFAULT '',
+		length INTEGER DEFAULT 0,
+		UNIQUE(article_id, url),
+		FOREIGN KEY(article_id) REFERENCES articles(id)
+	);
+
 	-- Translation cache table to avoid redundant API calls
 	CREATE TABLE IF NOT EXISTS translation_cache (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -188,6 +201,8 @@ func initSchema(db *sql.DB) error {
 	CREATE INDEX IF NOT EXISTS idx_articles_fav_published ON articles(is_favorite, published_at DESC);
 	CREATE INDEX IF NOT EXISTS idx_articles_readlater_published ON articles(is_read_later, published_at DESC);
 
+	CREATE INDEX IF NOT EXISTS idx_article_enclosures_article_id ON article_enclosures(article_id);
+
 	-- Translation cache index
 	CREATE INDEX IF NOT EXISTS idx_translation_cache_lookup ON translation_cache(source_text_hash, target_lang, provider);
 	`
@@ -238,9 +253,349 @@ func runMigrations(db *sql.DB) error {
 	// Migration: Add summary column for caching AI-generated summaries
 	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN summary TEXT DEFAULT ''`)
 
+	// Migration: Add HTTP cache validators for conditional feed requests
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN etag TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN last_modified TEXT DEFAULT ''`)
+
+	// Migration: Add author and categories columns for richer article metadata
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN author TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN categories TEXT DEFAULT ''`)
+
+	// Migration: Add feed health tracking columns and fetch history table
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN consecutive_failures INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN next_retry_at DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN is_paused BOOLEAN DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN paused_reason TEXT DEFAULT ''`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS feed_fetch_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		feed_id INTEGER NOT NULL,
+		fetched_at DATETIME NOT NULL,
+		http_status INTEGER DEFAULT 0,
+		duration_ms INTEGER DEFAULT 0,
+		item_count INTEGER DEFAULT 0,
+		error TEXT DEFAULT '',
+		FOREIGN KEY(feed_id) REFERENCES feeds(id)
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_feed_fetch_history_feed ON feed_fetch_history(feed_id, fetched_at DESC)`)
+
+	// Migration: Record server-requested fetch deferrals per feed and per host
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN deferred_until DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN defer_reason TEXT DEFAULT ''`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS host_deferrals (
+		host TEXT PRIMARY KEY,
+		deferred_until DATETIME NOT NULL,
+		reason TEXT DEFAULT ''
+	)`)
+
+	// Migration: WebSub push subscriptions, one per feed
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS websub_subscriptions (
+		feed_id INTEGER PRIMARY KEY,
+		hub_url TEXT NOT NULL,
+		topic_url TEXT NOT NULL,
+		secret TEXT DEFAULT '',
+		state TEXT NOT NULL,
+		lease_seconds INTEGER DEFAULT 0,
+		lease_expires_at DATETIME,
+		last_push_at DATETIME,
+		updated_at DATETIME NOT NULL,
+		last_error TEXT DEFAULT ''
+	)`)
+	// Migration: Random token in the WebSub callback URL so it can't be guessed from the feed ID
+	_, _ = db.Exec(`ALTER TABLE websub_subscriptions ADD COLUMN callback_token TEXT DEFAULT ''`)
+
+	// Migration: Output feeds republishing article selections
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS output_feeds (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		token TEXT NOT NULL UNIQUE,
+		source TEXT NOT NULL,
+		source_value TEXT DEFAULT '',
+		conditions TEXT DEFAULT '',
+		max_items INTEGER DEFAULT 50,
+		created_at DATETIME NOT NULL
+	)`)
+
+	// Migration: Per-feed request options (credentials, headers, cookies, User-Agent)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN auth_type TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN auth_username TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN auth_secret TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN custom_headers TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN cookies TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN user_agent TEXT DEFAULT ''`)
+
+	// Migration: Per-feed script arguments, environment and timeout; script stderr in the fetch history
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN script_args TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN script_env TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN script_timeout INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE feed_fetch_history ADD COLUMN stderr TEXT DEFAULT ''`)
+
+	// Migration: Podcast episode details, playback progress and episode downloads
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS podcast_episodes (
+		article_id INTEGER PRIMARY KEY,
+		duration INTEGER DEFAULT 0,
+		episode INTEGER DEFAULT 0,
+		season INTEGER DEFAULT 0,
+		episode_type TEXT DEFAULT '',
+		chapters_url TEXT DEFAULT '',
+		chapters_type TEXT DEFAULT '',
+		chapters TEXT DEFAULT '',
+		position REAL DEFAULT 0,
+		played BOOLEAN DEFAULT 0,
+		progress_at DATETIME,
+		FOREIGN KEY(article_id) REFERENCES articles(id)
+	)`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS episode_downloads (
+		article_id INTEGER PRIMARY KEY,
+		audio_url TEXT NOT NULL,
+		status TEXT NOT NULL,
+		file_path TEXT DEFAULT '',
+		size INTEGER DEFAULT 0,
+		error TEXT DEFAULT '',
+		queued_at DATETIME NOT NULL,
+		completed_at DATETIME
+	)`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN keep_episodes INTEGER DEFAULT 0`)
+
+	// Migration: Automatic full-text fetching with a status per article
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN fetch_full_text BOOLEAN DEFAULT 0`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS article_full_text (
+		article_id INTEGER PRIMARY KEY,
+		status TEXT NOT NULL,
+		attempts INTEGER DEFAULT 0,
+		error TEXT DEFAULT '',
+		next_attempt_at DATETIME,
+		updated_at DATETIME NOT NULL,
+		FOREIGN KEY(article_id) REFERENCES articles(id)
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_article_full_text_due ON article_full_text(status, next_attempt_at)`)
+	// Migration: Keep the feed's version of articles whose content was replaced by their full text
+	_, _ = db.Exec(`ALTER TABLE article_full_text ADD COLUMN feed_content TEXT`)
+
+	// Migration: Site-specific full-text extraction rules
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS extraction_rules (
+		domain TEXT PRIMARY KEY,
+		content_selectors TEXT DEFAULT '',
+		strip_selectors TEXT DEFAULT '',
+		next_page_selector TEXT DEFAULT '',
+		lazy_image_attrs TEXT DEFAULT '',
+		updated_at DATETIME NOT NULL
+	)`)
+
+	// Migration: Refresh policies (quiet hours, low data, daily budget) and data usage per day
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS refresh_policies (
+		category TEXT PRIMARY KEY,
+		quiet_start TEXT DEFAULT '',
+		quiet_end TEXT DEFAULT '',
+		quiet_mode TEXT DEFAULT '',
+		quiet_slow_factor INTEGER DEFAULT 0,
+		low_data BOOLEAN DEFAULT 0,
+		daily_budget_mb INTEGER DEFAULT 0
+	)`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS data_usage (
+		day TEXT NOT NULL,
+		category TEXT NOT NULL,
+		bytes INTEGER DEFAULT 0,
+		PRIMARY KEY (day, category)
+	)`)
+
+	// Migration: Track permanent redirects and record feed URL changes
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN redirect_target TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN redirect_count INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS feed_url_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		feed_id INTEGER NOT NULL,
+		old_url TEXT NOT NULL,
+		new_url TEXT NOT NULL,
+		changed_at DATETIME NOT NULL,
+		merged_into INTEGER DEFAULT 0
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_feed_url_changes_feed ON feed_url_changes(feed_id)`)
+
+	// Migration: Identify articles by feed-scoped GUID instead of a globally unique URL
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN guid TEXT`)
+	if err := migrateArticleIdentity(db); err != nil {
+		return fmt.Errorf("article identity migration: %w", err)
+	}
+
+	// Article columns are added after the identity migration, whose table rebuild keeps only the columns it knows
+
+	// Migration: Link duplicate articles across feeds to a canonical article
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN canonical_id INTEGER DEFAULT NULL`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_articles_canonical_id ON articles(canonical_id)`)
+	// Hidden by duplicate handling rather than by the user, shown again if it becomes canonical
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN hidden_as_duplicate BOOLEAN DEFAULT 0`)
+	// Migration: Indexed URL key that finds the copies of new articles linked from other feeds
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN url_key TEXT DEFAULT NULL`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_articles_url_key ON articles(url_key)`)
+	if err := backfillDuplicateKeys(db); err != nil {
+		return fmt.Errorf("duplicate keys migration: %w", err)
+	}
+
+	// Migration: Article revisions for updated feed items
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN content_hash TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN item_updated_at DATETIME DEFAULT NULL`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN mark_unread_on_update BOOLEAN DEFAULT 0`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS article_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		article_id INTEGER NOT NULL,
+		revision INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		content TEXT DEFAULT '',
+		content_hash TEXT DEFAULT '',
+		item_updated_at DATETIME DEFAULT NULL,
+		created_at DATETIME NOT NULL
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_article_revisions_article_id ON article_revisions(article_id, revision)`)
+
 	return nil
 }
 
+// migrateArticleIdentity drops the legacy UNIQUE constraint on articles.url,
+// fills the guid column for existing rows and creates the (feed_id, guid) unique index.
+func migrateArticleIdentity(db *sql.DB) error {
+	var tableSQL string
+	if err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'articles'`).Scan(&tableSQL); err != nil {
+		return err
+	}
+
+	// SQLite can't drop a column constraint, so rebuild the table without it
+	if strings.Contains(tableSQL, "url TEXT UNIQUE") {
+		const columns = `id, feed_id, title, url, guid, image_url, audio_url, video_url, translated_title, published_at, is_read, is_favorite, is_hidden, is_read_later, content, summary, author, categories`
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		statements := []string{
+			`CREATE TABLE articles_new (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				feed_id INTEGER,
+				title TEXT,
+				url TEXT,
+				guid TEXT,
+				image_url TEXT,
+				audio_url TEXT DEFAULT '',
+				video_url TEXT DEFAULT '',
+				translated_title TEXT,
+				published_at DATETIME,
+				is_read BOOLEAN DEFAULT 0,
+				is_favorite BOOLEAN DEFAULT 0,
+				is_hidden BOOLEAN DEFAULT 0,
+				is_read_later BOOLEAN DEFAULT 0,
+				content TEXT DEFAULT '',
+				summary TEXT DEFAULT '',
+				author TEXT DEFAULT '',
+				categories TEXT DEFAULT '',
+				FOREIGN KEY(feed_id) REFERENCES feeds(id)
+			)`,
+			`INSERT INTO articles_new (` + columns + `) SELECT ` + columns + ` FROM articles`,
+			`DROP TABLE articles`,
+			`ALTER TABLE articles_new RENAME TO articles`,
+			// Indexes are dropped together with the old table
+			`CREATE INDEX IF NOT EXISTS idx_articles_feed_id ON articles(feed_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_published_at ON articles(published_at DESC)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_is_read ON articles(is_read)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_is_favorite ON articles(is_favorite)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_is_hidden ON articles(is_hidden)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_is_read_later ON articles(is_read_later)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_feed_published ON articles(feed_id, published_at DESC)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_read_published ON articles(is_read, published_at DESC)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_fav_published ON articles(is_favorite, published_at DESC)`,
+			`CREATE INDEX IF NOT EXISTS idx_articles_readlater_published ON articles(is_read_later, published_at DESC)`,
+		}
+		for _, stmt := range statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	if err := backfillArticleGUIDs(db); err != nil {
+		return err
+	}
+
+	_, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_articles_feed_guid ON articles(feed_id, guid)`)
+	return err
+}
+
+// backfillArticleGUIDs assigns an identity key to rows saved before the guid column existed.
+// The original GUIDs are unknown, so the normalized URL is used; SaveArticles upgrades
+// these keys to the real GUID the next time the item is fetched.
+func backfillArticleGUIDs(db *sql.DB) error {
+	type pending struct {
+		id        int64
+		feedID    int64
+		url       string
+		title     string
+		content   string
+		published sql.NullTime
+	}
+
+	rows, err := db.Query(`SELECT id, COALESCE(feed_id, 0), COALESCE(url, ''), COALESCE(title, ''), COALESCE(content, ''), published_at FROM articles WHERE guid IS NULL OR guid = ''`)
+	if err != nil {
+		return err
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.feedID, &p.url, &p.title, &p.content, &p.published); err != nil {
+			rows.Close()
+			return err
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+	if len(todo) == 0 {
+		return nil
+	}
+
+	// Keys already taken per feed, so variants of one URL don't collide
+	seen := make(map[string]bool)
+	rows, err = db.Query(`SELECT COALESCE(feed_id, 0), guid FROM articles WHERE guid != ''`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var feedID int64
+		var guid string
+		if err := rows.Scan(&feedID, &guid); err == nil {
+			seen[fmt.Sprintf("%d\x00%s", feedID, guid)] = true
+		}
+	}
+	rows.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, p := range todo {
+		candidates := []string{
+			articleGUID(&models.Article{URL: p.url, Title: p.title, Content: p.content, PublishedAt: p.published.Time}),
+			p.url,
+			fmt.Sprintf("%s#%d", p.url, p.id),
+		}
+		for _, key := range candidates {
+			seenKey := fmt.Sprintf("%d\x00%s", p.feedID, key)
+			if key == "" || seen[seenKey] {
+				continue
+			}
+			seen[seenKey] = true
+			if _, err := tx.Exec(`UPDATE articles SET guid = ? WHERE id = ?`, key, p.id); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return tx.Commit()
+}
+
 // TranslationCache represents a cached translation entry
 type TranslationCache struct {
 	ID             int64
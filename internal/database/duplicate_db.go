@@ -0,0 +1,210 @@
+package database
+
+import (
+	"database/sql"
+	"net/url"
+	"sort"
+	"time"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+// duplicateURLKey returns the indexed key used to find copies of an article in other feeds: its
+// URL over https without "www." and query, or "" if it does not point below the site root.
+func duplicateURLKey(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err != nil || rawURL == "" || len(parsed.Path) <= 1 {
+		return ""
+	}
+	return utils.NormalizeURLForComparison(utils.NormalizeURLForDuplicates(rawURL))
+}
+
+// GetDuplicateCandidates returns the articles of other feeds that may carry the same story as
+// one of articles, with the fields needed to recognise it: ID, feed, title, URL, publication
+// time and the canonical article they are already linked to. Candidates share the URL key
+// of an article or were published at most window apart from it.
+func (db *DB) GetDuplicateCandidates(excludeFeedID int64, articles []*models.Article, window time.Duration) ([]models.Article, error) {
+	db.WaitForReady()
+	seen := make(map[int64]bool)
+	var candidates []models.Article
+	collect := func(query string, args ...interface{}) error {
+		rows, err := db.Query(`SELECT id, feed_id, title, COALESCE(url, ''), published_at, COALESCE(canonical_id, 0) FROM articles
+			WHERE feed_id != ? AND `+query, append([]interface{}{excludeFeedID}, args...)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var a models.Article
+			var publishedAt sql.NullTime
+			if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &publishedAt, &a.CanonicalID); err != nil {
+				return err
+			}
+			if publishedAt.Valid {
+				a.PublishedAt = publishedAt.Time
+			}
+			if !seen[a.ID] {
+				seen[a.ID] = true
+				candidates = append(candidates, a)
+			}
+		}
+		return rows.Err()
+	}
+
+	var published []time.Time
+	for _, article := range articles {
+		if key := duplicateURLKey(article.URL); key != "" {
+			if err := collect(`url_key = ?`, key); err != nil {
+				return nil, err
+			}
+		}
+		if !article.PublishedAt.IsZero() {
+			published = append(published, article.PublishedAt)
+		}
+	}
+
+	// Windows around the publication times, merged where they overlap
+	sort.Slice(published, func(i, j int) bool { return published[i].Before(published[j]) })
+	for i := 0; i < len(published); {
+		from, to := published[i].Add(-window), published[i].Add(window)
+		for i++; i < len(published) && !published[i].Add(-window).After(to); i++ {
+			to = published[i].Add(window)
+		}
+		if err := collect(`published_at BETWEEN ? AND ?`, from, to); err != nil {
+			return nil, err
+		}
+	}
+	return candidates, nil
+}
+
+// backfillDuplicateKeys sets the duplicate URL key of articles saved before it was stored.
+func backfillDuplicateKeys(db *sql.DB) error {
+	type pending struct {
+		id  int64
+		url string
+	}
+	rows, err := db.Query(`SELECT id, COALESCE(url, '') FROM articles WHERE url_key IS NULL`)
+	if err != nil {
+		return err
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.url); err != nil {
+			rows.Close()
+			return err
+		}
+		todo = append(todo, p)
+	}
+	rows.Close()
+	if len(todo) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, p := range todo {
+		if _, err := tx.Exec(`UPDATE articles SET url_key = ? WHERE id = ?`, duplicateURLKey(p.url), p.id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetArticleCanonical links an article to the canonical article of its story.
+func (db *DB) SetArticleCanonical(id, canonicalID int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE articles SET canonical_id = ? WHERE id = ?`, canonicalID, id)
+	return err
+}
+
+// HideDuplicateArticle hides an article because it duplicates another feed's article.
+// Articles the user already hid are left as they are.
+func (db *DB) HideDuplicateArticle(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE articles SET is_hidden = 1, hidden_as_duplicate = 1 WHERE id = ? AND is_hidden = 0`, id)
+	return err
+}
+
+// GetArticleCopies returns every feed's copy of the story an article belongs to,
+// the canonical article first. An article without duplicates yields only itself.
+func (db *DB) GetArticleCopies(articleID int64) ([]models.ArticleCopy, error) {
+	db.WaitForReady()
+	var canonicalID int64
+	if err := db.QueryRow(`SELECT COALESCE(canonical_id, id) FROM articles WHERE id = ?`, articleID).Scan(&canonicalID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT a.id, a.feed_id, COALESCE(f.title, ''), a.title, COALESCE(a.url, ''), a.published_at
+		FROM articles a
+		LEFT JOIN feeds f ON a.feed_id = f.id
+		WHERE a.id = ? OR a.canonical_id = ?
+		ORDER BY a.canonical_id IS NOT NULL, a.id`, canonicalID, canonicalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	copies := []models.ArticleCopy{}
+	for rows.Next() {
+		var c models.ArticleCopy
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&c.ArticleID, &c.FeedID, &c.FeedTitle, &c.Title, &c.URL, &publishedAt); err != nil {
+			return nil, err
+		}
+		if publishedAt.Valid {
+			c.PublishedAt = publishedAt.Time
+		}
+		c.IsCanonical = c.ArticleID == canonicalID
+		copies = append(copies, c)
+	}
+	return copies, rows.Err()
+}
+
+// sqlQueryExecer is implemented by both *sql.DB and *sql.Tx.
+type sqlQueryExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// repairDuplicateCanonicals relinks the duplicates of deleted canonical articles to the oldest
+// remaining copy of their story. That copy becomes the canonical article and is shown again,
+// unless the user hid it.
+func repairDuplicateCanonicals(q sqlQueryExecer) error {
+	rows, err := q.Query(`SELECT canonical_id, MIN(id) FROM articles
+		WHERE canonical_id IS NOT NULL AND canonical_id NOT IN (SELECT id FROM articles)
+		GROUP BY canonical_id`)
+	if err != nil {
+		return err
+	}
+	type relink struct{ deadID, newID int64 }
+	var relinks []relink
+	for rows.Next() {
+		var r relink
+		if err := rows.Scan(&r.deadID, &r.newID); err != nil {
+			rows.Close()
+			return err
+		}
+		relinks = append(relinks, r)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range relinks {
+		if _, err := q.Exec(`UPDATE articles SET canonical_id = NULL,
+			is_hidden = CASE WHEN hidden_as_duplicate = 1 THEN 0 ELSE is_hidden END, hidden_as_duplicate = 0
+			WHERE id = ?`, r.newID); err != nil {
+			return err
+		}
+		if _, err := q.Exec(`UPDATE articles SET canonical_id = ? WHERE canonical_id = ?`, r.newID, r.deadID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+const extractionRuleColumns = `domain, COALESCE(content_selectors, ''), COALESCE(strip_selectors, ''), COALESCE(next_page_selector, ''), COALESCE(lazy_image_attrs, ''), updated_at`
+
+// NormalizeRuleDomain returns the form extraction rule domains are stored in: lower case,
+// without leading or trailing dots.
+func NormalizeRuleDomain(domain string) string {
+	return strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// GetExtractionRules returns all extraction rules ordered by domain.
+func (db *DB) GetExtractionRules() ([]models.ExtractionRule, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT ` + extractionRuleColumns + ` FROM extraction_rules ORDER BY domain`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.ExtractionRule
+	for rows.Next() {
+		rule, err := scanExtractionRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// GetExtractionRule returns the rule of a domain, or sql.ErrNoRows if it has none.
+func (db *DB) GetExtractionRule(domain string) (*models.ExtractionRule, error) {
+	db.WaitForReady()
+	return scanExtractionRule(db.QueryRow(`SELECT `+extractionRuleColumns+` FROM extraction_rules WHERE domain = ?`, NormalizeRuleDomain(domain)))
+}
+
+// FindExtractionRule returns the rule applying to pages on host: the rule of the host itself,
+// or else of its closest parent domain. It returns nil if no rule applies.
+func (db *DB) FindExtractionRule(host string) (*models.ExtractionRule, error) {
+	domain := NormalizeRuleDomain(host)
+	for domain != "" {
+		rule, err := db.GetExtractionRule(domain)
+		if err == nil {
+			return rule, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return nil, nil
+}
+
+// SaveExtractionRule creates or replaces the rule of rule.Domain.
+func (db *DB) SaveExtractionRule(rule *models.ExtractionRule) error {
+	db.WaitForReady()
+	rule.Domain = NormalizeRuleDomain(rule.Domain)
+	rule.UpdatedAt = time.Now()
+	_, err := db.Exec(`INSERT INTO extraction_rules (domain, content_selectors, strip_selectors, next_page_selector, lazy_image_attrs, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(domain) DO UPDATE SET content_selectors = excluded.content_selectors, strip_selectors = excluded.strip_selectors,
+			next_page_selector = excluded.next_page_selector, lazy_image_attrs = excluded.lazy_image_attrs, updated_at = excluded.updated_at`,
+		rule.Domain, encodeCategories(rule.ContentSelectors), encodeCategories(rule.StripSelectors), rule.NextPageSelector,
+		encodeCategories(rule.LazyImageAttrs), rule.UpdatedAt)
+	return err
+}
+
+// DeleteExtractionRule removes the rule of a domain. It returns sql.ErrNoRows if there is none.
+func (db *DB) DeleteExtractionRule(domain string) error {
+	db.WaitForReady()
+	result, err := db.Exec(`DELETE FROM extraction_rules WHERE domain = ?`, NormalizeRuleDomain(domain))
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanExtractionRule(row rowScanner) (*models.ExtractionRule, error) {
+	var rule models.ExtractionRule
+	var content, strip, lazy string
+	if err := row.Scan(&rule.Domain, &content, &strip, &rule.NextPageSelector, &lazy, &rule.UpdatedAt); err != nil {
+		return nil, err
+	}
+	// The selector lists share the JSON array encoding of article categories
+	rule.ContentSelectors = decodeCategories(content)
+	rule.StripSelectors = decodeCategories(strip)
+	rule.LazyImageAttrs = decodeCategories(lazy)
+	return &rule, nil
+}
@@ -0,0 +1,75 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestExtractionRules_SaveFindDelete(t *testing.T) {
+	db := setupTestDB(t)
+
+	rule := &models.ExtractionRule{
+		Domain:           "Example.com",
+		ContentSelectors: []string{"//article", "div.post"},
+		StripSelectors:   []string{".comments"},
+		NextPageSelector: "a.next",
+		LazyImageAttrs:   []string{"data-src"},
+	}
+	if err := db.SaveExtractionRule(rule); err != nil {
+		t.Fatalf("SaveExtractionRule error: %v", err)
+	}
+	if err := db.SaveExtractionRule(&models.ExtractionRule{Domain: "news.example.com", ContentSelectors: []string{"#story"}}); err != nil {
+		t.Fatalf("SaveExtractionRule error: %v", err)
+	}
+
+	tests := []struct {
+		host string
+		want string // empty when no rule applies
+	}{
+		{"example.com", "example.com"},
+		{"www.example.com", "example.com"},
+		{"news.example.com", "news.example.com"},
+		{"live.news.example.com", "news.example.com"},
+		{"example.org", ""},
+		{"com", ""},
+	}
+	for _, tt := range tests {
+		got, err := db.FindExtractionRule(tt.host)
+		if err != nil {
+			t.Fatalf("FindExtractionRule(%q) error: %v", tt.host, err)
+		}
+		if (got == nil && tt.want != "") || (got != nil && got.Domain != tt.want) {
+			t.Errorf("FindExtractionRule(%q) = %+v, want %q", tt.host, got, tt.want)
+		}
+	}
+
+	got, err := db.GetExtractionRule("example.com")
+	if err != nil {
+		t.Fatalf("GetExtractionRule error: %v", err)
+	}
+	if len(got.ContentSelectors) != 2 || got.StripSelectors[0] != ".comments" || got.NextPageSelector != "a.next" || got.LazyImageAttrs[0] != "data-src" {
+		t.Errorf("rule = %+v", got)
+	}
+
+	// Saving again replaces the rule
+	rule.StripSelectors = nil
+	if err := db.SaveExtractionRule(rule); err != nil {
+		t.Fatalf("SaveExtractionRule error: %v", err)
+	}
+	rules, _ := db.GetExtractionRules()
+	if len(rules) != 2 || rules[0].Domain != "example.com" || len(rules[0].StripSelectors) != 0 {
+		t.Errorf("rules = %+v", rules)
+	}
+
+	if err := db.DeleteExtractionRule("example.com"); err != nil {
+		t.Fatalf("DeleteExtractionRule error: %v", err)
+	}
+	if err := db.DeleteExtractionRule("example.com"); err != sql.ErrNoRows {
+		t.Errorf("second DeleteExtractionRule error = %v, want sql.ErrNoRows", err)
+	}
+	if got, _ := db.FindExtractionRule("www.example.com"); got != nil {
+		t.Errorf("deleted rule still applies: %+v", got)
+	}
+}
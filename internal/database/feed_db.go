@@ -51,7 +51,38 @@ func (db *DB) AddFeed(feed *models.Feed) (int64, error) {
 func (db *DB) DeleteFeed(id int64) error {
 	db.WaitForReady()
 	// First delete associated articles
-	_, err := db.Exec("DELETE FROM articles WHERE feed_id = ?", id)
+	_, err := db.Exec("DELETE FROM article_enclosures WHERE article_id IN (SELECT id FROM articles WHERE feed_id = ?)", id)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM article_revisions WHERE article_id IN (SELECT id FROM articles WHERE feed_id = ?)", id)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM podcast_episodes WHERE article_id IN (SELECT id FROM articles WHERE feed_id = ?)", id)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM article_full_text WHERE article_id IN (SELECT id FROM articles WHERE feed_id = ?)", id)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM articles WHERE feed_id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := repairDuplicateCanonicals(db); err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM feed_fetch_history WHERE feed_id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM feed_url_changes WHERE feed_id = ? OR merged_into = ?", id, id)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM websub_subscriptions WHERE feed_id = ?", id)
 	if err != nil {
 		return err
 	}
@@ -62,7 +93,7 @@ func (db *DB) DeleteFeed(id int64) error {
 // GetFeeds returns all feeds ordered by category and position.
 func (db *DB) GetFeeds() ([]models.Feed, error) {
 	db.WaitForReady()
-	rows, err := db.Query("SELECT id, title, url, link, description, category, image_url, COALESCE(position, 0), last_updated, last_error, COALESCE(discovery_completed, 0), COALESCE(script_path, ''), COALESCE(hide_from_timeline, 0), COALESCE(proxy_url, ''), COALESCE(proxy_enabled, 0), COALESCE(refresh_interval, 0), COALESCE(is_image_mode, 0), COALESCE(type, ''), COALESCE(xpath_item, ''), COALESCE(xpath_item_title, ''), COALESCE(xpath_item_content, ''), COALESCE(xpath_item_uri, ''), COALESCE(xpath_item_author, ''), COALESCE(xpath_item_timestamp, ''), COALESCE(xpath_item_time_format, ''), COALESCE(xpath_item_thumbnail, ''), COALESCE(xpath_item_categories, ''), COALESCE(xpath_item_uid, ''), COALESCE(article_view_mode, 'global') FROM feeds ORDER BY category ASC, position ASC, id ASC")
+	rows, err := db.Query("SELECT id, title, url, link, description, category, image_url, COALESCE(position, 0), last_updated, last_error, COALESCE(discovery_completed, 0), COALESCE(script_path, ''), COALESCE(hide_from_timeline, 0), COALESCE(proxy_url, ''), COALESCE(proxy_enabled, 0), COALESCE(refresh_interval, 0), COALESCE(is_image_mode, 0), COALESCE(type, ''), COALESCE(xpath_item, ''), COALESCE(xpath_item_title, ''), COALESCE(xpath_item_content, ''), COALESCE(xpath_item_uri, ''), COALESCE(xpath_item_author, ''), COALESCE(xpath_item_timestamp, ''), COALESCE(xpath_item_time_format, ''), COALESCE(xpath_item_thumbnail, ''), COALESCE(xpath_item_categories, ''), COALESCE(xpath_item_uid, ''), COALESCE(article_view_mode, 'global'), COALESCE(etag, ''), COALESCE(last_modified, ''), COALESCE(consecutive_failures, 0), next_retry_at, COALESCE(is_paused, 0), COALESCE(paused_reason, ''), deferred_until, COALESCE(defer_reason, ''), COALESCE(mark_unread_on_update, 0), COALESCE(auth_type, ''), COALESCE(auth_username, ''), COALESCE(user_agent, ''), (COALESCE(auth_secret, '') != '' OR COALESCE(custom_headers, '') != '' OR COALESCE(cookies, '') != ''), COALESCE(script_args, ''), COALESCE(script_timeout, 0), COALESCE(script_env, '') != '', COALESCE(keep_episodes, 0), COALESCE(fetch_full_text, 0) FROM feeds ORDER BY category ASC, position ASC, id ASC")
 	if err != nil {
 		return nil, err
 	}
@@ -71,8 +102,11 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 	var feeds []models.Feed
 	for rows.Next() {
 		var f models.Feed
-		var link, category, imageURL, lastError, scriptPath, proxyURL, feedType, xpathItem, xpathItemTitle, xpathItemContent, xpathItemUri, xpathItemAuthor, xpathItemTimestamp, xpathItemTimeFormat, xpathItemThumbnail, xpathItemCategories, xpathItemUid, articleViewMode sql.NullString
-		if err := rows.Scan(&f.ID, &f.Title, &f.URL, &link, &f.Description, &category, &imageURL, &f.Position, &f.LastUpdated, &lastError, &f.DiscoveryCompleted, &scriptPath, &f.HideFromTimeline, &proxyURL, &f.ProxyEnabled, &f.RefreshInterval, &f.IsImageMode, &feedType, &xpathItem, &xpathItemTitle, &xpathItemContent, &xpathItemUri, &xpathItemAuthor, &xpathItemTimestamp, &xpathItemTimeFormat, &xpathItemThumbnail, &xpathItemCategories, &xpathItemUid, &articleViewMode); err != nil {
+		var link, category, imageURL, lastError, scriptPath, proxyURL, feedType, xpathItem, xpathItemTitle, xpathItemContent, xpathItemUri, xpathItemAuthor, xpathItemTimestamp, xpathItemTimeFormat, xpathItemThumbnail, xpathItemCategories, xpathItemUid, articleViewMode, etag, lastModified, pausedReason sql.NullString
+		var nextRetryAt sql.NullTime
+		var deferredUntil sql.NullTime
+		var deferReason, scriptArgs string
+		if err := rows.Scan(&f.ID, &f.Title, &f.URL, &link, &f.Description, &category, &imageURL, &f.Position, &f.LastUpdated, &lastError, &f.DiscoveryCompleted, &scriptPath, &f.HideFromTimeline, &proxyURL, &f.ProxyEnabled, &f.RefreshInterval, &f.IsImageMode, &feedType, &xpathItem, &xpathItemTitle, &xpathItemContent, &xpathItemUri, &xpathItemAuthor, &xpathItemTimestamp, &xpathItemTimeFormat, &xpathItemThumbnail, &xpathItemCategories, &xpathItemUid, &articleViewMode, &etag, &lastModified, &f.ConsecutiveFailures, &nextRetryAt, &f.IsPaused, &pausedReason, &deferredUntil, &deferReason, &f.MarkUnreadOnUpdate, &f.AuthType, &f.AuthUsername, &f.UserAgent, &f.HasRequestSecrets, &scriptArgs, &f.ScriptTimeout, &f.HasScriptEnv, &f.KeepEpisodes, &f.FetchFullText); err != nil {
 			return nil, err
 		}
 		f.Link = link.String
@@ -96,6 +130,17 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 		if f.ArticleViewMode == "" {
 			f.ArticleViewMode = "global"
 		}
+		f.ETag = etag.String
+		f.LastModified = lastModified.String
+		f.PausedReason = pausedReason.String
+		if nextRetryAt.Valid {
+			f.NextRetryAt = nextRetryAt.Time
+		}
+		if deferredUntil.Valid {
+			f.DeferredUntil = deferredUntil.Time
+		}
+		f.DeferReason = deferReason
+		f.ScriptArgs = decodeScriptArgs(scriptArgs)
 		feeds = append(feeds, f)
 	}
 	return feeds, nil
@@ -104,11 +149,14 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 // GetFeedByID retrieves a specific feed by its ID.
 func (db *DB) GetFeedByID(id int64) (*models.Feed, error) {
 	db.WaitForReady()
-	row := db.QueryRow("SELECT id, title, url, link, description, category, image_url, COALESCE(position, 0), last_updated, last_error, COALESCE(discovery_completed, 0), COALESCE(script_path, ''), COALESCE(hide_from_timeline, 0), COALESCE(proxy_url, ''), COALESCE(proxy_enabled, 0), COALESCE(refresh_interval, 0), COALESCE(is_image_mode, 0), COALESCE(type, ''), COALESCE(xpath_item, ''), COALESCE(xpath_item_title, ''), COALESCE(xpath_item_content, ''), COALESCE(xpath_item_uri, ''), COALESCE(xpath_item_author, ''), COALESCE(xpath_item_timestamp, ''), COALESCE(xpath_item_time_format, ''), COALESCE(xpath_item_thumbnail, ''), COALESCE(xpath_item_categories, ''), COALESCE(xpath_item_uid, ''), COALESCE(article_view_mode, 'global') FROM feeds WHERE id = ?", id)
+	row := db.QueryRow("SELECT id, title, url, link, description, category, image_url, COALESCE(position, 0), last_updated, last_error, COALESCE(discovery_completed, 0), COALESCE(script_path, ''), COALESCE(hide_from_timeline, 0), COALESCE(proxy_url, ''), COALESCE(proxy_enabled, 0), COALESCE(refresh_interval, 0), COALESCE(is_image_mode, 0), COALESCE(type, ''), COALESCE(xpath_item, ''), COALESCE(xpath_item_title, ''), COALESCE(xpath_item_content, ''), COALESCE(xpath_item_uri, ''), COALESCE(xpath_item_author, ''), COALESCE(xpath_item_timestamp, ''), COALESCE(xpath_item_time_format, ''), COALESCE(xpath_item_thumbnail, ''), COALESCE(xpath_item_categories, ''), COALESCE(xpath_item_uid, ''), COALESCE(article_view_mode, 'global'), COALESCE(etag, ''), COALESCE(last_modified, ''), COALESCE(consecutive_failures, 0), next_retry_at, COALESCE(is_paused, 0), COALESCE(paused_reason, ''), deferred_until, COALESCE(defer_reason, ''), COALESCE(mark_unread_on_update, 0), COALESCE(auth_type, ''), COALESCE(auth_username, ''), COALESCE(user_agent, ''), (COALESCE(auth_secret, '') != '' OR COALESCE(custom_headers, '') != '' OR COALESCE(cookies, '') != ''), COALESCE(script_args, ''), COALESCE(script_timeout, 0), COALESCE(script_env, '') != '', COALESCE(keep_episodes, 0), COALESCE(fetch_full_text, 0) FROM feeds WHERE id = ?", id)
 
 	var f models.Feed
-	var link, category, imageURL, lastError, scriptPath, proxyURL, feedType, xpathItem, xpathItemTitle, xpathItemContent, xpathItemUri, xpathItemAuthor, xpathItemTimestamp, xpathItemTimeFormat, xpathItemThumbnail, xpathItemCategories, xpathItemUid, articleViewMode sql.NullString
-	if err := row.Scan(&f.ID, &f.Title, &f.URL, &link, &f.Description, &category, &imageURL, &f.Position, &f.LastUpdated, &lastError, &f.DiscoveryCompleted, &scriptPath, &f.HideFromTimeline, &proxyURL, &f.ProxyEnabled, &f.RefreshInterval, &f.IsImageMode, &feedType, &xpathItem, &xpathItemTitle, &xpathItemContent, &xpathItemUri, &xpathItemAuthor, &xpathItemTimestamp, &xpathItemTimeFormat, &xpathItemThumbnail, &xpathItemCategories, &xpathItemUid, &articleViewMode); err != nil {
+	var link, category, imageURL, lastError, scriptPath, proxyURL, feedType, xpathItem, xpathItemTitle, xpathItemContent, xpathItemUri, xpathItemAuthor, xpathItemTimestamp, xpathItemTimeFormat, xpathItemThumbnail, xpathItemCategories, xpathItemUid, articleViewMode, etag, lastModified, pausedReason sql.NullString
+	var nextRetryAt sql.NullTime
+	var deferredUntil sql.NullTime
+	var deferReason, scriptArgs string
+	if err := row.Scan(&f.ID, &f.Title, &f.URL, &link, &f.Description, &category, &imageURL, &f.Position, &f.LastUpdated, &lastError, &f.DiscoveryCompleted, &scriptPath, &f.HideFromTimeline, &proxyURL, &f.ProxyEnabled, &f.RefreshInterval, &f.IsImageMode, &feedType, &xpathItem, &xpathItemTitle, &xpathItemContent, &xpathItemUri, &xpathItemAuthor, &xpathItemTimestamp, &xpathItemTimeFormat, &xpathItemThumbnail, &xpathItemCategories, &xpathItemUid, &articleViewMode, &etag, &lastModified, &f.ConsecutiveFailures, &nextRetryAt, &f.IsPaused, &pausedReason, &deferredUntil, &deferReason, &f.MarkUnreadOnUpdate, &f.AuthType, &f.AuthUsername, &f.UserAgent, &f.HasRequestSecrets, &scriptArgs, &f.ScriptTimeout, &f.HasScriptEnv, &f.KeepEpisodes, &f.FetchFullText); err != nil {
 		return nil, err
 	}
 	f.Link = link.String
@@ -132,6 +180,17 @@ func (db *DB) GetFeedByID(id int64) (*models.Feed, error) {
 	if f.ArticleViewMode == "" {
 		f.ArticleViewMode = "global"
 	}
+	f.ETag = etag.String
+	f.LastModified = lastModified.String
+	f.PausedReason = pausedReason.String
+	if nextRetryAt.Valid {
+		f.NextRetryAt = nextRetryAt.Time
+	}
+	if deferredUntil.Valid {
+		f.DeferredUntil = deferredUntil.Time
+	}
+	f.DeferReason = deferReason
+	f.ScriptArgs = decodeScriptArgs(scriptArgs)
 
 	return &f, nil
 }
@@ -198,6 +257,14 @@ func (db *DB) UpdateFeedError(id int64, errorMsg string) error {
 	return err
 }
 
+// UpdateFeedValidators stores the ETag and Last-Modified values returned by the feed server.
+// Empty values clear previously stored validators.
+func (db *DB) UpdateFeedValidators(id int64, etag, lastModified string) error {
+	db.WaitForReady()
+	_, err := db.Exec("UPDATE feeds SET etag = ?, last_modified = ? WHERE id = ?", etag, lastModified, id)
+	return err
+}
+
 // MarkFeedDiscovered marks a feed as having completed discovery.
 func (db *DB) MarkFeedDiscovered(id int64) error {
 	db.WaitForReady()
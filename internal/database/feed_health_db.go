@@ -0,0 +1,163 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// feedFetchHistoryLimit is the number of fetch history entries kept per feed.
+const feedFetchHistoryLimit = 100
+
+// RecordFeedFetch appends an entry to the feed's fetch history and prunes old entries.
+func (db *DB) RecordFeedFetch(entry models.FetchHistoryEntry) error {
+	db.WaitForReady()
+	if entry.FetchedAt.IsZero() {
+		entry.FetchedAt = time.Now()
+	}
+	_, err := db.Exec(`INSERT INTO feed_fetch_history (feed_id, fetched_at, http_status, duration_ms, item_count, error, stderr) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.FeedID, entry.FetchedAt, entry.HTTPStatus, entry.DurationMs, entry.ItemCount, entry.Error, entry.Stderr)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM feed_fetch_history WHERE feed_id = ? AND id NOT IN (
+		SELECT id FROM feed_fetch_history WHERE feed_id = ? ORDER BY id DESC LIMIT ?
+	)`, entry.FeedID, entry.FeedID, feedFetchHistoryLimit)
+	return err
+}
+
+// GetFeedFetchHistory returns the most recent fetch history entries of a feed, newest first.
+func (db *DB) GetFeedFetchHistory(feedID int64, limit int) ([]models.FetchHistoryEntry, error) {
+	db.WaitForReady()
+	if limit <= 0 || limit > feedFetchHistoryLimit {
+		limit = feedFetchHistoryLimit
+	}
+	rows, err := db.Query(`SELECT id, feed_id, fetched_at, COALESCE(http_status, 0), COALESCE(duration_ms, 0), COALESCE(item_count, 0), COALESCE(error, ''), COALESCE(stderr, '')
+		FROM feed_fetch_history WHERE feed_id = ? ORDER BY id DESC LIMIT ?`, feedID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.FetchHistoryEntry
+	for rows.Next() {
+		var e models.FetchHistoryEntry
+		if err := rows.Scan(&e.ID, &e.FeedID, &e.FetchedAt, &e.HTTPStatus, &e.DurationMs, &e.ItemCount, &e.Error, &e.Stderr); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// MarkFeedFetchSucceeded resets the failure state of a feed after a successful fetch.
+// A paused feed that fetches successfully (e.g. on manual refresh) is resumed.
+func (db *DB) MarkFeedFetchSucceeded(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET last_error = '', consecutive_failures = 0, next_retry_at = NULL, is_paused = 0, paused_reason = '', last_updated = ? WHERE id = ?`, time.Now(), id)
+	return err
+}
+
+// MarkFeedFetchFailed stores the error and failure count of a feed and
+// defers its next scheduled fetch until nextRetryAt.
+func (db *DB) MarkFeedFetchFailed(id int64, errorMsg string, failures int, nextRetryAt time.Time) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET last_error = ?, consecutive_failures = ?, next_retry_at = ? WHERE id = ?`, errorMsg, failures, nextRetryAt, id)
+	return err
+}
+
+// PauseFeed stops scheduled refreshes of a feed until it is resumed.
+func (db *DB) PauseFeed(id int64, reason string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET is_paused = 1, paused_reason = ? WHERE id = ?`, reason, id)
+	return err
+}
+
+// ResumeFeed re-enables scheduled refreshes of a paused feed and clears its backoff.
+func (db *DB) ResumeFeed(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET is_paused = 0, paused_reason = '', consecutive_failures = 0, next_retry_at = NULL WHERE id = ?`, id)
+	return err
+}
+
+// GetLatestArticleTimes returns the publication time of the newest article of each feed.
+// Feeds without articles are missing from the map.
+func (db *DB) GetLatestArticleTimes() (map[int64]time.Time, error) {
+	db.WaitForReady()
+	// With MAX, SQLite takes the bare published_at from the newest row, keeping its column type
+	rows, err := db.Query(`SELECT feed_id, published_at, MAX(published_at) FROM articles GROUP BY feed_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	latest := make(map[int64]time.Time)
+	for rows.Next() {
+		var feedID int64
+		var published sql.NullTime
+		var newest interface{}
+		if err := rows.Scan(&feedID, &published, &newest); err != nil {
+			return nil, err
+		}
+		if published.Valid {
+			latest[feedID] = published.Time
+		}
+	}
+	return latest, rows.Err()
+}
+
+// SetFeedDeferral stores a server-requested deferral of a feed's next scheduled fetch.
+// A zero until clears the deferral.
+func (db *DB) SetFeedDeferral(id int64, until time.Time, reason string) error {
+	db.WaitForReady()
+	if until.IsZero() {
+		_, err := db.Exec(`UPDATE feeds SET deferred_until = NULL, defer_reason = '' WHERE id = ?`, id)
+		return err
+	}
+	_, err := db.Exec(`UPDATE feeds SET deferred_until = ?, defer_reason = ? WHERE id = ?`, until, reason, id)
+	return err
+}
+
+// SetHostDeferral defers all scheduled fetches from a host until the given time.
+func (db *DB) SetHostDeferral(host string, until time.Time, reason string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`INSERT INTO host_deferrals (host, deferred_until, reason) VALUES (?, ?, ?)
+		ON CONFLICT(host) DO UPDATE SET deferred_until = excluded.deferred_until, reason = excluded.reason`, host, until, reason)
+	return err
+}
+
+// GetHostDeferrals returns the host deferrals still active at now, keyed by host.
+// Expired deferrals are removed.
+func (db *DB) GetHostDeferrals(now time.Time) (map[string]models.HostDeferral, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT host, deferred_until, COALESCE(reason, '') FROM host_deferrals`)
+	if err != nil {
+		return nil, err
+	}
+
+	deferrals := make(map[string]models.HostDeferral)
+	var expired []string
+	for rows.Next() {
+		var d models.HostDeferral
+		if err := rows.Scan(&d.Host, &d.Until, &d.Reason); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if d.Until.After(now) {
+			deferrals[d.Host] = d
+		} else {
+			expired = append(expired, d.Host)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, host := range expired {
+		_, _ = db.Exec(`DELETE FROM host_deferrals WHERE host = ?`, host)
+	}
+	return deferrals, nil
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// NotePermanentRedirect records that a fetch of the feed was permanently redirected to target
+// and returns how many fetches in a row have been redirected to that same target.
+func (db *DB) NotePermanentRedirect(id int64, target string) (int, error) {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET
+		redirect_count = CASE WHEN redirect_target = ? THEN COALESCE(redirect_count, 0) + 1 ELSE 1 END,
+		redirect_target = ?
+		WHERE id = ?`, target, target, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = db.QueryRow(`SELECT COALESCE(redirect_count, 0) FROM feeds WHERE id = ?`, id).Scan(&count)
+	return count, err
+}
+
+// ClearPermanentRedirect forgets a pending redirect of a feed, e.g. once the old URL answers directly again.
+func (db *DB) ClearPermanentRedirect(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET redirect_target = '', redirect_count = 0 WHERE id = ? AND redirect_count > 0`, id)
+	return err
+}
+
+// ApplyFeedURLChange moves a feed to its new URL and records the change.
+// If another feed is already subscribed to newURL, the feed's articles are merged into
+// that subscription and the feed is removed; the ID of the surviving feed is returned.
+// Otherwise the returned ID is 0.
+func (db *DB) ApplyFeedURLChange(id int64, oldURL, newURL string) (int64, error) {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var mergedInto int64
+	err = tx.QueryRow(`SELECT id FROM feeds WHERE url = ? AND id != ?`, newURL, id).Scan(&mergedInto)
+	switch {
+	case err == sql.ErrNoRows:
+		mergedInto = 0
+		if _, err := tx.Exec(`UPDATE feeds SET url = ?, redirect_target = '', redirect_count = 0 WHERE id = ?`, newURL, id); err != nil {
+			return 0, err
+		}
+	case err != nil:
+		return 0, err
+	default:
+		if err := mergeFeedInto(tx, id, mergedInto); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO feed_url_changes (feed_id, old_url, new_url, changed_at, merged_into) VALUES (?, ?, ?, ?, ?)`,
+		id, oldURL, newURL, time.Now(), mergedInto)
+	if err != nil {
+		return 0, err
+	}
+	return mergedInto, tx.Commit()
+}
+
+// mergeFeedInto moves the articles and output feeds of feed src to feed dst and deletes src.
+// Articles dst already has (same GUID) are dropped after their user state, revisions,
+// enclosures, episode progress and full text are carried over to dst's copy.
+func mergeFeedInto(tx *sql.Tx, src, dst int64) error {
+	if _, err := tx.Exec(`UPDATE OR IGNORE articles SET feed_id = ? WHERE feed_id = ?`, dst, src); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT s.id, d.id FROM articles s JOIN articles d ON d.feed_id = ? AND d.guid = s.guid WHERE s.feed_id = ?`, dst, src)
+	if err != nil {
+		return err
+	}
+	type copyPair struct{ from, to int64 }
+	var pairs []copyPair
+	for rows.Next() {
+		var p copyPair
+		if err := rows.Scan(&p.from, &p.to); err != nil {
+			rows.Close()
+			return err
+		}
+		pairs = append(pairs, p)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		if err := mergeArticleInto(tx, p.from, p.to); err != nil {
+			return err
+		}
+	}
+
+	steps := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`DELETE FROM article_enclosures WHERE article_id IN (SELECT id FROM articles WHERE feed_id = ?)`, []interface{}{src}},
+		{`DELETE FROM article_revisions WHERE article_id IN (SELECT id FROM articles WHERE feed_id = ?)`, []interface{}{src}},
+		{`DELETE FROM podcast_episodes WHERE article_id IN (SELECT id FROM articles WHERE feed_id = ?)`, []interface{}{src}},
+		{`DELETE FROM article_full_text WHERE article_id IN (SELECT id FROM articles WHERE feed_id = ?)`, []interface{}{src}},
+		{`DELETE FROM articles WHERE feed_id = ?`, []interface{}{src}},
+		{`DELETE FROM feed_fetch_history WHERE feed_id = ?`, []interface{}{src}},
+		{`DELETE FROM websub_subscriptions WHERE feed_id = ?`, []interface{}{src}},
+		{`UPDATE output_feeds SET source_value = ? WHERE source = ? AND source_value = ?`, []interface{}{strconv.FormatInt(dst, 10), models.OutputSourceFeed, strconv.FormatInt(src, 10)}},
+		{`DELETE FROM feeds WHERE id = ?`, []interface{}{src}},
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step.query, step.args...); err != nil {
+			return err
+		}
+	}
+	return repairDuplicateCanonicals(tx)
+}
+
+// mergeArticleInto carries what the user did with article from over to article to, another copy
+// of the same item. Its revisions and full text are moved if to has none, and the enclosures,
+// episode details and episode download to lacks are added.
+func mergeArticleInto(tx *sql.Tx, from, to int64) error {
+	_, err := tx.Exec(`UPDATE articles SET
+		is_favorite = MAX(COALESCE(is_favorite, 0), (SELECT COALESCE(is_favorite, 0) FROM articles WHERE id = ?)),
+		is_read_later = MAX(COALESCE(is_read_later, 0), (SELECT COALESCE(is_read_later, 0) FROM articles WHERE id = ?)),
+		is_read = MAX(COALESCE(is_read, 0), (SELECT COALESCE(is_read, 0) FROM articles WHERE id = ?)),
+		summary = CASE WHEN COALESCE(summary, '') = '' THEN (SELECT COALESCE(summary, '') FROM articles WHERE id = ?) ELSE summary END
+		WHERE id = ?`, from, from, from, from, to)
+	if err != nil {
+		return err
+	}
+
+	var revisions, fullText int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM article_revisions WHERE article_id = ?`, to).Scan(&revisions); err != nil {
+		return err
+	}
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM article_full_text WHERE article_id = ?`, to).Scan(&fullText); err != nil {
+		return err
+	}
+	if revisions == 0 {
+		if _, err := tx.Exec(`UPDATE article_revisions SET article_id = ? WHERE article_id = ?`, to, from); err != nil {
+			return err
+		}
+	}
+	if fullText == 0 {
+		if _, err := tx.Exec(`UPDATE article_full_text SET article_id = ? WHERE article_id = ?`, to, from); err != nil {
+			return err
+		}
+		// The fetched full text is the content of the dropped copy
+		if _, err := tx.Exec(`UPDATE articles SET content = (SELECT content FROM articles WHERE id = ?)
+			WHERE id = ? AND EXISTS (SELECT 1 FROM article_full_text WHERE article_id = ? AND status = ?)`,
+			from, to, to, models.FullTextOK); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE OR IGNORE article_enclosures SET article_id = ? WHERE article_id = ?`, to, from); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE OR IGNORE podcast_episodes SET article_id = ? WHERE article_id = ?`, to, from); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE OR IGNORE episode_downloads SET article_id = ? WHERE article_id = ?`, to, from)
+	return err
+}
+
+// GetFeedURLChanges returns the URL changes involving a feed, newest first.
+// A feedID of 0 returns the changes of all feeds.
+func (db *DB) GetFeedURLChanges(feedID int64) ([]models.FeedURLChange, error) {
+	db.WaitForReady()
+	query := `SELECT id, feed_id, old_url, new_url, changed_at, COALESCE(merged_into, 0) FROM feed_url_changes`
+	var args []interface{}
+	if feedID != 0 {
+		query += ` WHERE feed_id = ? OR merged_into = ?`
+		args = append(args, feedID, feedID)
+	}
+	query += ` ORDER BY id DESC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.FeedURLChange
+	for rows.Next() {
+		var c models.FeedURLChange
+		if err := rows.Scan(&c.ID, &c.FeedID, &c.OldURL, &c.NewURL, &c.ChangedAt, &c.MergedInto); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
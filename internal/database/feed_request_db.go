@@ -0,0 +1,60 @@
+package database
+
+import (
+	"fmt"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/models"
+)
+
+// GetFeedRequestOptions returns the decrypted request options of a feed.
+func (db *DB) GetFeedRequestOptions(feedID int64) (models.FeedRequestOptions, error) {
+	db.WaitForReady()
+	var opts models.FeedRequestOptions
+	var secret, headers, cookies string
+	err := db.QueryRow(`SELECT COALESCE(auth_type, ''), COALESCE(auth_username, ''), COALESCE(auth_secret, ''), COALESCE(custom_headers, ''), COALESCE(cookies, ''), COALESCE(user_agent, '') FROM feeds WHERE id = ?`, feedID).
+		Scan(&opts.AuthType, &opts.AuthUsername, &secret, &headers, &cookies, &opts.UserAgent)
+	if err != nil {
+		return opts, err
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *string
+	}{
+		{"auth_secret", secret, &opts.AuthSecret},
+		{"custom_headers", headers, &opts.CustomHeaders},
+		{"cookies", cookies, &opts.Cookies},
+	} {
+		if field.value == "" {
+			continue
+		}
+		decrypted, err := crypto.Decrypt(field.value)
+		if err != nil {
+			return opts, fmt.Errorf("failed to decrypt %s of feed %d: %w", field.name, feedID, err)
+		}
+		*field.dst = decrypted
+	}
+	return opts, nil
+}
+
+// UpdateFeedRequestOptions stores the request options of a feed, encrypting the secret ones.
+func (db *DB) UpdateFeedRequestOptions(feedID int64, opts models.FeedRequestOptions) error {
+	db.WaitForReady()
+	secret, err := crypto.Encrypt(opts.AuthSecret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt auth secret: %w", err)
+	}
+	headers, err := crypto.Encrypt(opts.CustomHeaders)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt custom headers: %w", err)
+	}
+	cookies, err := crypto.Encrypt(opts.Cookies)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt cookies: %w", err)
+	}
+	_, err = db.Exec(`UPDATE feeds SET auth_type = ?, auth_username = ?, auth_secret = ?, custom_headers = ?, cookies = ?, user_agent = ? WHERE id = ?`,
+		opts.AuthType, opts.AuthUsername, secret, headers, cookies, opts.UserAgent, feedID)
+	return err
+}
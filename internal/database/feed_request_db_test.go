@@ -0,0 +1,61 @@
+package database_test
+
+import (
+	"testing"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/models"
+)
+
+func TestFeedRequestOptions_EncryptedAtRest(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, err := db.AddFeed(&models.Feed{Title: "Private", URL: "https://private.example/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+
+	opts := models.FeedRequestOptions{
+		AuthType:      models.FeedAuthBearer,
+		AuthSecret:    "token-123",
+		CustomHeaders: `{"X-Api-Key": "k1"}`,
+		Cookies:       "session=abc",
+		UserAgent:     "PrivateReader/1.0",
+	}
+	if err := db.UpdateFeedRequestOptions(feedID, opts); err != nil {
+		t.Fatalf("UpdateFeedRequestOptions error: %v", err)
+	}
+
+	var secret, headers, cookies string
+	if err := db.QueryRow(`SELECT auth_secret, custom_headers, cookies FROM feeds WHERE id = ?`, feedID).Scan(&secret, &headers, &cookies); err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	for _, stored := range []string{secret, headers, cookies} {
+		if !crypto.IsEncrypted(stored) {
+			t.Errorf("value stored in plain text: %q", stored)
+		}
+	}
+
+	got, err := db.GetFeedRequestOptions(feedID)
+	if err != nil {
+		t.Fatalf("GetFeedRequestOptions error: %v", err)
+	}
+	if got != opts {
+		t.Errorf("GetFeedRequestOptions = %+v, want %+v", got, opts)
+	}
+
+	feed, err := db.GetFeedByID(feedID)
+	if err != nil {
+		t.Fatalf("GetFeedByID error: %v", err)
+	}
+	if feed.AuthType != models.FeedAuthBearer || feed.UserAgent != "PrivateReader/1.0" || !feed.HasRequestSecrets {
+		t.Errorf("feed request options = %q %q %v", feed.AuthType, feed.UserAgent, feed.HasRequestSecrets)
+	}
+
+	if err := db.UpdateFeedRequestOptions(feedID, models.FeedRequestOptions{}); err != nil {
+		t.Fatalf("clearing options error: %v", err)
+	}
+	feed, _ = db.GetFeedByID(feedID)
+	if feed.HasRequestSecrets || feed.AuthType != "" {
+		t.Errorf("options not cleared: %+v", feed)
+	}
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/models"
+)
+
+// GetFeedScriptOptions returns the script options of a feed with the environment decrypted.
+func (db *DB) GetFeedScriptOptions(feedID int64) (models.FeedScriptOptions, error) {
+	db.WaitForReady()
+	var opts models.FeedScriptOptions
+	var args, env string
+	err := db.QueryRow(`SELECT COALESCE(script_args, ''), COALESCE(script_env, ''), COALESCE(script_timeout, 0) FROM feeds WHERE id = ?`, feedID).
+		Scan(&args, &env, &opts.Timeout)
+	if err != nil {
+		return opts, err
+	}
+	opts.Args = decodeScriptArgs(args)
+
+	if env != "" {
+		decrypted, err := crypto.Decrypt(env)
+		if err != nil {
+			return opts, fmt.Errorf("failed to decrypt script_env of feed %d: %w", feedID, err)
+		}
+		if err := json.Unmarshal([]byte(decrypted), &opts.Env); err != nil {
+			return opts, fmt.Errorf("invalid script_env of feed %d: %w", feedID, err)
+		}
+	}
+	return opts, nil
+}
+
+// UpdateFeedScriptOptions stores the script options of a feed, encrypting the environment.
+func (db *DB) UpdateFeedScriptOptions(feedID int64, opts models.FeedScriptOptions) error {
+	db.WaitForReady()
+	var args, env string
+	if len(opts.Args) > 0 {
+		data, err := json.Marshal(opts.Args)
+		if err != nil {
+			return err
+		}
+		args = string(data)
+	}
+	if len(opts.Env) > 0 {
+		data, err := json.Marshal(opts.Env)
+		if err != nil {
+			return err
+		}
+		if env, err = crypto.Encrypt(string(data)); err != nil {
+			return fmt.Errorf("failed to encrypt script environment: %w", err)
+		}
+	}
+	_, err := db.Exec(`UPDATE feeds SET script_args = ?, script_env = ?, script_timeout = ? WHERE id = ?`, args, env, opts.Timeout, feedID)
+	return err
+}
+
+// decodeScriptArgs decodes the JSON array stored in feeds.script_args, ignoring invalid values.
+func decodeScriptArgs(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var args []string
+	if err := json.Unmarshal([]byte(value), &args); err != nil {
+		return nil
+	}
+	return args
+}
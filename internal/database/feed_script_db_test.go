@@ -0,0 +1,58 @@
+package database_test
+
+import (
+	"reflect"
+	"testing"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/models"
+)
+
+func TestFeedScriptOptions_EnvEncryptedAtRest(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, err := db.AddFeed(&models.Feed{Title: "Script", URL: "script://fetch.py?arg=golang", ScriptPath: "fetch.py"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+
+	opts := models.FeedScriptOptions{
+		Args:    []string{"golang", "--limit=5"},
+		Env:     map[string]string{"API_KEY": "k1"},
+		Timeout: 90,
+	}
+	if err := db.UpdateFeedScriptOptions(feedID, opts); err != nil {
+		t.Fatalf("UpdateFeedScriptOptions error: %v", err)
+	}
+
+	var env string
+	if err := db.QueryRow(`SELECT script_env FROM feeds WHERE id = ?`, feedID).Scan(&env); err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	if !crypto.IsEncrypted(env) {
+		t.Errorf("environment stored in plain text: %q", env)
+	}
+
+	got, err := db.GetFeedScriptOptions(feedID)
+	if err != nil {
+		t.Fatalf("GetFeedScriptOptions error: %v", err)
+	}
+	if !reflect.DeepEqual(got, opts) {
+		t.Errorf("GetFeedScriptOptions = %+v, want %+v", got, opts)
+	}
+
+	feed, err := db.GetFeedByID(feedID)
+	if err != nil {
+		t.Fatalf("GetFeedByID error: %v", err)
+	}
+	if !reflect.DeepEqual(feed.ScriptArgs, opts.Args) || feed.ScriptTimeout != 90 || !feed.HasScriptEnv {
+		t.Errorf("feed script options = %v %d %v", feed.ScriptArgs, feed.ScriptTimeout, feed.HasScriptEnv)
+	}
+
+	if err := db.UpdateFeedScriptOptions(feedID, models.FeedScriptOptions{}); err != nil {
+		t.Fatalf("clearing options error: %v", err)
+	}
+	feed, _ = db.GetFeedByID(feedID)
+	if feed.ScriptArgs != nil || feed.ScriptTimeout != 0 || feed.HasScriptEnv {
+		t.Errorf("options not cleared: %+v", feed)
+	}
+}
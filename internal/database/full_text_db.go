@@ -0,0 +1,166 @@
+package database
+
+import (
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// FullTextJob is an article waiting for its full text to be fetched.
+type FullTextJob struct {
+	ArticleID int64
+	FeedID    int64
+	URL       string
+	Attempts  int
+}
+
+// QueueFullTextFetch marks articles as waiting for their full text. Articles already queued or
+// fetched are left as they are.
+func (db *DB) QueueFullTextFetch(articleIDs []int64) error {
+	if len(articleIDs) == 0 {
+		return nil
+	}
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, id := range articleIDs {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO article_full_text (article_id, status, next_attempt_at, updated_at) VALUES (?, ?, ?, ?)`,
+			id, models.FullTextPending, now, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDueFullTextJobs returns up to limit pending articles whose next attempt is due, longest waiting first.
+func (db *DB) GetDueFullTextJobs(limit int) ([]FullTextJob, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT t.article_id, a.feed_id, a.url, t.attempts FROM article_full_text t
+		JOIN articles a ON a.id = t.article_id
+		WHERE t.status = ? AND (t.next_attempt_at IS NULL OR t.next_attempt_at <= ?)
+		ORDER BY t.next_attempt_at ASC, t.article_id ASC LIMIT ?`, models.FullTextPending, time.Now(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []FullTextJob
+	for rows.Next() {
+		var job FullTextJob
+		if err := rows.Scan(&job.ArticleID, &job.FeedID, &job.URL, &job.Attempts); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// SetFullTextContent stores the fetched full text as the article's content. The feed's version
+// is kept aside and the content hash keeps describing it, so revisions of the item compare
+// feed versions and the next refresh does not see a revision.
+func (db *DB) SetFullTextContent(articleID int64, content string) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE article_full_text SET status = ?, attempts = attempts + 1, error = '', next_attempt_at = NULL, updated_at = ?,
+		feed_content = (SELECT COALESCE(content, '') FROM articles WHERE id = ?) WHERE article_id = ?`,
+		models.FullTextOK, time.Now(), articleID, articleID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE articles SET content = ? WHERE id = ?`, content, articleID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetFullTextFailure records a failed attempt. The article is tried again at retryAt, or marked
+// failed if retryAt is zero.
+func (db *DB) SetFullTextFailure(articleID int64, errMsg string, retryAt time.Time) error {
+	db.WaitForReady()
+	status := models.FullTextPending
+	if retryAt.IsZero() {
+		status = models.FullTextFailed
+	}
+	_, err := db.Exec(`UPDATE article_full_text SET status = ?, attempts = attempts + 1, error = ?, next_attempt_at = ?, updated_at = ? WHERE article_id = ?`,
+		status, errMsg, nullTime(retryAt), time.Now(), articleID)
+	return err
+}
+
+// DeferFullTextFetch postpones a pending article to until without counting an attempt.
+func (db *DB) DeferFullTextFetch(articleID int64, until time.Time) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE article_full_text SET next_attempt_at = ?, updated_at = ? WHERE article_id = ? AND status = ?`,
+		until, time.Now(), articleID, models.FullTextPending)
+	return err
+}
+
+// GetFullTextStatus returns the full-text fetch status of an article, or nil if it was never queued.
+func (db *DB) GetFullTextStatus(articleID int64) (*models.FullTextStatus, error) {
+	statuses, err := db.getFullTextStatuses([]int64{articleID})
+	if err != nil {
+		return nil, err
+	}
+	return statuses[articleID], nil
+}
+
+// AttachFullTextStatus loads the full-text fetch status of the given articles into their FullText field.
+func (db *DB) AttachFullTextStatus(articles []models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+	statuses, err := db.getFullTextStatuses(ids)
+	if err != nil {
+		return err
+	}
+	for i := range articles {
+		articles[i].FullText = statuses[articles[i].ID]
+	}
+	return nil
+}
+
+func (db *DB) getFullTextStatuses(articleIDs []int64) (map[int64]*models.FullTextStatus, error) {
+	db.WaitForReady()
+	result := make(map[int64]*models.FullTextStatus)
+	err := queryInChunks(articleIDs, func(placeholders string, args []interface{}) error {
+		rows, err := db.Query(`SELECT article_id, status, COALESCE(attempts, 0), COALESCE(error, ''), updated_at FROM article_full_text WHERE article_id IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var s models.FullTextStatus
+			if err := rows.Scan(&s.ArticleID, &s.Status, &s.Attempts, &s.Error, &s.UpdatedAt); err != nil {
+				return err
+			}
+			result[s.ArticleID] = &s
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// SetFeedFetchFullText sets whether the full text of a feed's new articles is fetched automatically.
+func (db *DB) SetFeedFetchFullText(feedID int64, enabled bool) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET fetch_full_text = ? WHERE id = ?`, enabled, feedID)
+	return err
+}
+
+// deleteOrphanedFullText removes full-text statuses whose article no longer exists.
+func (db *DB) deleteOrphanedFullText() error {
+	_, err := db.Exec(`DELETE FROM article_full_text WHERE article_id NOT IN (SELECT id FROM articles)`)
+	return err
+}
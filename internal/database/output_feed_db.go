@@ -0,0 +1,131 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+const outputFeedSelect = `SELECT id, name, token, source, COALESCE(source_value, ''), COALESCE(conditions, ''), COALESCE(max_items, 50), created_at FROM output_feeds`
+
+func scanOutputFeed(row rowScanner) (*models.OutputFeed, error) {
+	var of models.OutputFeed
+	if err := row.Scan(&of.ID, &of.Name, &of.Token, &of.Source, &of.SourceValue, &of.Conditions, &of.Limit, &of.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &of, nil
+}
+
+// CreateOutputFeed stores a new output feed and returns its ID.
+func (db *DB) CreateOutputFeed(of *models.OutputFeed) (int64, error) {
+	db.WaitForReady()
+	res, err := db.Exec(`INSERT INTO output_feeds (name, token, source, source_value, conditions, max_items, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		of.Name, of.Token, of.Source, of.SourceValue, of.Conditions, of.Limit, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateOutputFeed updates the name and article selection of an output feed. The token is kept.
+func (db *DB) UpdateOutputFeed(of *models.OutputFeed) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE output_feeds SET name = ?, source = ?, source_value = ?, conditions = ?, max_items = ? WHERE id = ?`,
+		of.Name, of.Source, of.SourceValue, of.Conditions, of.Limit, of.ID)
+	return err
+}
+
+// SetOutputFeedToken replaces the token of an output feed, revoking access through the old one.
+func (db *DB) SetOutputFeedToken(id int64, token string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE output_feeds SET token = ? WHERE id = ?`, token, id)
+	return err
+}
+
+// DeleteOutputFeed deletes an output feed.
+func (db *DB) DeleteOutputFeed(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM output_feeds WHERE id = ?`, id)
+	return err
+}
+
+// GetOutputFeeds returns all output feeds.
+func (db *DB) GetOutputFeeds() ([]models.OutputFeed, error) {
+	db.WaitForReady()
+	rows, err := db.Query(outputFeedSelect + ` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outputs := []models.OutputFeed{}
+	for rows.Next() {
+		of, err := scanOutputFeed(rows)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, *of)
+	}
+	return outputs, rows.Err()
+}
+
+// GetOutputFeedByID returns an output feed, or nil if there is none with this ID.
+func (db *DB) GetOutputFeedByID(id int64) (*models.OutputFeed, error) {
+	db.WaitForReady()
+	of, err := scanOutputFeed(db.QueryRow(outputFeedSelect+` WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return of, err
+}
+
+// GetOutputFeedByToken returns the output feed with the given token, or nil if there is none.
+func (db *DB) GetOutputFeedByToken(token string) (*models.OutputFeed, error) {
+	db.WaitForReady()
+	if token == "" {
+		return nil, nil
+	}
+	of, err := scanOutputFeed(db.QueryRow(outputFeedSelect+` WHERE token = ?`, token))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return of, err
+}
+
+// GetArticleContents returns the stored content of the given articles, keyed by article ID.
+// Articles without content are left out.
+func (db *DB) GetArticleContents(articleIDs []int64) (map[int64]string, error) {
+	db.WaitForReady()
+	result := make(map[int64]string)
+
+	// Query in chunks to stay below SQLite's bound parameter limit
+	const chunkSize = 500
+	for start := 0; start < len(articleIDs); start += chunkSize {
+		end := min(start+chunkSize, len(articleIDs))
+		chunk := articleIDs[start:end]
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ",")
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		rows, err := db.Query(`SELECT id, content FROM articles WHERE content IS NOT NULL AND content != '' AND id IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int64
+			var content string
+			if err := rows.Scan(&id, &content); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			result[id] = content
+		}
+		rows.Close()
+	}
+	return result, nil
+}
@@ -0,0 +1,342 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// ErrNoEpisodeAudio is returned when downloading an article that has no audio.
+var ErrNoEpisodeAudio = errors.New("article has no audio")
+
+// insertPodcastEpisodeQuery stores the episode details of a newly inserted article.
+const insertPodcastEpisodeQuery = `INSERT OR IGNORE INTO podcast_episodes (article_id, duration, episode, season, episode_type, chapters_url, chapters_type, chapters) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+// rankedEpisodesQuery numbers the episodes of each feed, newest first.
+const rankedEpisodesQuery = `SELECT id, feed_id, ROW_NUMBER() OVER (PARTITION BY feed_id ORDER BY published_at DESC, id DESC) AS rn
+	FROM articles WHERE COALESCE(audio_url, '') != ''`
+
+const episodeDownloadColumns = `d.article_id, COALESCE(a.feed_id, 0), COALESCE(a.title, ''), d.audio_url, d.status, COALESCE(d.file_path, ''), COALESCE(d.size, 0), COALESCE(d.error, ''), d.queued_at, d.completed_at`
+
+// savePodcastEpisode stores the episode details parsed from the feed for a new article.
+func savePodcastEpisode(ctx context.Context, exec articleExecer, articleID int64, ep *models.PodcastEpisode) error {
+	_, err := exec.ExecContext(ctx, insertPodcastEpisodeQuery, articleID, ep.Duration, ep.Episode, ep.Season, ep.EpisodeType, ep.ChaptersURL, ep.ChaptersType, encodeChapters(ep.Chapters))
+	return err
+}
+
+func encodeChapters(chapters []models.Chapter) string {
+	if len(chapters) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(chapters)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func decodeChapters(value string) []models.Chapter {
+	if value == "" {
+		return nil
+	}
+	var chapters []models.Chapter
+	if err := json.Unmarshal([]byte(value), &chapters); err != nil {
+		return nil
+	}
+	return chapters
+}
+
+// GetPodcastEpisode returns the episode details, playback progress and download of an article.
+// Articles with audio but no details from the feed get an episode with only progress fields.
+func (db *DB) GetPodcastEpisode(articleID int64) (*models.PodcastEpisode, error) {
+	db.WaitForReady()
+	var audioURL string
+	if err := db.QueryRow(`SELECT COALESCE(audio_url, '') FROM articles WHERE id = ?`, articleID).Scan(&audioURL); err != nil {
+		return nil, err
+	}
+	episodes, err := db.getPodcastEpisodes([]int64{articleID})
+	if err != nil {
+		return nil, err
+	}
+	ep, ok := episodes[articleID]
+	if !ok {
+		if audioURL == "" {
+			return nil, ErrNoEpisodeAudio
+		}
+		ep = &models.PodcastEpisode{ArticleID: articleID}
+	}
+	if ep.Download, err = db.GetEpisodeDownload(articleID); err != nil {
+		return nil, err
+	}
+	return ep, nil
+}
+
+// AttachPodcastEpisodes loads the episode details and downloads of the given articles that have audio into their Podcast field.
+func (db *DB) AttachPodcastEpisodes(articles []models.Article) error {
+	var ids []int64
+	for _, a := range articles {
+		if a.AudioURL != "" {
+			ids = append(ids, a.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	db.WaitForReady()
+	episodes, err := db.getPodcastEpisodes(ids)
+	if err != nil {
+		return err
+	}
+	downloads, err := db.getEpisodeDownloads(ids)
+	if err != nil {
+		return err
+	}
+	for i := range articles {
+		if articles[i].AudioURL == "" {
+			continue
+		}
+		ep, ok := episodes[articles[i].ID]
+		if !ok {
+			ep = &models.PodcastEpisode{ArticleID: articles[i].ID}
+		}
+		ep.Download = downloads[articles[i].ID]
+		articles[i].Podcast = ep
+	}
+	return nil
+}
+
+// getPodcastEpisodes returns the stored episodes of the given articles keyed by article ID.
+func (db *DB) getPodcastEpisodes(articleIDs []int64) (map[int64]*models.PodcastEpisode, error) {
+	result := make(map[int64]*models.PodcastEpisode)
+	err := queryInChunks(articleIDs, func(placeholders string, args []interface{}) error {
+		rows, err := db.Query(`SELECT article_id, COALESCE(duration, 0), COALESCE(episode, 0), COALESCE(season, 0), COALESCE(episode_type, ''), COALESCE(chapters_url, ''), COALESCE(chapters_type, ''), COALESCE(chapters, ''), COALESCE(position, 0), COALESCE(played, 0), progress_at
+			FROM podcast_episodes WHERE article_id IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var ep models.PodcastEpisode
+			var chapters string
+			var progressAt sql.NullTime
+			if err := rows.Scan(&ep.ArticleID, &ep.Duration, &ep.Episode, &ep.Season, &ep.EpisodeType, &ep.ChaptersURL, &ep.ChaptersType, &chapters, &ep.Position, &ep.Played, &progressAt); err != nil {
+				return err
+			}
+			ep.Chapters = decodeChapters(chapters)
+			if progressAt.Valid {
+				ep.ProgressAt = progressAt.Time
+			}
+			result[ep.ArticleID] = &ep
+		}
+		return rows.Err()
+	})
+	return result, err
+}
+
+// SetEpisodeProgress stores the playback position and/or played flag of an episode; nil values are left unchanged.
+func (db *DB) SetEpisodeProgress(articleID int64, position *float64, played *bool) error {
+	db.WaitForReady()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO podcast_episodes (article_id) VALUES (?)`, articleID); err != nil {
+		return err
+	}
+	if position != nil {
+		if _, err := tx.Exec(`UPDATE podcast_episodes SET position = ?, progress_at = ? WHERE article_id = ?`, *position, time.Now(), articleID); err != nil {
+			return err
+		}
+	}
+	if played != nil {
+		if _, err := tx.Exec(`UPDATE podcast_episodes SET played = ?, progress_at = ? WHERE article_id = ?`, *played, time.Now(), articleID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteOrphanedPodcastEpisodes removes episode details whose article no longer exists.
+// Downloads are left to the episode downloader, which also removes their files.
+func (db *DB) deleteOrphanedPodcastEpisodes() error {
+	_, err := db.Exec(`DELETE FROM podcast_episodes WHERE article_id NOT IN (SELECT id FROM articles)`)
+	return err
+}
+
+// SetFeedKeepEpisodes sets how many of a feed's newest episodes are downloaded and kept (0 = manual downloads).
+func (db *DB) SetFeedKeepEpisodes(feedID int64, keep int) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feeds SET keep_episodes = ? WHERE id = ?`, keep, feedID)
+	return err
+}
+
+// QueueEpisodeDownload queues the audio of an article for download. Failed downloads are queued again;
+// queued, running and finished ones are left as they are.
+func (db *DB) QueueEpisodeDownload(articleID int64) error {
+	db.WaitForReady()
+	var audioURL string
+	if err := db.QueryRow(`SELECT COALESCE(audio_url, '') FROM articles WHERE id = ?`, articleID).Scan(&audioURL); err != nil {
+		return err
+	}
+	if audioURL == "" {
+		return ErrNoEpisodeAudio
+	}
+	_, err := db.Exec(`INSERT INTO episode_downloads (article_id, audio_url, status, queued_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(article_id) DO UPDATE SET status = excluded.status, error = '', audio_url = excluded.audio_url, queued_at = excluded.queued_at
+		WHERE episode_downloads.status = ?`,
+		articleID, audioURL, models.EpisodeDownloadQueued, time.Now(), models.EpisodeDownloadFailed)
+	return err
+}
+
+// NextQueuedEpisodeDownload returns the download queued first, or nil if the queue is empty.
+func (db *DB) NextQueuedEpisodeDownload() (*models.EpisodeDownload, error) {
+	db.WaitForReady()
+	downloads, err := db.queryEpisodeDownloads(`WHERE d.status = ? ORDER BY d.queued_at ASC, d.article_id ASC LIMIT 1`, models.EpisodeDownloadQueued)
+	if err != nil || len(downloads) == 0 {
+		return nil, err
+	}
+	return &downloads[0], nil
+}
+
+// SetEpisodeDownloadState records the progress or outcome of a download.
+func (db *DB) SetEpisodeDownloadState(articleID int64, status, filePath string, size int64, errMsg string) error {
+	db.WaitForReady()
+	var completedAt interface{}
+	if status == models.EpisodeDownloadDone {
+		completedAt = time.Now()
+	}
+	_, err := db.Exec(`UPDATE episode_downloads SET status = ?, file_path = ?, size = ?, error = ?, completed_at = ? WHERE article_id = ?`,
+		status, filePath, size, errMsg, completedAt, articleID)
+	return err
+}
+
+// ResetRunningEpisodeDownloads queues downloads that were interrupted, e.g. by quitting the app.
+func (db *DB) ResetRunningEpisodeDownloads() error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE episode_downloads SET status = ? WHERE status = ?`, models.EpisodeDownloadQueued, models.EpisodeDownloadDownloading)
+	return err
+}
+
+// GetEpisodeDownload returns the download of an article, or nil if it has none.
+func (db *DB) GetEpisodeDownload(articleID int64) (*models.EpisodeDownload, error) {
+	db.WaitForReady()
+	downloads, err := db.queryEpisodeDownloads(`WHERE d.article_id = ?`, articleID)
+	if err != nil || len(downloads) == 0 {
+		return nil, err
+	}
+	return &downloads[0], nil
+}
+
+// GetEpisodeDownloads returns all downloads, most recently queued first.
+func (db *DB) GetEpisodeDownloads() ([]models.EpisodeDownload, error) {
+	db.WaitForReady()
+	return db.queryEpisodeDownloads(`ORDER BY d.queued_at DESC, d.article_id DESC`)
+}
+
+// DeleteEpisodeDownload removes the download record of an article. The caller deletes the file.
+func (db *DB) DeleteEpisodeDownload(articleID int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM episode_downloads WHERE article_id = ?`, articleID)
+	return err
+}
+
+// GetEpisodesToAutoDownload returns the newest episodes of feeds with a keep-episodes policy
+// that are neither downloaded, queued nor played.
+func (db *DB) GetEpisodesToAutoDownload() ([]int64, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`WITH ranked AS (` + rankedEpisodesQuery + `)
+		SELECT r.id FROM ranked r
+		JOIN feeds f ON f.id = r.feed_id
+		WHERE COALESCE(f.keep_episodes, 0) > 0 AND r.rn <= f.keep_episodes
+		AND r.id NOT IN (SELECT article_id FROM episode_downloads)
+		AND r.id NOT IN (SELECT article_id FROM podcast_episodes WHERE played = 1)
+		ORDER BY r.feed_id, r.rn`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetExpiredEpisodeDownloads returns downloads whose article was deleted, or that are no longer
+// among the newest episodes their feed keeps.
+func (db *DB) GetExpiredEpisodeDownloads() ([]models.EpisodeDownload, error) {
+	db.WaitForReady()
+	return db.queryEpisodeDownloads(`
+		LEFT JOIN (` + rankedEpisodesQuery + `) r ON r.id = d.article_id
+		LEFT JOIN feeds f ON f.id = r.feed_id
+		WHERE a.id IS NULL OR (COALESCE(f.keep_episodes, 0) > 0 AND r.rn > f.keep_episodes)`)
+}
+
+// queryEpisodeDownloads selects downloads joined with their article; clause follows the FROM clause.
+func (db *DB) queryEpisodeDownloads(clause string, args ...interface{}) ([]models.EpisodeDownload, error) {
+	rows, err := db.Query(`SELECT `+episodeDownloadColumns+` FROM episode_downloads d LEFT JOIN articles a ON a.id = d.article_id `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var downloads []models.EpisodeDownload
+	for rows.Next() {
+		d, err := scanEpisodeDownload(rows)
+		if err != nil {
+			return nil, err
+		}
+		downloads = append(downloads, d)
+	}
+	return downloads, rows.Err()
+}
+
+// getEpisodeDownloads returns the downloads of the given articles keyed by article ID.
+func (db *DB) getEpisodeDownloads(articleIDs []int64) (map[int64]*models.EpisodeDownload, error) {
+	result := make(map[int64]*models.EpisodeDownload)
+	err := queryInChunks(articleIDs, func(placeholders string, args []interface{}) error {
+		downloads, err := db.queryEpisodeDownloads(`WHERE d.article_id IN (`+placeholders+`)`, args...)
+		for i := range downloads {
+			result[downloads[i].ArticleID] = &downloads[i]
+		}
+		return err
+	})
+	return result, err
+}
+
+func scanEpisodeDownload(rows *sql.Rows) (models.EpisodeDownload, error) {
+	var d models.EpisodeDownload
+	var completedAt sql.NullTime
+	err := rows.Scan(&d.ArticleID, &d.FeedID, &d.ArticleTitle, &d.AudioURL, &d.Status, &d.FilePath, &d.Size, &d.Error, &d.QueuedAt, &completedAt)
+	if completedAt.Valid {
+		d.CompletedAt = completedAt.Time
+	}
+	return d, err
+}
+
+// queryInChunks calls query with placeholders and arguments for chunks of ids, staying below SQLite's bound parameter limit.
+func queryInChunks(ids []int64, query func(placeholders string, args []interface{}) error) error {
+	const chunkSize = 500
+	for start := 0; start < len(ids); start += chunkSize {
+		chunk := ids[start:min(start+chunkSize, len(ids))]
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		if err := query(strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ","), args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
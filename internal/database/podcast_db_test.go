@@ -0,0 +1,144 @@
+package database_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	dbpkg "MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestPodcastEpisode_SavedWithArticleAndProgress(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, err := db.AddFeed(&models.Feed{Title: "Show", URL: "https://example.com/podcast.xml"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+
+	article := &models.Article{
+		FeedID: feedID, Title: "Episode 12", URL: "https://example.com/ep12", AudioURL: "https://example.com/ep12.mp3", PublishedAt: time.Now(),
+		Podcast: &models.PodcastEpisode{
+			Duration: 3723, Episode: 12, Season: 2, EpisodeType: "full",
+			ChaptersURL: "https://example.com/ep12.json", ChaptersType: "application/json+chapters",
+			Chapters: []models.Chapter{{Start: 0, Title: "Intro"}, {Start: 95.5, Title: "News", URL: "https://example.com/news"}},
+		},
+	}
+	if err := db.SaveArticle(article); err != nil {
+		t.Fatalf("SaveArticle error: %v", err)
+	}
+
+	ep, err := db.GetPodcastEpisode(article.ID)
+	if err != nil {
+		t.Fatalf("GetPodcastEpisode error: %v", err)
+	}
+	if ep.Duration != 3723 || ep.Episode != 12 || ep.Season != 2 || ep.EpisodeType != "full" || ep.ChaptersURL == "" {
+		t.Errorf("episode = %+v", ep)
+	}
+	if len(ep.Chapters) != 2 || ep.Chapters[1].Start != 95.5 || ep.Chapters[1].URL != "https://example.com/news" {
+		t.Errorf("chapters = %+v", ep.Chapters)
+	}
+
+	position := 612.5
+	if err := db.SetEpisodeProgress(article.ID, &position, nil); err != nil {
+		t.Fatalf("SetEpisodeProgress error: %v", err)
+	}
+	played := true
+	if err := db.SetEpisodeProgress(article.ID, nil, &played); err != nil {
+		t.Fatalf("SetEpisodeProgress error: %v", err)
+	}
+	ep, _ = db.GetPodcastEpisode(article.ID)
+	if ep.Position != 612.5 || !ep.Played || ep.ProgressAt.IsZero() || ep.Duration != 3723 {
+		t.Errorf("progress = %+v", ep)
+	}
+
+	articles := []models.Article{{ID: article.ID, AudioURL: article.AudioURL}, {ID: 999}}
+	if err := db.AttachPodcastEpisodes(articles); err != nil {
+		t.Fatalf("AttachPodcastEpisodes error: %v", err)
+	}
+	if articles[0].Podcast == nil || articles[0].Podcast.Position != 612.5 || articles[1].Podcast != nil {
+		t.Errorf("attached = %+v, %+v", articles[0].Podcast, articles[1].Podcast)
+	}
+}
+
+func TestPodcastEpisode_ProgressWithoutFeedDetails(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Show", URL: "https://example.com/podcast.xml"})
+	audio := &models.Article{FeedID: feedID, Title: "Bare", URL: "https://example.com/bare", AudioURL: "https://example.com/bare.mp3", PublishedAt: time.Now()}
+	text := &models.Article{FeedID: feedID, Title: "Post", URL: "https://example.com/post", PublishedAt: time.Now()}
+	for _, a := range []*models.Article{audio, text} {
+		if err := db.SaveArticle(a); err != nil {
+			t.Fatalf("SaveArticle error: %v", err)
+		}
+	}
+
+	position := 30.0
+	if err := db.SetEpisodeProgress(audio.ID, &position, nil); err != nil {
+		t.Fatalf("SetEpisodeProgress error: %v", err)
+	}
+	ep, err := db.GetPodcastEpisode(audio.ID)
+	if err != nil || ep.Position != 30 || ep.Played {
+		t.Errorf("GetPodcastEpisode = %+v, %v", ep, err)
+	}
+
+	if _, err := db.GetPodcastEpisode(text.ID); !errors.Is(err, dbpkg.ErrNoEpisodeAudio) {
+		t.Errorf("episode of a text article: err = %v", err)
+	}
+	if err := db.QueueEpisodeDownload(text.ID); !errors.Is(err, dbpkg.ErrNoEpisodeAudio) {
+		t.Errorf("queueing a text article: err = %v", err)
+	}
+}
+
+func TestEpisodeDownloads_KeepNewestEpisodes(t *testing.T) {
+	db := setupTestDB(t)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Show", URL: "https://example.com/podcast.xml"})
+	base := time.Now().Add(-24 * time.Hour)
+	var ids []int64
+	for i := 0; i < 4; i++ {
+		a := &models.Article{FeedID: feedID, Title: "Episode", URL: fmt.Sprintf("https://example.com/ep%d", i), AudioURL: "https://example.com/ep.mp3", PublishedAt: base.Add(time.Duration(i) * time.Hour)}
+		if err := db.SaveArticle(a); err != nil {
+			t.Fatalf("SaveArticle error: %v", err)
+		}
+		ids = append(ids, a.ID)
+	}
+
+	if got, _ := db.GetEpisodesToAutoDownload(); len(got) != 0 {
+		t.Errorf("episodes queued without a keep policy: %v", got)
+	}
+	if err := db.SetFeedKeepEpisodes(feedID, 2); err != nil {
+		t.Fatalf("SetFeedKeepEpisodes error: %v", err)
+	}
+	played := true
+	_ = db.SetEpisodeProgress(ids[2], nil, &played)
+
+	got, err := db.GetEpisodesToAutoDownload()
+	if err != nil {
+		t.Fatalf("GetEpisodesToAutoDownload error: %v", err)
+	}
+	if len(got) != 1 || got[0] != ids[3] {
+		t.Errorf("auto downloads = %v, want [%d] (newest unplayed)", got, ids[3])
+	}
+
+	// An older episode downloaded by hand falls outside the two newest
+	for _, id := range []int64{ids[0], ids[3]} {
+		if err := db.QueueEpisodeDownload(id); err != nil {
+			t.Fatalf("QueueEpisodeDownload error: %v", err)
+		}
+	}
+	next, err := db.NextQueuedEpisodeDownload()
+	if err != nil || next == nil || next.ArticleID != ids[0] || next.FeedID != feedID {
+		t.Fatalf("NextQueuedEpisodeDownload = %+v, %v", next, err)
+	}
+	if err := db.SetEpisodeDownloadState(ids[0], models.EpisodeDownloadDone, "/tmp/ep.mp3", 10, ""); err != nil {
+		t.Fatalf("SetEpisodeDownloadState error: %v", err)
+	}
+
+	expired, err := db.GetExpiredEpisodeDownloads()
+	if err != nil {
+		t.Fatalf("GetExpiredEpisodeDownloads error: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ArticleID != ids[0] || expired[0].FilePath != "/tmp/ep.mp3" {
+		t.Errorf("expired = %+v", expired)
+	}
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"database/sql"
+
+	"MrRSS/internal/models"
+)
+
+// GetRefreshPolicies returns the global policy, if set, and the category policies, ordered by category.
+func (db *DB) GetRefreshPolicies() ([]models.RefreshPolicy, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT category, COALESCE(quiet_start, ''), COALESCE(quiet_end, ''), COALESCE(quiet_mode, ''),
+		COALESCE(quiet_slow_factor, 0), COALESCE(low_data, 0), COALESCE(daily_budget_mb, 0)
+		FROM refresh_policies ORDER BY category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []models.RefreshPolicy
+	for rows.Next() {
+		var p models.RefreshPolicy
+		if err := rows.Scan(&p.Category, &p.QuietStart, &p.QuietEnd, &p.QuietMode, &p.QuietSlowFactor, &p.LowData, &p.DailyBudgetMB); err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// SaveRefreshPolicy creates or replaces the policy of p.Category, or the global policy if it is "".
+func (db *DB) SaveRefreshPolicy(p models.RefreshPolicy) error {
+	db.WaitForReady()
+	_, err := db.Exec(`INSERT INTO refresh_policies (category, quiet_start, quiet_end, quiet_mode, quiet_slow_factor, low_data, daily_budget_mb)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(category) DO UPDATE SET quiet_start = excluded.quiet_start, quiet_end = excluded.quiet_end,
+			quiet_mode = excluded.quiet_mode, quiet_slow_factor = excluded.quiet_slow_factor, low_data = excluded.low_data,
+			daily_budget_mb = excluded.daily_budget_mb`,
+		p.Category, p.QuietStart, p.QuietEnd, p.QuietMode, p.QuietSlowFactor, p.LowData, p.DailyBudgetMB)
+	return err
+}
+
+// DeleteRefreshPolicy removes the policy of a category. It returns sql.ErrNoRows if there is none.
+func (db *DB) DeleteRefreshPolicy(category string) error {
+	db.WaitForReady()
+	result, err := db.Exec(`DELETE FROM refresh_policies WHERE category = ?`, category)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AddDataUsage adds downloaded bytes to a category's usage of a day (YYYY-MM-DD).
+func (db *DB) AddDataUsage(day, category string, bytes int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`INSERT INTO data_usage (day, category, bytes) VALUES (?, ?, ?)
+		ON CONFLICT(day, category) DO UPDATE SET bytes = bytes + excluded.bytes`, day, category, bytes)
+	return err
+}
+
+// GetDataUsage returns the bytes downloaded on a day (YYYY-MM-DD) keyed by category.
+func (db *DB) GetDataUsage(day string) (map[string]int64, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`SELECT category, bytes FROM data_usage WHERE day = ?`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make(map[string]int64)
+	for rows.Next() {
+		var category string
+		var bytes int64
+		if err := rows.Scan(&category, &bytes); err != nil {
+			return nil, err
+		}
+		usage[category] = bytes
+	}
+	return usage, rows.Err()
+}
+
+// DeleteDataUsageBefore removes the usage of days before day (YYYY-MM-DD).
+func (db *DB) DeleteDataUsageBefore(day string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM data_usage WHERE day < ?`, day)
+	return err
+}
+
+// GetArticleCategory returns the category of an article's feed.
+func (db *DB) GetArticleCategory(articleID int64) (string, error) {
+	db.WaitForReady()
+	var category string
+	err := db.QueryRow(`SELECT COALESCE(f.category, '') FROM articles a JOIN feeds f ON f.id = a.feed_id WHERE a.id = ?`, articleID).Scan(&category)
+	return category, err
+}
@@ -0,0 +1,76 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestRefreshPolicies_SaveDelete(t *testing.T) {
+	db := setupTestDB(t)
+
+	global := models.RefreshPolicy{QuietStart: "22:00", QuietEnd: "07:00", QuietMode: models.QuietHoursPause, DailyBudgetMB: 50}
+	if err := db.SaveRefreshPolicy(global); err != nil {
+		t.Fatalf("SaveRefreshPolicy error: %v", err)
+	}
+	if err := db.SaveRefreshPolicy(models.RefreshPolicy{Category: "Podcasts", LowData: true}); err != nil {
+		t.Fatalf("SaveRefreshPolicy error: %v", err)
+	}
+	global.DailyBudgetMB = 100
+	if err := db.SaveRefreshPolicy(global); err != nil {
+		t.Fatalf("SaveRefreshPolicy update error: %v", err)
+	}
+
+	policies, err := db.GetRefreshPolicies()
+	if err != nil || len(policies) != 2 {
+		t.Fatalf("GetRefreshPolicies = %+v, %v", policies, err)
+	}
+	if policies[0] != global {
+		t.Errorf("global policy = %+v, want %+v", policies[0], global)
+	}
+	if !policies[1].LowData || policies[1].Category != "Podcasts" {
+		t.Errorf("category policy = %+v", policies[1])
+	}
+
+	if err := db.DeleteRefreshPolicy("Podcasts"); err != nil {
+		t.Fatalf("DeleteRefreshPolicy error: %v", err)
+	}
+	if err := db.DeleteRefreshPolicy("Podcasts"); err != sql.ErrNoRows {
+		t.Errorf("deleting a missing policy: err = %v", err)
+	}
+}
+
+func TestDataUsage(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, u := range []struct {
+		day      string
+		category string
+		bytes    int64
+	}{
+		{"2024-05-01", "News", 100},
+		{"2024-05-02", "News", 200},
+		{"2024-05-02", "News", 50},
+		{"2024-05-02", "", 10},
+	} {
+		if err := db.AddDataUsage(u.day, u.category, u.bytes); err != nil {
+			t.Fatalf("AddDataUsage error: %v", err)
+		}
+	}
+
+	usage, err := db.GetDataUsage("2024-05-02")
+	if err != nil {
+		t.Fatalf("GetDataUsage error: %v", err)
+	}
+	if usage["News"] != 250 || usage[""] != 10 || len(usage) != 2 {
+		t.Errorf("usage = %v", usage)
+	}
+
+	if err := db.DeleteDataUsageBefore("2024-05-02"); err != nil {
+		t.Fatalf("DeleteDataUsageBefore error: %v", err)
+	}
+	if usage, _ := db.GetDataUsage("2024-05-01"); len(usage) != 0 {
+		t.Errorf("old usage kept: %v", usage)
+	}
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+const webSubSelect = `SELECT feed_id, hub_url, topic_url, COALESCE(secret, ''), COALESCE(callback_token, ''), state, COALESCE(lease_seconds, 0), lease_expires_at, last_push_at, updated_at, COALESCE(last_error, '') FROM websub_subscriptions`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebSubSubscription(row rowScanner) (*models.WebSubSubscription, error) {
+	var sub models.WebSubSubscription
+	var leaseExpiresAt, lastPushAt sql.NullTime
+	if err := row.Scan(&sub.FeedID, &sub.HubURL, &sub.TopicURL, &sub.Secret, &sub.CallbackToken, &sub.State, &sub.LeaseSeconds, &leaseExpiresAt, &lastPushAt, &sub.UpdatedAt, &sub.LastError); err != nil {
+		return nil, err
+	}
+	if leaseExpiresAt.Valid {
+		sub.LeaseExpiresAt = leaseExpiresAt.Time
+	}
+	if lastPushAt.Valid {
+		sub.LastPushAt = lastPushAt.Time
+	}
+	return &sub, nil
+}
+
+// GetWebSubSubscription returns the WebSub subscription of a feed, or nil if it has none.
+func (db *DB) GetWebSubSubscription(feedID int64) (*models.WebSubSubscription, error) {
+	db.WaitForReady()
+	sub, err := scanWebSubSubscription(db.QueryRow(webSubSelect+` WHERE feed_id = ?`, feedID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
+}
+
+// GetWebSubSubscriptions returns all WebSub subscriptions.
+func (db *DB) GetWebSubSubscriptions() ([]models.WebSubSubscription, error) {
+	db.WaitForReady()
+	rows, err := db.Query(webSubSelect + ` ORDER BY feed_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.WebSubSubscription
+	for rows.Next() {
+		sub, err := scanWebSubSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// GetActiveWebSubFeedIDs returns the feeds whose WebSub lease is active at now.
+func (db *DB) GetActiveWebSubFeedIDs(now time.Time) (map[int64]bool, error) {
+	subs, err := db.GetWebSubSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int64]bool)
+	for _, sub := range subs {
+		if sub.State == models.WebSubStateActive && sub.LeaseExpiresAt.After(now) {
+			ids[sub.FeedID] = true
+		}
+	}
+	return ids, nil
+}
+
+// SaveWebSubSubscriptionRequest stores a pending subscription request of a feed,
+// replacing any previous subscription. The lease of an active subscription being
+// renewed at the same hub is kept until the hub confirms the renewal.
+func (db *DB) SaveWebSubSubscriptionRequest(feedID int64, hubURL, topicURL, secret, callbackToken string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`INSERT INTO websub_subscriptions (feed_id, hub_url, topic_url, secret, callback_token, state, updated_at, last_error) VALUES (?, ?, ?, ?, ?, ?, ?, '')
+		ON CONFLICT(feed_id) DO UPDATE SET
+			state = CASE WHEN state = ? AND hub_url = excluded.hub_url AND topic_url = excluded.topic_url AND callback_token = excluded.callback_token THEN state ELSE excluded.state END,
+			hub_url = excluded.hub_url, topic_url = excluded.topic_url, secret = excluded.secret, callback_token = excluded.callback_token,
+			updated_at = excluded.updated_at, last_error = ''`,
+		feedID, hubURL, topicURL, secret, callbackToken, models.WebSubStatePending, time.Now(), models.WebSubStateActive)
+	return err
+}
+
+// ActivateWebSubSubscription marks a subscription as verified by the hub for the given lease.
+func (db *DB) ActivateWebSubSubscription(feedID int64, leaseSeconds int) error {
+	db.WaitForReady()
+	now := time.Now()
+	_, err := db.Exec(`UPDATE websub_subscriptions SET state = ?, lease_seconds = ?, lease_expires_at = ?, updated_at = ?, last_error = '' WHERE feed_id = ?`,
+		models.WebSubStateActive, leaseSeconds, now.Add(time.Duration(leaseSeconds)*time.Second), now, feedID)
+	return err
+}
+
+// DenyWebSubSubscription marks a subscription as refused by the hub or failed.
+func (db *DB) DenyWebSubSubscription(feedID int64, reason string) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE websub_subscriptions SET state = ?, lease_expires_at = NULL, updated_at = ?, last_error = ? WHERE feed_id = ?`,
+		models.WebSubStateDenied, time.Now(), reason, feedID)
+	return err
+}
+
+// MarkWebSubPush records that the hub pushed content for a feed.
+func (db *DB) MarkWebSubPush(feedID int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE websub_subscriptions SET last_push_at = ? WHERE feed_id = ?`, time.Now(), feedID)
+	return err
+}
@@ -125,6 +125,11 @@ func (s *Service) discoverBlogRSS(ctx context.Context, blogURL string) (Discover
 	}, nil
 }
 
+// FindFeed finds the feed URL of a web page from its <link rel="alternate"> tags or common feed paths.
+func (s *Service) FindFeed(ctx context.Context, pageURL string) (string, error) {
+	return s.findRSSFeed(ctx, pageURL)
+}
+
 // findRSSFeed finds the RSS feed URL for a blog
 func (s *Service) findRSSFeed(ctx context.Context, blogURL string) (string, error) {
 	// Common RSS feed paths to try
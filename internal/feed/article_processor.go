@@ -1,9 +1,11 @@
 package feed
 
 import (
+	"MrRSS/internal/database"
 	"MrRSS/internal/models"
 	"MrRSS/internal/utils"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -80,11 +82,26 @@ func (f *Fetcher) processArticles(feed models.Feed, items []*gofeed.Item) []*mod
 			FeedID:          feed.ID,
 			Title:           title,
 			URL:             item.Link,
+			GUID:            strings.TrimSpace(item.GUID),
 			ImageURL:        imageURL,
 			AudioURL:        audioURL,
 			VideoURL:        videoURL,
 			PublishedAt:     published,
 			TranslatedTitle: translatedTitle,
+			Content:         content,
+			Author:          extractAuthor(item),
+			Categories:      extractCategories(item),
+			Enclosures:      extractEnclosures(item),
+		}
+		if item.UpdatedParsed != nil {
+			article.ItemUpdatedAt = *item.UpdatedParsed
+		}
+		if article.GUID == "" && article.URL == "" && item.PublishedParsed == nil {
+			// The fetch time is no identity; key the item by its title and content alone
+			article.GUID = database.ItemKey(title, content, time.Time{})
+		}
+		if audioURL != "" {
+			article.Podcast = extractPodcastEpisode(item)
 		}
 		articles = append(articles, article)
 	}
@@ -139,6 +156,61 @@ func extractAudioURL(item *gofeed.Item) string {
 	return ""
 }
 
+// extractAuthor returns the item's author names joined with ", "
+func extractAuthor(item *gofeed.Item) string {
+	authors := item.Authors
+	if len(authors) == 0 && item.Author != nil {
+		authors = []*gofeed.Person{item.Author}
+	}
+
+	var names []string
+	for _, person := range authors {
+		if person == nil {
+			continue
+		}
+		name := strings.TrimSpace(person.Name)
+		if name == "" {
+			name = strings.TrimSpace(person.Email)
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// extractCategories returns the item's non-empty, de-duplicated categories
+func extractCategories(item *gofeed.Item) []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, category := range item.Categories {
+		category = strings.TrimSpace(category)
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+		categories = append(categories, category)
+	}
+	return categories
+}
+
+// extractEnclosures returns every enclosure of a feed item
+func extractEnclosures(item *gofeed.Item) []models.Enclosure {
+	var enclosures []models.Enclosure
+	for _, enc := range item.Enclosures {
+		if enc == nil || enc.URL == "" {
+			continue
+		}
+		length, _ := strconv.ParseInt(strings.TrimSpace(enc.Length), 10, 64)
+		enclosures = append(enclosures, models.Enclosure{
+			URL:    enc.URL,
+			Type:   enc.Type,
+			Length: length,
+		})
+	}
+	return enclosures
+}
+
 // extractVideoURL extracts the video URL from a feed item (for YouTube videos)
 func extractVideoURL(item *gofeed.Item) string {
 	// Check if this is a YouTube link (watch, youtu.be, or shorts)
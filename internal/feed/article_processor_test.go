@@ -422,3 +422,48 @@ func TestProcessArticlesWithYouTubeFeed(t *testing.T) {
 		t.Errorf("Expected video URL '%s', got '%s'", expectedVideoURL, article.VideoURL)
 	}
 }
+
+func TestProcessArticlesKeepsAuthorsCategoriesAndEnclosures(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Failed to init db: %v", err)
+	}
+	f := NewFetcher(db, nil)
+
+	item := &gofeed.Item{
+		Title:      "Episode 42",
+		Link:       "https://example.com/ep42",
+		Authors:    []*gofeed.Person{{Name: "Alice"}, {Email: "bob@example.com"}},
+		Categories: []string{"Tech", " Tech ", "Podcast", ""},
+		Enclosures: []*gofeed.Enclosure{
+			{URL: "https://example.com/ep42.mp3", Type: "audio/mpeg", Length: "12345"},
+			{URL: "https://example.com/ep42.m4a", Type: "audio/mp4", Length: ""},
+			{URL: "https://example.com/cover.jpg", Type: "image/jpeg", Length: "99"},
+		},
+	}
+
+	articles := f.processArticles(models.Feed{ID: 1}, []*gofeed.Item{item})
+	if len(articles) != 1 {
+		t.Fatalf("expected 1 article, got %d", len(articles))
+	}
+	a := articles[0]
+
+	if a.Author != "Alice, bob@example.com" {
+		t.Errorf("unexpected author %q", a.Author)
+	}
+	if len(a.Categories) != 2 || a.Categories[0] != "Tech" || a.Categories[1] != "Podcast" {
+		t.Errorf("unexpected categories %v", a.Categories)
+	}
+	if len(a.Enclosures) != 3 {
+		t.Fatalf("expected 3 enclosures, got %d", len(a.Enclosures))
+	}
+	if a.Enclosures[0].Length != 12345 || a.Enclosures[1].Length != 0 {
+		t.Errorf("unexpected enclosure lengths: %+v", a.Enclosures)
+	}
+	if a.AudioURL != "https://example.com/ep42.mp3" {
+		t.Errorf("expected first audio enclosure as audio_url, got %q", a.AudioURL)
+	}
+}
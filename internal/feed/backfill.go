@@ -0,0 +1,383 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+const (
+	// DefaultBackfillMaxItems is the item cap of a backfill that does not set one
+	DefaultBackfillMaxItems = 500
+	// Upper bound for the item cap of a backfill
+	maxBackfillItems = 5000
+	// Safety net for archives whose pages never run out
+	maxBackfillPages = 200
+	// Upper bound for the size of one archive page
+	maxBackfillPageSize = 20 << 20
+)
+
+// Pause between two archive pages, so a backfill does not hammer the site
+var backfillPageDelay = time.Second
+
+var (
+	// ErrBackfillRunning is returned when a backfill is started while another one runs.
+	ErrBackfillRunning = errors.New("a backfill is already running")
+	// ErrBackfillUnsupported is returned for feeds that are not fetched as RSS, Atom or JSON Feed.
+	ErrBackfillUnsupported = errors.New("backfill is only supported for RSS, Atom and JSON feeds")
+)
+
+// BackfillOptions limits how far back a backfill goes.
+type BackfillOptions struct {
+	Until    time.Time `json:"until"`     // Items published before this are not imported (zero = no date limit)
+	MaxItems int       `json:"max_items"` // Items to import at most (0 = DefaultBackfillMaxItems)
+}
+
+// GetBackfillProgress returns the progress of the running or last backfill. Total is the
+// item cap and Current the number of items found so far.
+func (f *Fetcher) GetBackfillProgress() Progress {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.backfill
+}
+
+// StartBackfill starts a backfill of feed in the background. See Backfill.
+func (f *Fetcher) StartBackfill(feed models.Feed, opts BackfillOptions) error {
+	if !canBackfill(feed) {
+		return ErrBackfillUnsupported
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if !f.beginBackfill(backfillMaxItems(opts), cancel) {
+		cancel()
+		return ErrBackfillRunning
+	}
+	go func() {
+		defer cancel()
+		if _, err := f.runBackfill(ctx, feed, opts); err != nil {
+			log.Printf("Error backfilling feed %s: %v", feed.Title, err)
+		}
+	}()
+	return nil
+}
+
+// CancelBackfill stops the running backfill. Items saved so far are kept.
+func (f *Fetcher) CancelBackfill() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.backfillCancel != nil {
+		f.backfillCancel()
+	}
+}
+
+// Backfill imports the older items of a feed by walking its archive: RFC 5005 prev-archive and
+// next links, which also covers Atom next links, or else WordPress ?paged=N pages. Items are saved
+// like the items of a refresh and keep their publish dates. It stops at opts.Until, at the item
+// cap, on the last page or when ctx is cancelled, and returns the number of items found.
+func (f *Fetcher) Backfill(ctx context.Context, feed models.Feed, opts BackfillOptions) (int, error) {
+	if !canBackfill(feed) {
+		return 0, ErrBackfillUnsupported
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if !f.beginBackfill(backfillMaxItems(opts), cancel) {
+		return 0, ErrBackfillRunning
+	}
+	return f.runBackfill(ctx, feed, opts)
+}
+
+// canBackfill reports whether a feed is fetched as a regular feed whose archive can be walked.
+func canBackfill(feed models.Feed) bool {
+	return feed.ScriptPath == "" && feed.Type == ""
+}
+
+func backfillMaxItems(opts BackfillOptions) int {
+	if opts.MaxItems <= 0 {
+		return DefaultBackfillMaxItems
+	}
+	return min(opts.MaxItems, maxBackfillItems)
+}
+
+// beginBackfill marks a backfill as running. It returns false if one already is.
+func (f *Fetcher) beginBackfill(total int, cancel context.CancelFunc) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.backfill.IsRunning {
+		return false
+	}
+	f.backfill = Progress{Total: total, IsRunning: true, Errors: make(map[int64]string)}
+	f.backfillCancel = cancel
+	return true
+}
+
+// runBackfill walks the archive of a feed once beginBackfill succeeded.
+func (f *Fetcher) runBackfill(ctx context.Context, feed models.Feed, opts BackfillOptions) (int, error) {
+	found, err := f.walkArchive(ctx, feed, opts.Until, backfillMaxItems(opts))
+
+	f.mu.Lock()
+	f.backfill.Current = found
+	f.backfill.IsRunning = false
+	f.backfillCancel = nil
+	if err != nil {
+		f.backfill.Errors[feed.ID] = err.Error()
+	}
+	f.mu.Unlock()
+	utils.DebugLog("Backfill of feed %s found %d items", feed.Title, found)
+	return found, err
+}
+
+// walkArchive fetches the pages of a feed's archive from newest to oldest and saves their items.
+func (f *Fetcher) walkArchive(ctx context.Context, feed models.Feed, until time.Time, maxItems int) (int, error) {
+	client, err := f.getMeteredHTTPClient(feed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	f.setupTranslator()
+
+	pageURL := feed.URL
+	wordPressPage := 0 // Number of the current ?paged=N page once paging WordPress-style
+	visited := make(map[string]bool)
+	seenItems := make(map[string]bool)
+	found := 0
+
+	for pages := 0; pages < maxBackfillPages && found < maxItems; pages++ {
+		if pages > 0 {
+			select {
+			case <-ctx.Done():
+				return found, nil
+			case <-time.After(backfillPageDelay):
+			}
+		}
+		visited[pageURL] = true
+
+		page, next, err := fetchArchivePage(ctx, client, pageURL)
+		if err != nil {
+			var httpErr gofeed.HTTPError
+			if wordPressPage > 0 && errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+				// WordPress answers 404 past its last page
+				return found, nil
+			}
+			if ctx.Err() != nil {
+				return found, nil
+			}
+			return found, fmt.Errorf("%s: %w", pageURL, err)
+		}
+
+		// Keep the new items of the page that are recent enough
+		var items []*gofeed.Item
+		reachedUntil := false
+		for _, item := range page.Items {
+			key := item.GUID
+			if key == "" {
+				key = item.Link
+			}
+			if key != "" {
+				if seenItems[key] {
+					continue
+				}
+				seenItems[key] = true
+			}
+			if !until.IsZero() {
+				if date := itemDate(item); date != nil && date.Before(until) {
+					reachedUntil = true
+					continue
+				}
+			}
+			items = append(items, item)
+		}
+		if len(items) > maxItems-found {
+			items = items[:maxItems-found]
+		}
+
+		if len(items) > 0 {
+			articles := f.processArticles(feed, items)
+			if !f.saveFeedArticles(ctx, feed, articles) {
+				return found, fmt.Errorf("failed to save the items of %s", pageURL)
+			}
+			found += len(items)
+			f.mu.Lock()
+			f.backfill.Current = found
+			f.mu.Unlock()
+		}
+		if reachedUntil || len(items) == 0 {
+			return found, nil
+		}
+
+		switch {
+		case wordPressPage > 0:
+			wordPressPage++
+			pageURL = wordPressPageURL(feed.URL, wordPressPage)
+		case next != "":
+			pageURL = next
+		case pages == 0 && isWordPressFeed(feed.URL, page):
+			wordPressPage = 2
+			pageURL = wordPressPageURL(feed.URL, wordPressPage)
+		default:
+			return found, nil
+		}
+		if visited[pageURL] {
+			return found, nil
+		}
+	}
+	return found, nil
+}
+
+// fetchArchivePage downloads and parses one page of a feed's archive. It returns the parsed page
+// and the absolute URL of the next older page it links to, "" if none.
+func fetchArchivePage(ctx context.Context, client *http.Client, pageURL string) (*gofeed.Feed, string, error) {
+	parser := gofeed.NewParser()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", parser.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBackfillPageSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := parser.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, "", err
+	}
+	next := findArchiveLink(resp.Header, body)
+	if next != "" {
+		base, _ := url.Parse(pageURL)
+		if u, err := base.Parse(next); err == nil {
+			next = u.String()
+		} else {
+			next = ""
+		}
+	}
+	return page, next, nil
+}
+
+// findArchiveLink returns the link to the next older page of a feed: an RFC 5005 prev-archive link,
+// or else a next link, from the HTTP Link header, the feed-level <link>/<atom:link> elements or the
+// next_url of a JSON Feed.
+func findArchiveLink(header http.Header, body []byte) string {
+	links := make(map[string]string)
+	add := func(rels, href string) {
+		for _, rel := range strings.Fields(strings.ToLower(rels)) {
+			if _, ok := links[rel]; !ok && href != "" {
+				links[rel] = href
+			}
+		}
+	}
+
+	for _, value := range header.Values("Link") {
+		for _, part := range strings.Split(value, ",") {
+			start, end := strings.Index(part, "<"), strings.Index(part, ">")
+			if start < 0 || end < start {
+				continue
+			}
+			for _, param := range strings.Split(part[end+1:], ";") {
+				name, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if ok && strings.EqualFold(strings.TrimSpace(name), "rel") {
+					add(strings.Trim(val, `"`), strings.TrimSpace(part[start+1:end]))
+				}
+			}
+		}
+	}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var jsonFeed struct {
+			NextURL string `json:"next_url"`
+		}
+		if json.Unmarshal(trimmed, &jsonFeed) == nil {
+			add("next", jsonFeed.NextURL)
+		}
+	} else {
+		decoder := xml.NewDecoder(bytes.NewReader(body))
+		decoder.Strict = false
+		// Only ASCII attributes are of interest, so any declared charset can be read as is
+		decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			start, ok := token.(xml.StartElement)
+			if !ok {
+				continue
+			}
+			// Links inside items describe the items, not the feed
+			if start.Name.Local == "item" || start.Name.Local == "entry" {
+				break
+			}
+			if start.Name.Local != "link" {
+				continue
+			}
+			var rel, href string
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "rel":
+					rel = attr.Value
+				case "href":
+					href = strings.TrimSpace(attr.Value)
+				}
+			}
+			add(rel, href)
+		}
+	}
+
+	if href := links["prev-archive"]; href != "" {
+		return href
+	}
+	return links["next"]
+}
+
+// isWordPressFeed reports whether a feed looks like it is served by WordPress, which pages
+// its feeds with ?paged=N.
+func isWordPressFeed(feedURL string, page *gofeed.Feed) bool {
+	if strings.Contains(strings.ToLower(page.Generator), "wordpress") {
+		return true
+	}
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/feed") || u.Query().Get("feed") != ""
+}
+
+// wordPressPageURL returns the URL of page n of a WordPress feed.
+func wordPressPageURL(feedURL string, n int) string {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return feedURL
+	}
+	query := u.Query()
+	query.Set("paged", strconv.Itoa(n))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// itemDate returns the publish date of an item, or its update date if it has none.
+func itemDate(item *gofeed.Item) *time.Time {
+	if item.PublishedParsed != nil {
+		return item.PublishedParsed
+	}
+	return item.UpdatedParsed
+}
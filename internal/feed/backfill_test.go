@@ -0,0 +1,201 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func init() {
+	backfillPageDelay = 0
+}
+
+// atomPage renders an Atom feed page with entries for the given days of January 2020 and an optional archive link.
+func atomPage(rel, href string, days ...int) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Archive</title>`)
+	if rel != "" {
+		fmt.Fprintf(&b, `<link rel="%s" href="%s"/>`, rel, href)
+	}
+	for _, day := range days {
+		fmt.Fprintf(&b, `<entry><id>urn:day:%d</id><title>Day %d</title><link href="https://example.com/day/%d"/><updated>2020-01-%02dT10:00:00Z</updated></entry>`, day, day, day, day)
+	}
+	b.WriteString(`</feed>`)
+	return b.String()
+}
+
+func serveFeeds(pages map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(page))
+	}))
+}
+
+func backfilledDays(t *testing.T, f *Fetcher, feedID int64) map[string]time.Time {
+	t.Helper()
+	articles, err := f.db.GetArticles("", feedID, "", true, 1000, 0)
+	if err != nil {
+		t.Fatalf("GetArticles error: %v", err)
+	}
+	days := make(map[string]time.Time)
+	for _, a := range articles {
+		days[a.Title] = a.PublishedAt
+	}
+	return days
+}
+
+func TestBackfill_FollowsArchiveLinks(t *testing.T) {
+	server := serveFeeds(map[string]string{
+		"/feed.xml":  atomPage("prev-archive", "/archive/2", 30, 29),
+		"/archive/2": atomPage("prev-archive", "/archive/1", 28, 27),
+		// Paged rather than archived, and a relative link
+		"/archive/1":            atomPage("next", "older?page=3", 26, 25),
+		"/archive/older?page=3": atomPage("", "", 24),
+	})
+	defer server.Close()
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Archive", URL: server.URL + "/feed.xml"})
+	feed, _ := db.GetFeedByID(feedID)
+
+	found, err := f.Backfill(context.Background(), *feed, BackfillOptions{})
+	if err != nil {
+		t.Fatalf("Backfill error: %v", err)
+	}
+	if found != 7 {
+		t.Errorf("found %d items, want 7", found)
+	}
+	days := backfilledDays(t, f, feedID)
+	if len(days) != 7 {
+		t.Errorf("saved %d articles, want 7: %v", len(days), days)
+	}
+	if want := time.Date(2020, 1, 24, 10, 0, 0, 0, time.UTC); !days["Day 24"].Equal(want) {
+		t.Errorf("Day 24 published at %v, want %v", days["Day 24"], want)
+	}
+
+	progress := f.GetBackfillProgress()
+	if progress.IsRunning || progress.Current != 7 || progress.Total != DefaultBackfillMaxItems || len(progress.Errors) != 0 {
+		t.Errorf("progress = %+v", progress)
+	}
+}
+
+func TestBackfill_StopsAtDateAndItemCap(t *testing.T) {
+	server := serveFeeds(map[string]string{
+		"/feed.xml": atomPage("next", "/page/2", 30, 29, 28),
+		"/page/2":   atomPage("next", "/page/3", 27, 26, 25),
+		"/page/3":   atomPage("", "", 24, 23, 22),
+	})
+	defer server.Close()
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Archive", URL: server.URL + "/feed.xml"})
+	feed, _ := db.GetFeedByID(feedID)
+
+	until := time.Date(2020, 1, 26, 0, 0, 0, 0, time.UTC)
+	found, err := f.Backfill(context.Background(), *feed, BackfillOptions{Until: until})
+	if err != nil || found != 5 {
+		t.Fatalf("Backfill = %d, %v; want 5 items", found, err)
+	}
+	if _, ok := backfilledDays(t, f, feedID)["Day 25"]; ok {
+		t.Error("imported an item older than the until date")
+	}
+
+	db = setupDBForFeedTests(t)
+	f = NewFetcher(db, nil)
+	feedID, _ = db.AddFeed(&models.Feed{Title: "Capped", URL: server.URL + "/feed.xml"})
+	feed, _ = db.GetFeedByID(feedID)
+	found, err = f.Backfill(context.Background(), *feed, BackfillOptions{MaxItems: 4})
+	if err != nil || found != 4 {
+		t.Fatalf("Backfill = %d, %v; want 4 items", found, err)
+	}
+	if progress := f.GetBackfillProgress(); progress.Total != 4 || progress.Current != 4 {
+		t.Errorf("progress = %+v", progress)
+	}
+}
+
+func TestBackfill_WordPressPages(t *testing.T) {
+	rss := func(titles ...string) string {
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0"?><rss version="2.0"><channel><title>Blog</title><generator>https://wordpress.org/?v=6.4.2</generator>`)
+		for _, title := range titles {
+			fmt.Fprintf(&b, `<item><title>%s</title><link>https://blog.example.com/%s/</link><pubDate>Mon, 06 Jan 2020 10:00:00 +0000</pubDate></item>`, title, title)
+		}
+		b.WriteString(`</channel></rss>`)
+		return b.String()
+	}
+	// WordPress answers 404 past the last page
+	server := serveFeeds(map[string]string{
+		"/blog/?feed=rss2":         rss("newest", "newer"),
+		"/blog/?feed=rss2&paged=2": rss("older", "oldest"),
+	})
+	defer server.Close()
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Blog", URL: server.URL + "/blog/?feed=rss2"})
+	feed, _ := db.GetFeedByID(feedID)
+
+	found, err := f.Backfill(context.Background(), *feed, BackfillOptions{})
+	if err != nil || found != 4 {
+		t.Fatalf("Backfill = %d, %v; want 4 items", found, err)
+	}
+	if _, ok := backfilledDays(t, f, feedID)["oldest"]; !ok {
+		t.Error("second WordPress page not imported")
+	}
+}
+
+func TestBackfill_UnsupportedFeedsAndConcurrentRuns(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+
+	if _, err := f.Backfill(context.Background(), models.Feed{Type: "HTML+XPath", URL: "https://example.com/"}, BackfillOptions{}); err != ErrBackfillUnsupported {
+		t.Errorf("XPath feed: err = %v", err)
+	}
+
+	f.beginBackfill(10, func() {})
+	if err := f.StartBackfill(models.Feed{URL: "https://example.com/feed.xml"}, BackfillOptions{}); err != ErrBackfillRunning {
+		t.Errorf("second backfill: err = %v", err)
+	}
+}
+
+func TestFindArchiveLink(t *testing.T) {
+	header := http.Header{"Link": {`<https://example.com/feed?page=2>; rel="next", <https://example.com/feed>; rel="self"`}}
+	if got := findArchiveLink(header, nil); got != "https://example.com/feed?page=2" {
+		t.Errorf("Link header: %q", got)
+	}
+
+	atom := []byte(atomPage("next", "/page/2", 1))
+	if got := findArchiveLink(http.Header{}, atom); got != "/page/2" {
+		t.Errorf("atom: %q", got)
+	}
+
+	// Archived feeds prefer prev-archive over the paging links
+	both := []byte(`<feed xmlns="http://www.w3.org/2005/Atom"><link rel="next" href="/next"/><link rel="prev-archive" href="/archive"/></feed>`)
+	if got := findArchiveLink(http.Header{}, both); got != "/archive" {
+		t.Errorf("prev-archive: %q", got)
+	}
+
+	// Links of items are not paging links
+	itemLink := []byte(`<rss><channel><item><atom:link xmlns:atom="http://www.w3.org/2005/Atom" rel="next" href="/x"/></item></channel></rss>`)
+	if got := findArchiveLink(http.Header{}, itemLink); got != "" {
+		t.Errorf("item link: %q", got)
+	}
+
+	jsonFeed := []byte(`{"version": "https://jsonfeed.org/version/1.1", "next_url": "https://example.com/feed.json?page=2", "items": []}`)
+	if got := findArchiveLink(http.Header{}, jsonFeed); got != "https://example.com/feed.json?page=2" {
+		t.Errorf("JSON Feed: %q", got)
+	}
+}
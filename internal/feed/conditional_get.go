@@ -0,0 +1,108 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// ErrNotModified is returned when a conditional feed request is answered with 304 Not Modified.
+var ErrNotModified = errors.New("feed not modified")
+
+// Upper bound for the size of a feed document
+var maxFeedSize int64 = 20 << 20
+
+// fetchInfo holds HTTP response details of a feed download
+type fetchInfo struct {
+	StatusCode   int
+	ETag         string
+	LastModified string
+	// Final URL reached through permanent redirects (301/308) only, "" if there were none
+	PermanentRedirect string
+	// Server hints on when to fetch again, 0 if not sent
+	RetryAfter time.Duration
+	MaxAge     time.Duration
+	// WebSub hub and canonical topic URL advertised by the feed, "" if none
+	Hub  string
+	Self string
+	// What a script feed wrote to stderr, recorded in the fetch history
+	ScriptStderr string
+}
+
+// fetchFeedURL downloads and parses a feed over HTTP using the given client.
+// When conditional is true, the feed's stored validators are sent as
+// If-None-Match / If-Modified-Since and a 304 response yields ErrNotModified.
+func (f *Fetcher) fetchFeedURL(ctx context.Context, client *http.Client, feed *models.Feed, conditional bool) (*gofeed.Feed, *fetchInfo, error) {
+	parser := gofeed.NewParser()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", parser.UserAgent)
+	if conditional {
+		if feed.ETag != "" {
+			req.Header.Set("If-None-Match", feed.ETag)
+		}
+		if feed.LastModified != "" {
+			req.Header.Set("If-Modified-Since", feed.LastModified)
+		}
+	}
+
+	redirectClient, permanentRedirect := trackPermanentRedirects(client)
+	resp, err := redirectClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	info := &fetchInfo{
+		StatusCode:        resp.StatusCode,
+		ETag:              resp.Header.Get("ETag"),
+		LastModified:      resp.Header.Get("Last-Modified"),
+		PermanentRedirect: permanentRedirect(),
+		RetryAfter:        parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		MaxAge:            parseMaxAge(resp.Header.Get("Cache-Control")),
+	}
+
+	if conditional && resp.StatusCode == http.StatusNotModified {
+		// A 304 may omit validators; keep the ones we sent
+		if info.ETag == "" {
+			info.ETag = feed.ETag
+		}
+		if info.LastModified == "" {
+			info.LastModified = feed.LastModified
+		}
+		return nil, info, ErrNotModified
+	}
+
+	// Keep gofeed's error type so callers see the same errors as with ParseURL
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, info, gofeed.HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, info, err
+	}
+	if int64(len(body)) > maxFeedSize {
+		return nil, info, fmt.Errorf("feed is larger than %d bytes", maxFeedSize)
+	}
+	info.Hub, info.Self = discoverWebSubLinks(resp.Header, body)
+
+	parsedFeed, err := parser.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, info, err
+	}
+	return parsedFeed, info, nil
+}
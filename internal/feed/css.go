@@ -0,0 +1,222 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mmcdole/gofeed"
+)
+
+// An attribute to read instead of the text, appended to a selector: "a.title@href", or "@href" for the item itself
+var cssAttrSuffix = regexp.MustCompile(`@([A-Za-z_:][-\w:.]*)$`)
+
+// Layouts tried for scraped timestamps when the feed has no time format
+var scrapedTimeFormats = []string{
+	time.RFC3339,
+	time.RFC1123,
+	time.RFC1123Z,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	"01/02/2006",
+	"January 2, 2006",
+	"2 January 2006",
+	"Jan 2, 2006",
+	"2006-01",
+}
+
+// parseFeedWithCSS parses an HTML+CSS feed. Such feeds store CSS selectors in the XPath fields of models.Feed.
+func (f *Fetcher) parseFeedWithCSS(ctx context.Context, feed *models.Feed) (*gofeed.Feed, error) {
+	if feed.XPathItem == "" {
+		return nil, fmt.Errorf("CSS item selector is required for CSS-based feeds")
+	}
+
+	httpClient, err := f.getMeteredHTTPClient(*feed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	body, err := fetchScrapedDocument(ctx, httpClient, feed.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedFeed, err := parseCSSDocument(body, feed, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsedFeed.Items) == 0 {
+		return nil, fmt.Errorf("no items found with selector: %s", feed.XPathItem)
+	}
+	return parsedFeed, nil
+}
+
+// parseCSSDocument extracts the items of an HTML+CSS feed from a fetched page.
+// warn, if not nil, is told about fields of each item that look misconfigured.
+func parseCSSDocument(body []byte, feed *models.Feed, warn func(item int, field, message string)) (*gofeed.Feed, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	baseURL, _ := url.Parse(feed.URL)
+
+	parsedFeed := &gofeed.Feed{
+		Title:       feed.Title,
+		Link:        feed.URL,
+		Description: feed.Description,
+		Items:       make([]*gofeed.Item, 0),
+	}
+	doc.Find(feed.XPathItem).Each(func(i int, sel *goquery.Selection) {
+		var itemWarn xpathWarnFunc
+		if warn != nil {
+			itemWarn = func(field, message string) { warn(i, field, message) }
+		}
+		parsedFeed.Items = append(parsedFeed.Items, extractItemFromCSSSelection(sel, feed, baseURL, itemWarn))
+	})
+	return parsedFeed, nil
+}
+
+// extractItemFromCSSSelection extracts a gofeed.Item from an element matched by the item selector
+func extractItemFromCSSSelection(item *goquery.Selection, feed *models.Feed, baseURL *url.URL, warn xpathWarnFunc) *gofeed.Item {
+	gofeedItem := &gofeed.Item{}
+
+	gofeedItem.Title, _ = cssValue(item, feed.XPathItemTitle)
+	if gofeedItem.Title == "" {
+		warn.add("xpath_item_title", "title is empty")
+	}
+
+	if feed.XPathItemContent != "" {
+		if target, attr := cssTarget(item, feed.XPathItemContent); target.Length() > 0 {
+			if attr != "" {
+				gofeedItem.Content = target.AttrOr(attr, "")
+			} else {
+				gofeedItem.Content, _ = goquery.OuterHtml(target)
+			}
+		}
+	}
+
+	link, _ := cssValue(item, feed.XPathItemUri, "href")
+	if link == "" && goquery.NodeName(item) == "a" {
+		link = item.AttrOr("href", "")
+	}
+	gofeedItem.Link = link
+
+	if author, _ := cssValue(item, feed.XPathItemAuthor); author != "" {
+		gofeedItem.Author = &gofeed.Person{Name: author}
+	}
+
+	if feed.XPathItemTimestamp != "" {
+		timeStr, found := cssValue(item, feed.XPathItemTimestamp, "datetime")
+		switch {
+		case !found:
+			warn.add("xpath_item_timestamp", "selector matched nothing")
+		case timeStr != "":
+			if parsedTime, err := parseScrapedTime(timeStr, feed.XPathItemTimeFormat); err == nil {
+				gofeedItem.PublishedParsed = &parsedTime
+			} else if feed.XPathItemTimeFormat != "" {
+				warn.add("xpath_item_timestamp", fmt.Sprintf("cannot parse %q with time format %q", timeStr, feed.XPathItemTimeFormat))
+			} else {
+				warn.add("xpath_item_timestamp", fmt.Sprintf("cannot parse %q with any known time format", timeStr))
+			}
+		}
+	}
+
+	if imageURL, _ := cssValue(item, feed.XPathItemThumbnail, "src", "data-src"); imageURL != "" {
+		if !strings.HasPrefix(imageURL, "http") {
+			warn.add("xpath_item_thumbnail", fmt.Sprintf("relative URL %q resolved against the feed URL", imageURL))
+			imageURL = resolveScrapedURL(baseURL, imageURL)
+		}
+		gofeedItem.Image = &gofeed.Image{URL: imageURL}
+	}
+
+	if feed.XPathItemCategories != "" {
+		selector, attr := splitCSSSelector(feed.XPathItemCategories)
+		item.Find(selector).Each(func(_ int, cat *goquery.Selection) {
+			value := strings.TrimSpace(cat.Text())
+			if attr != "" {
+				value = strings.TrimSpace(cat.AttrOr(attr, ""))
+			}
+			if value != "" {
+				gofeedItem.Categories = append(gofeedItem.Categories, value)
+			}
+		})
+	}
+
+	gofeedItem.GUID, _ = cssValue(item, feed.XPathItemUid)
+
+	finalizeScrapedItem(gofeedItem, feed, baseURL, warn, "css")
+	return gofeedItem
+}
+
+// splitCSSSelector splits an optional trailing "@attribute" off a selector.
+func splitCSSSelector(selector string) (string, string) {
+	selector = strings.TrimSpace(selector)
+	if m := cssAttrSuffix.FindStringSubmatchIndex(selector); m != nil {
+		return strings.TrimSpace(selector[:m[0]]), selector[m[2]:m[3]]
+	}
+	return selector, ""
+}
+
+// cssTarget returns the first element below item matched by selector, or item itself for a bare "@attribute".
+func cssTarget(item *goquery.Selection, selector string) (*goquery.Selection, string) {
+	sel, attr := splitCSSSelector(selector)
+	if sel == "" {
+		return item, attr
+	}
+	return item.Find(sel).First(), attr
+}
+
+// cssValue returns the value a selector points to: the named attribute, else the first
+// non-empty default attribute, else the element's text. found is false if nothing matched.
+func cssValue(item *goquery.Selection, selector string, defaultAttrs ...string) (value string, found bool) {
+	if strings.TrimSpace(selector) == "" {
+		return "", false
+	}
+	target, attr := cssTarget(item, selector)
+	if target.Length() == 0 {
+		return "", false
+	}
+	if attr != "" {
+		return strings.TrimSpace(target.AttrOr(attr, "")), true
+	}
+	for _, name := range defaultAttrs {
+		if v := strings.TrimSpace(target.AttrOr(name, "")); v != "" {
+			return v, true
+		}
+	}
+	return strings.TrimSpace(target.Text()), true
+}
+
+// resolveScrapedURL resolves a relative link against the page it was scraped from.
+func resolveScrapedURL(baseURL *url.URL, link string) string {
+	if baseURL == nil {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return baseURL.ResolveReference(ref).String()
+}
+
+// parseScrapedTime parses a scraped timestamp with the feed's time format, or with common layouts if it has none.
+func parseScrapedTime(value, format string) (time.Time, error) {
+	if format != "" {
+		return time.Parse(format, value)
+	}
+	var err error
+	for _, layout := range scrapedTimeFormats {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,106 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"fmt"
+	"testing"
+)
+
+func TestParseCSSDocument(t *testing.T) {
+	page := `<html><body>
+		<article class="post">
+			<h2><a href="/posts/1">First post</a></h2>
+			<div class="body"><p>Hello</p></div>
+			<span class="by">Ann</span>
+			<time datetime="2024-03-01T10:00:00Z">March 1</time>
+			<img data-src="/img/1.png">
+			<a class="tag">go</a><a class="tag">web</a>
+		</article>
+		<article class="post" data-id="p2">
+			<h2><a href="https://other.example/2">Second post</a></h2>
+			<time>someday</time>
+		</article>
+	</body></html>`
+	feed := &models.Feed{
+		URL:                 "https://blog.example.com/archive/",
+		Type:                "HTML+CSS",
+		XPathItem:           "article.post",
+		XPathItemTitle:      "h2",
+		XPathItemContent:    ".body",
+		XPathItemUri:        "h2 a",
+		XPathItemAuthor:     ".by",
+		XPathItemTimestamp:  "time",
+		XPathItemThumbnail:  "img",
+		XPathItemCategories: "a.tag",
+		XPathItemUid:        "@data-id",
+	}
+
+	var warnings []XPathWarning
+	parsed, err := parseCSSDocument([]byte(page), feed, func(item int, field, message string) {
+		warnings = append(warnings, XPathWarning{Item: item, Field: field, Message: message})
+	})
+	if err != nil {
+		t.Fatalf("parseCSSDocument error: %v", err)
+	}
+	if len(parsed.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(parsed.Items))
+	}
+
+	first := parsed.Items[0]
+	if first.Title != "First post" {
+		t.Errorf("title = %q", first.Title)
+	}
+	if first.Link != "https://blog.example.com/posts/1" {
+		t.Errorf("link = %q", first.Link)
+	}
+	if first.Content != `<div class="body"><p>Hello</p></div>` {
+		t.Errorf("content = %q", first.Content)
+	}
+	if first.Author == nil || first.Author.Name != "Ann" {
+		t.Errorf("author = %+v", first.Author)
+	}
+	if first.PublishedParsed == nil || first.PublishedParsed.Day() != 1 {
+		t.Errorf("published = %v", first.PublishedParsed)
+	}
+	if first.Image == nil || first.Image.URL != "https://blog.example.com/img/1.png" {
+		t.Errorf("image = %+v", first.Image)
+	}
+	if len(first.Categories) != 2 || first.Categories[1] != "web" {
+		t.Errorf("categories = %v", first.Categories)
+	}
+	// The item has no data-id, so the link is used as GUID
+	if first.GUID != first.Link {
+		t.Errorf("guid = %q", first.GUID)
+	}
+
+	second := parsed.Items[1]
+	if second.GUID != "p2" || second.Link != "https://other.example/2" || second.PublishedParsed != nil {
+		t.Errorf("unexpected second item: %+v", second)
+	}
+
+	wantFields := map[string]bool{"0/xpath_item_uri": true, "0/xpath_item_thumbnail": true, "1/xpath_item_timestamp": true}
+	if len(warnings) != len(wantFields) {
+		t.Errorf("warnings = %+v", warnings)
+	}
+	for _, w := range warnings {
+		key := fmt.Sprintf("%d/%s", w.Item, w.Field)
+		if !wantFields[key] {
+			t.Errorf("unexpected warning %+v", w)
+		}
+	}
+}
+
+func TestSplitCSSSelector(t *testing.T) {
+	tests := []struct{ in, selector, attr string }{
+		{"a.link@href", "a.link", "href"},
+		{"@data-id", "", "data-id"},
+		{"h2 > a", "h2 > a", ""},
+		{"a[href*='@']", "a[href*='@']", ""},
+	}
+	for _, tt := range tests {
+		selector, attr := splitCSSSelector(tt.in)
+		if selector != tt.selector || attr != tt.attr {
+			t.Errorf("splitCSSSelector(%q) = %q, %q; want %q, %q", tt.in, selector, attr, tt.selector, tt.attr)
+		}
+	}
+}
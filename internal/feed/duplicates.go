@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"log"
+	"net/url"
+	"time"
+)
+
+// Ways of handling an article that duplicates one from another feed (duplicate_handling setting)
+const (
+	DuplicateHandlingOff      = "off"       // No detection
+	DuplicateHandlingLink     = "link"      // Only link the duplicate to its canonical article
+	DuplicateHandlingMarkRead = "mark_read" // Link and mark the duplicate as read
+	DuplicateHandlingHide     = "hide"      // Link and hide the duplicate
+)
+
+const (
+	// Syndicated copies of a story are published at about the same time
+	duplicateMaxTimeApart = 48 * time.Hour
+	// Title similarity required when the URLs only match without their query
+	duplicateSameURLSimilarity = 0.6
+	// Title similarity required when the URLs differ
+	duplicateTitleSimilarity = 0.9
+	// Shorter normalized titles ("Weekly update") are too generic to match on alone
+	duplicateMinTitleLength = 20
+)
+
+// isDuplicateArticle reports whether two articles from different feeds carry the same story.
+func isDuplicateArticle(a, b models.Article) bool {
+	if a.URL != "" && b.URL != "" && hasArticlePath(a.URL) {
+		// The same article is often linked over http or through the www host
+		urlA, urlB := utils.NormalizeURLForDuplicates(a.URL), utils.NormalizeURLForDuplicates(b.URL)
+		if utils.URLsMatch(urlA, urlB) {
+			return true
+		}
+		if utils.NormalizeURLForComparison(urlA) == utils.NormalizeURLForComparison(urlB) &&
+			utils.TitleSimilarity(a.Title, b.Title) >= duplicateSameURLSimilarity {
+			return true
+		}
+	}
+
+	// Wire copy and aggregators republish the story under their own URL
+	if len([]rune(utils.NormalizeTitle(a.Title))) < duplicateMinTitleLength {
+		return false
+	}
+	if !a.PublishedAt.IsZero() && !b.PublishedAt.IsZero() {
+		apart := a.PublishedAt.Sub(b.PublishedAt)
+		if apart < 0 {
+			apart = -apart
+		}
+		if apart > duplicateMaxTimeApart {
+			return false
+		}
+	}
+	return utils.TitleSimilarity(a.Title, b.Title) >= duplicateTitleSimilarity
+}
+
+// hasArticlePath reports whether a URL points below the site root. Items linking
+// only to a site's home page cannot be told apart by their URL.
+func hasArticlePath(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && len(parsed.Path) > 1
+}
+
+// getDuplicateHandling returns how articles duplicating another feed's article are handled.
+func (f *Fetcher) getDuplicateHandling() string {
+	handling, _ := f.db.GetSetting("duplicate_handling")
+	switch handling {
+	case DuplicateHandlingOff, DuplicateHandlingLink, DuplicateHandlingHide:
+		return handling
+	default:
+		return DuplicateHandlingMarkRead
+	}
+}
+
+// linkDuplicates links newly saved articles of a feed that carry a story already saved
+// from another feed to that story's canonical article, then marks them read or hides them.
+// They are compared with the articles of other feeds sharing their URL or published around
+// the same time.
+func (f *Fetcher) linkDuplicates(feed models.Feed, saved []*models.Article) {
+	handling := f.getDuplicateHandling()
+	if handling == DuplicateHandlingOff {
+		return
+	}
+
+	var newArticles []*models.Article
+	for _, a := range saved {
+		if a.ID != 0 {
+			newArticles = append(newArticles, a)
+		}
+	}
+	if len(newArticles) == 0 {
+		return
+	}
+
+	candidates, err := f.db.GetDuplicateCandidates(feed.ID, newArticles, duplicateMaxTimeApart)
+	if err != nil {
+		log.Printf("Error getting duplicate candidates for feed %s: %v", feed.Title, err)
+		return
+	}
+
+	linked := 0
+	for _, article := range newArticles {
+		canonicalID := findCanonical(*article, candidates)
+		if canonicalID == 0 {
+			continue
+		}
+		if err := f.db.SetArticleCanonical(article.ID, canonicalID); err != nil {
+			log.Printf("Error linking duplicate article %d: %v", article.ID, err)
+			continue
+		}
+		switch handling {
+		case DuplicateHandlingMarkRead:
+			err = f.db.MarkArticleRead(article.ID, true)
+		case DuplicateHandlingHide:
+			err = f.db.HideDuplicateArticle(article.ID)
+		}
+		if err != nil {
+			log.Printf("Error handling duplicate article %d: %v", article.ID, err)
+		}
+		linked++
+	}
+	if linked > 0 {
+		utils.DebugLog("Linked %d duplicate articles in feed %s", linked, feed.Title)
+	}
+}
+
+// findCanonical returns the canonical article of the story an article duplicates, or 0 if it is new.
+// When several candidates match, the oldest story wins.
+func findCanonical(article models.Article, candidates []models.Article) int64 {
+	var canonicalID int64
+	for _, c := range candidates {
+		if !isDuplicateArticle(article, c) {
+			continue
+		}
+		id := c.ID
+		if c.CanonicalID != 0 {
+			id = c.CanonicalID
+		}
+		if canonicalID == 0 || id < canonicalID {
+			canonicalID = id
+		}
+	}
+	return canonicalID
+}
@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestIsDuplicateArticle(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		a, b models.Article
+		want bool
+	}{
+		{
+			name: "same URL with tracking parameters",
+			a:    models.Article{Title: "Story", URL: "https://blog.example/post/1?utm_source=agg", PublishedAt: now},
+			b:    models.Article{Title: "Different headline", URL: "https://blog.example/post/1", PublishedAt: now},
+			want: true,
+		},
+		{
+			name: "same path, similar title",
+			a:    models.Article{Title: "Release notes for 2.0", URL: "https://blog.example/post?id=1&ref=x", PublishedAt: now},
+			b:    models.Article{Title: "Release notes for 2.0!", URL: "https://blog.example/post?id=1&ref=y", PublishedAt: now},
+			want: true,
+		},
+		{
+			name: "same URL over http and www",
+			a:    models.Article{Title: "Story", URL: "http://www.blog.example/post/1", PublishedAt: now},
+			b:    models.Article{Title: "Different headline", URL: "https://blog.example/post/1", PublishedAt: now},
+			want: true,
+		},
+		{
+			name: "wire copy under another URL",
+			a:    models.Article{Title: "Central bank raises interest rates by half a point", URL: "https://paper-a.example/economy/123", PublishedAt: now},
+			b:    models.Article{Title: "Central bank raises interest rates by half a point", URL: "https://paper-b.example/news/abc", PublishedAt: now.Add(-3 * time.Hour)},
+			want: true,
+		},
+		{
+			name: "same title published far apart",
+			a:    models.Article{Title: "Central bank raises interest rates by half a point", URL: "https://paper-a.example/1", PublishedAt: now},
+			b:    models.Article{Title: "Central bank raises interest rates by half a point", URL: "https://paper-b.example/2", PublishedAt: now.Add(-30 * 24 * time.Hour)},
+			want: false,
+		},
+		{
+			name: "short generic title",
+			a:    models.Article{Title: "Weekly update", URL: "https://a.example/w1", PublishedAt: now},
+			b:    models.Article{Title: "Weekly update", URL: "https://b.example/w1", PublishedAt: now},
+			want: false,
+		},
+		{
+			name: "home page links",
+			a:    models.Article{Title: "One", URL: "https://site.example/", PublishedAt: now},
+			b:    models.Article{Title: "Two", URL: "https://site.example/", PublishedAt: now},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicateArticle(tt.a, tt.b); got != tt.want {
+				t.Errorf("isDuplicateArticle = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveFeedArticles_LinksDuplicates(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+
+	blogID, _ := db.AddFeed(&models.Feed{Title: "Blog", URL: "https://blog.example/feed"})
+	aggID, _ := db.AddFeed(&models.Feed{Title: "Aggregator", URL: "https://agg.example/feed"})
+	wireID, _ := db.AddFeed(&models.Feed{Title: "Wire", URL: "https://wire.example/feed"})
+	blog, _ := db.GetFeedByID(blogID)
+	agg, _ := db.GetFeedByID(aggID)
+	wire, _ := db.GetFeedByID(wireID)
+
+	now := time.Now()
+	original := &models.Article{FeedID: blogID, Title: "How we scaled our database to a billion rows", URL: "https://blog.example/posts/scaling", PublishedAt: now}
+	if !f.saveFeedArticles(context.Background(), *blog, []*models.Article{original}) {
+		t.Fatal("saving original failed")
+	}
+
+	// Default handling marks duplicates read
+	// Linked over http and through the www host, and dated much later by the aggregator
+	viaAgg := &models.Article{FeedID: aggID, Title: "How we scaled our database to a billion rows", URL: "http://www.blog.example/posts/scaling?utm_source=agg", PublishedAt: now.Add(10 * 24 * time.Hour)}
+	unrelated := &models.Article{FeedID: aggID, Title: "Something else entirely happened today", URL: "https://agg.example/other", PublishedAt: now}
+	if !f.saveFeedArticles(context.Background(), *agg, []*models.Article{viaAgg, unrelated}) {
+		t.Fatal("saving aggregator articles failed")
+	}
+
+	got, _ := db.GetArticleByID(viaAgg.ID)
+	if got.CanonicalID != original.ID || !got.IsRead || got.IsHidden {
+		t.Errorf("aggregator copy: canonical %d read %v hidden %v, want canonical %d, read", got.CanonicalID, got.IsRead, got.IsHidden, original.ID)
+	}
+	if got, _ := db.GetArticleByID(unrelated.ID); got.CanonicalID != 0 || got.IsRead {
+		t.Errorf("unrelated article linked: canonical %d read %v", got.CanonicalID, got.IsRead)
+	}
+
+	// Wire copy under its own URL with a slightly different title, matched by title similarity
+	db.SetSetting("duplicate_handling", DuplicateHandlingHide)
+	viaWire := &models.Article{FeedID: wireID, Title: "How We Scaled Our Databases to a Billion Rows", URL: "https://wire.example/stories/987", PublishedAt: now.Add(-3 * time.Hour)}
+	if !f.saveFeedArticles(context.Background(), *wire, []*models.Article{viaWire}) {
+		t.Fatal("saving wire article failed")
+	}
+	if got, _ := db.GetArticleByID(viaWire.ID); got.CanonicalID != original.ID || !got.IsHidden {
+		t.Errorf("wire copy: canonical %d hidden %v, want canonical %d, hidden", got.CanonicalID, got.IsHidden, original.ID)
+	}
+
+	if got, _ := db.GetArticleByID(original.ID); got.DuplicateCount != 2 || got.IsRead {
+		t.Errorf("original: %d duplicates, read %v; want 2 duplicates, unread", got.DuplicateCount, got.IsRead)
+	}
+
+	copies, err := db.GetArticleCopies(viaWire.ID)
+	if err != nil {
+		t.Fatalf("GetArticleCopies: %v", err)
+	}
+	if len(copies) != 3 || !copies[0].IsCanonical || copies[0].FeedTitle != "Blog" {
+		t.Errorf("copies = %+v", copies)
+	}
+
+	// Refetching already saved articles does not touch them
+	db.MarkArticleRead(viaAgg.ID, false)
+	again := &models.Article{FeedID: aggID, Title: viaAgg.Title, URL: viaAgg.URL, PublishedAt: now}
+	f.saveFeedArticles(context.Background(), *agg, []*models.Article{again})
+	if got, _ := db.GetArticleByID(viaAgg.ID); got.IsRead {
+		t.Error("refetched duplicate was marked read again")
+	}
+}
+
+func TestSaveFeedArticles_DuplicateHandlingOff(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	db.SetSetting("duplicate_handling", DuplicateHandlingOff)
+
+	aID, _ := db.AddFeed(&models.Feed{Title: "A", URL: "https://a.example/feed"})
+	bID, _ := db.AddFeed(&models.Feed{Title: "B", URL: "https://b.example/feed"})
+	a, _ := db.GetFeedByID(aID)
+	b, _ := db.GetFeedByID(bID)
+
+	f.saveFeedArticles(context.Background(), *a, []*models.Article{{FeedID: aID, Title: "Same", URL: "https://site.example/story"}})
+	dup := &models.Article{FeedID: bID, Title: "Same", URL: "https://site.example/story"}
+	f.saveFeedArticles(context.Background(), *b, []*models.Article{dup})
+
+	if got, _ := db.GetArticleByID(dup.ID); got.CanonicalID != 0 || got.IsRead {
+		t.Errorf("duplicate handled although detection is off: canonical %d read %v", got.CanonicalID, got.IsRead)
+	}
+}
@@ -0,0 +1,294 @@
+package feed
+
+import (
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// maxArticlePages bounds how many pages of a multi-page article are fetched
+const maxArticlePages = 10
+
+// Rule domains are host names, without scheme, port or path
+var ruleDomainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// isXPathSelector reports whether a rule selector is an XPath expression rather than a CSS selector.
+func isXPathSelector(selector string) bool {
+	selector = strings.TrimSpace(selector)
+	return strings.HasPrefix(selector, "/") || strings.HasPrefix(selector, "(")
+}
+
+// selectNodes returns the nodes of doc matched by an XPath expression or CSS selector.
+// XPath expressions selecting attributes return nodes whose text is the attribute value.
+func selectNodes(doc *html.Node, selector string) ([]*html.Node, error) {
+	if isXPathSelector(selector) {
+		return htmlquery.QueryAll(doc, selector)
+	}
+	group, err := cascadia.ParseGroup(selector)
+	if err != nil {
+		return nil, err
+	}
+	return cascadia.QueryAll(doc, group), nil
+}
+
+// ValidateExtractionRule normalizes the domain of a rule and checks its selectors.
+func ValidateExtractionRule(rule *models.ExtractionRule) error {
+	rule.Domain = database.NormalizeRuleDomain(rule.Domain)
+	if !ruleDomainPattern.MatchString(rule.Domain) {
+		return fmt.Errorf("invalid domain: %q", rule.Domain)
+	}
+
+	empty, _ := html.Parse(strings.NewReader(""))
+	check := func(field string, selectors ...string) error {
+		for _, selector := range selectors {
+			if strings.TrimSpace(selector) == "" {
+				return fmt.Errorf("%s: empty selector", field)
+			}
+			if _, err := selectNodes(empty, selector); err != nil {
+				return fmt.Errorf("%s: invalid selector %q: %v", field, selector, err)
+			}
+		}
+		return nil
+	}
+	if err := check("content_selectors", rule.ContentSelectors...); err != nil {
+		return err
+	}
+	if err := check("strip_selectors", rule.StripSelectors...); err != nil {
+		return err
+	}
+	if rule.NextPageSelector != "" {
+		if err := check("next_page_selector", rule.NextPageSelector); err != nil {
+			return err
+		}
+	}
+	for _, attr := range rule.LazyImageAttrs {
+		if attr == "" || strings.ContainsAny(attr, " \t\"'=<>") {
+			return fmt.Errorf("lazy_image_attrs: invalid attribute name %q", attr)
+		}
+	}
+	return nil
+}
+
+// extractWithRule extracts the article content of one page with a site's rule. It returns the
+// content and the URL of the article's next page, or nil for the last page. If none of the
+// rule's content selectors matches, readability extracts the content of the cleaned page.
+func extractWithRule(doc *html.Node, pageURL *url.URL, rule *models.ExtractionRule) (string, *url.URL, error) {
+	// The pagination usually sits in an element the rule strips
+	next := findNextPage(doc, pageURL, rule.NextPageSelector)
+
+	for _, selector := range rule.StripSelectors {
+		nodes, err := selectNodes(doc, selector)
+		if err != nil {
+			utils.DebugLog("Extraction rule %s: strip selector %q: %v", rule.Domain, selector, err)
+			continue
+		}
+		for _, n := range nodes {
+			if n.Parent != nil {
+				n.Parent.RemoveChild(n)
+			}
+		}
+	}
+	fixLazyImages(doc, rule.LazyImageAttrs)
+
+	for _, selector := range rule.ContentSelectors {
+		nodes, err := selectNodes(doc, selector)
+		if err != nil {
+			utils.DebugLog("Extraction rule %s: content selector %q: %v", rule.Domain, selector, err)
+			continue
+		}
+		var buf bytes.Buffer
+		for _, n := range nodes {
+			if n.Type != html.ElementNode || n.Parent == nil {
+				continue
+			}
+			resolveRelativeURLs(n, pageURL)
+			if err := html.Render(&buf, n); err != nil {
+				return "", nil, fmt.Errorf("render HTML: %w", err)
+			}
+		}
+		if buf.Len() > 0 {
+			return buf.String(), next, nil
+		}
+	}
+
+	content, err := readabilityContent(doc, pageURL)
+	return content, next, err
+}
+
+// findNextPage returns the URL of the link matched by selector, or nil if there is none.
+// The selector may match the link element or its href attribute.
+func findNextPage(doc *html.Node, pageURL *url.URL, selector string) *url.URL {
+	if selector == "" {
+		return nil
+	}
+	nodes, err := selectNodes(doc, selector)
+	if err != nil || len(nodes) == 0 {
+		return nil
+	}
+	href := htmlquery.SelectAttr(nodes[0], "href")
+	if href == "" {
+		href = htmlquery.InnerText(nodes[0])
+	}
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return nil
+	}
+	next, err := pageURL.Parse(href)
+	if err != nil || (next.Scheme != "http" && next.Scheme != "https") {
+		return nil
+	}
+	next.Fragment = ""
+	return next
+}
+
+// fixLazyImages copies the first non-empty lazy-loading attribute of each image into its src,
+// or its srcset for attributes holding a srcset.
+func fixLazyImages(doc *html.Node, attrs []string) {
+	if len(attrs) == 0 {
+		return
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "img" || n.Data == "source") {
+			for _, name := range attrs {
+				value := strings.TrimSpace(htmlquery.SelectAttr(n, name))
+				if value == "" || strings.HasPrefix(value, "data:") {
+					continue
+				}
+				target := "src"
+				if strings.Contains(strings.ToLower(name), "srcset") || n.Data == "source" {
+					target = "srcset"
+				}
+				setAttr(n, target, value)
+				break
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+}
+
+// resolveRelativeURLs makes the links and image sources of n absolute, since the content is
+// shown away from its page.
+func resolveRelativeURLs(n *html.Node, base *url.URL) {
+	if n.Type == html.ElementNode {
+		for i, attr := range n.Attr {
+			if attr.Key != "href" && attr.Key != "src" {
+				continue
+			}
+			if strings.HasPrefix(attr.Val, "#") || strings.HasPrefix(attr.Val, "data:") {
+				continue
+			}
+			if u, err := base.Parse(strings.TrimSpace(attr.Val)); err == nil {
+				n.Attr[i].Val = u.String()
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		resolveRelativeURLs(c, base)
+	}
+}
+
+func setAttr(n *html.Node, key, value string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
+
+// ParseSiteConfig reads an extraction rule from a site config file in the format of the
+// ftr-site-config project (https://github.com/fivefilters/ftr-site-config). Directives it does
+// not support and selectors it cannot compile are skipped and reported in the returned warnings.
+func ParseSiteConfig(r io.Reader, domain string) (*models.ExtractionRule, []string, error) {
+	rule := &models.ExtractionRule{Domain: domain}
+	var warnings, nextPages []string
+	unsupported := make(map[string]bool)
+	empty, _ := html.Parse(strings.NewReader(""))
+	valid := func(key, selector string) bool {
+		if _, err := selectNodes(empty, selector); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: invalid selector %q: %v", key, selector, err))
+			return false
+		}
+		return true
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		var selector string
+		switch key {
+		case "body", "strip", "next_page_link":
+			selector = value
+		case "strip_id_or_class":
+			value = strings.Trim(value, `"'`)
+			if value == "" || strings.Contains(value, "'") {
+				continue
+			}
+			selector = fmt.Sprintf("//*[@id='%[1]s' or contains(concat(' ', normalize-space(@class), ' '), ' %[1]s ')]", value)
+		case "strip_image_src":
+			value = strings.Trim(value, `"'`)
+			if value == "" || strings.Contains(value, "'") {
+				continue
+			}
+			selector = fmt.Sprintf("//img[contains(@src, '%s')]", value)
+		default:
+			// Directives with arguments, such as replace_string(...), are reported by name
+			if i := strings.Index(key, "("); i > 0 {
+				key = key[:i]
+			}
+			if !unsupported[key] {
+				unsupported[key] = true
+				warnings = append(warnings, "unsupported directive: "+key)
+			}
+			continue
+		}
+		if !valid(key, selector) {
+			continue
+		}
+		switch key {
+		case "body":
+			rule.ContentSelectors = append(rule.ContentSelectors, selector)
+		case "next_page_link":
+			nextPages = append(nextPages, selector)
+		default:
+			rule.StripSelectors = append(rule.StripSelectors, selector)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	// The alternatives are XPath expressions, so they combine into a union
+	rule.NextPageSelector = strings.Join(nextPages, " | ")
+
+	if err := ValidateExtractionRule(rule); err != nil {
+		return nil, warnings, err
+	}
+	return rule, warnings, nil
+}
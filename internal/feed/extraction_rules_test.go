@@ -0,0 +1,155 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+// articlePage renders one page of a multi-page article, with a teaser, comments and a lazy-loaded image.
+func articlePage(text, image, pager string) string {
+	return fmt.Sprintf(`<html><body>
+<div class="teaser">Subscribe to read more</div>
+<div id="story">
+  <p>%s</p>
+  <img src="/placeholder.gif" data-lazy-src="/images/%s.jpg">
+  <div class="comments">First!</div>
+</div>
+<nav class="pager">%s</nav>
+<aside>Related stories</aside>
+</body></html>`, text, image, pager)
+}
+
+func servePages(pages map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+}
+
+func TestExtractFullText_WithRule(t *testing.T) {
+	server := servePages(map[string]string{
+		"/story":        articlePage("Page one text.", "one", `<a rel="next" href="/story?page=2">Next</a>`),
+		"/story?page=2": articlePage("Page two text.", "two", `<a rel="prev" href="/story">Back</a> <a rel="next" href="/story?page=3">Next</a>`),
+		// The last page links back to the first one
+		"/story?page=3": articlePage("Page three text.", "three", `<a rel="next" href="/story">Start over</a>`),
+	})
+	defer server.Close()
+
+	rule := &models.ExtractionRule{
+		Domain:           "127.0.0.1",
+		ContentSelectors: []string{"article .body", `//div[@id="story"]`},
+		StripSelectors:   []string{".comments", "//nav"},
+		NextPageSelector: `//a[@rel="next"]/@href`,
+		LazyImageAttrs:   []string{"data-lazy-src"},
+	}
+	content, err := ExtractFullText(context.Background(), server.Client(), server.URL+"/story", rule)
+	if err != nil {
+		t.Fatalf("ExtractFullText error: %v", err)
+	}
+
+	for _, want := range []string{"Page one text.", "Page two text.", "Page three text.", `src="` + server.URL + `/images/two.jpg"`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content misses %q:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"Subscribe", "First!", "Related", "Next", "placeholder.gif"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("content contains %q:\n%s", unwanted, content)
+		}
+	}
+	if n := strings.Count(content, "Page one text."); n != 1 {
+		t.Errorf("first page included %d times", n)
+	}
+}
+
+func TestExtractFullText_RuleFallsBackToReadability(t *testing.T) {
+	paragraphs := strings.Repeat("<p>A long paragraph of the article that readability should keep around.</p>", 10)
+	server := servePages(map[string]string{
+		"/post": `<html><body><div class="ad">Buy now</div><article>` + paragraphs + `</article></body></html>`,
+	})
+	defer server.Close()
+
+	rule := &models.ExtractionRule{Domain: "127.0.0.1", ContentSelectors: []string{".no-such-element"}, StripSelectors: []string{".ad"}}
+	content, err := ExtractFullText(context.Background(), server.Client(), server.URL+"/post", rule)
+	if err != nil {
+		t.Fatalf("ExtractFullText error: %v", err)
+	}
+	if !strings.Contains(content, "readability should keep around") || strings.Contains(content, "Buy now") {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestValidateExtractionRule(t *testing.T) {
+	valid := models.ExtractionRule{Domain: " .Example.COM ", ContentSelectors: []string{"div.post", "//article"}}
+	if err := ValidateExtractionRule(&valid); err != nil {
+		t.Fatalf("ValidateExtractionRule error: %v", err)
+	}
+	if valid.Domain != "example.com" {
+		t.Errorf("domain = %q", valid.Domain)
+	}
+
+	invalid := []models.ExtractionRule{
+		{Domain: "https://example.com/"},
+		{Domain: "example.com", ContentSelectors: []string{"//div[@class="}},
+		{Domain: "example.com", StripSelectors: []string{"div >> p"}},
+		{Domain: "example.com", StripSelectors: []string{""}},
+		{Domain: "example.com", NextPageSelector: "//a[@rel='next'"},
+		{Domain: "example.com", LazyImageAttrs: []string{"data src"}},
+	}
+	for _, rule := range invalid {
+		if err := ValidateExtractionRule(&rule); err == nil {
+			t.Errorf("ValidateExtractionRule(%+v) succeeded", rule)
+		}
+	}
+}
+
+func TestParseSiteConfig(t *testing.T) {
+	config := `# Example site config
+title: //h1[@class='headline']
+body: //div[@itemprop='articleBody']
+body: //article
+strip: //div[contains(@class, 'newsletter')]
+strip_id_or_class: comments
+strip_image_src: /tracking/
+strip: //div[@class=
+next_page_link: //a[@rel='next']
+next_page_link: //a[contains(@class, 'next-page')]
+prune: no
+replace_string(<noscript>): <div>
+test_url: https://www.example.com/2024/01/story
+`
+	rule, warnings, err := ParseSiteConfig(strings.NewReader(config), ".example.com")
+	if err != nil {
+		t.Fatalf("ParseSiteConfig error: %v", err)
+	}
+	if rule.Domain != "example.com" {
+		t.Errorf("domain = %q", rule.Domain)
+	}
+	if len(rule.ContentSelectors) != 2 || rule.ContentSelectors[0] != "//div[@itemprop='articleBody']" {
+		t.Errorf("content selectors = %q", rule.ContentSelectors)
+	}
+	if len(rule.StripSelectors) != 3 || !strings.Contains(rule.StripSelectors[1], "' comments '") || !strings.Contains(rule.StripSelectors[2], "/tracking/") {
+		t.Errorf("strip selectors = %q", rule.StripSelectors)
+	}
+	if rule.NextPageSelector != "//a[@rel='next'] | //a[contains(@class, 'next-page')]" {
+		t.Errorf("next page selector = %q", rule.NextPageSelector)
+	}
+
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"unsupported directive: title", "unsupported directive: prune", "unsupported directive: replace_string", "unsupported directive: test_url", "strip: invalid selector"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings miss %q: %q", want, warnings)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package feed
 
 import (
 	"MrRSS/internal/database"
+	"MrRSS/internal/discovery"
 	"MrRSS/internal/models"
+	"MrRSS/internal/refreshpolicy"
 	"MrRSS/internal/rules"
 	"MrRSS/internal/translation"
 	"MrRSS/internal/utils"
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -36,6 +39,25 @@ type Fetcher struct {
 	queueMu     sync.Mutex
 	// Priority system
 	priorityMu sync.Mutex // Protects priority operations
+	// Per-host limit shared by all refreshes
+	hostLimiter *hostLimiter
+	// Finds the feed a web page links to when subscribing to a page
+	feedFinder FeedFinder
+	// Automatic full-text fetching of new articles
+	fullTextLimiter *hostRateLimiter
+	fullTextWake    chan struct{}
+	// Archive backfill of a feed, protected by mu
+	backfill       Progress
+	backfillCancel context.CancelFunc
+	// Quiet hours, low data mode and daily data budget
+	policies *refreshpolicy.Service
+	// Directory mailboxes must be in, "" if unavailable
+	mailDir string
+}
+
+// FeedFinder finds the feed of a web page, e.g. through its <link rel="alternate"> tags.
+type FeedFinder interface {
+	FindFeed(ctx context.Context, pageURL string) (string, error)
 }
 
 func NewFetcher(db *database.DB, translator translation.Translator) *Fetcher {
@@ -45,6 +67,7 @@ func NewFetcher(db *database.DB, translator translation.Translator) *Fetcher {
 	if err == nil {
 		executor = NewScriptExecutor(scriptsDir)
 	}
+	mailDir, _ := utils.GetMailDir()
 
 	// Create HTTP client for feed parsing
 	httpClient, err := CreateHTTPClient("")
@@ -69,6 +92,12 @@ func NewFetcher(db *database.DB, translator translation.Translator) *Fetcher {
 		scriptExecutor:    executor,
 		refreshCalculator: NewIntelligentRefreshCalculator(db),
 		queuedFeeds:       make(map[int64]bool),
+		hostLimiter:       newHostLimiter(),
+		feedFinder:        discovery.NewService(),
+		fullTextLimiter:   newHostRateLimiter(),
+		fullTextWake:      make(chan struct{}, 1),
+		policies:          refreshpolicy.New(db),
+		mailDir:           mailDir,
 	}
 }
 
@@ -130,6 +159,7 @@ func (f *Fetcher) getConcurrencyLimit(feedCount int) int {
 // 1. Feed custom proxy (ProxyEnabled=true, ProxyURL != "") - highest priority
 // 2. Global proxy (global proxy_enabled=true) - automatically applied to all feeds
 // 3. No proxy (global proxy not enabled and feed has no custom proxy)
+// The feed's credentials, custom headers, cookies and User-Agent override are applied as well.
 func (f *Fetcher) getHTTPClient(feed models.Feed) (*http.Client, error) {
 	var proxyURL string
 
@@ -154,7 +184,21 @@ func (f *Fetcher) getHTTPClient(feed models.Feed) (*http.Client, error) {
 	}
 
 	// Create HTTP client with or without proxy
-	return CreateHTTPClient(proxyURL)
+	client, err := CreateHTTPClient(proxyURL)
+	if err != nil || !hasRequestOptions(feed) {
+		return client, err
+	}
+	opts, err := f.db.GetFeedRequestOptions(feed.ID)
+	if err != nil {
+		return nil, err
+	}
+	return withRequestOptions(client, feed.URL, opts)
+}
+
+// GetHTTPClient returns the HTTP client used to fetch a feed, which can also fetch its article pages.
+// Its responses count towards the data usage of the feed's category.
+func (f *Fetcher) GetHTTPClient(feed models.Feed) (*http.Client, error) {
+	return f.getMeteredHTTPClient(feed)
 }
 
 // setupTranslator configures the translator based on database settings.
@@ -228,13 +272,26 @@ func (f *Fetcher) FetchAll(ctx context.Context) {
 		return
 	}
 
+	// Paused feeds are only fetched on explicit request
+	activeFeeds := feeds[:0]
+	for _, feed := range feeds {
+		if !feed.IsPaused {
+			activeFeeds = append(activeFeeds, feed)
+		}
+	}
+	feeds = activeFeeds
+
 	f.mu.Lock()
 	f.progress.Total = len(feeds)
 	f.mu.Unlock()
 
 	var wg sync.WaitGroup
 	concurrency := f.getConcurrencyLimit(len(feeds))
+	if f.lowDataMode() {
+		concurrency = min(concurrency, lowDataConcurrency)
+	}
 	sem := make(chan struct{}, concurrency) // Limit concurrency based on network speed
+	hostLimit := f.getHostConcurrencyLimit()
 
 	for _, feed := range feeds {
 		// Check for cancellation
@@ -246,22 +303,13 @@ func (f *Fetcher) FetchAll(ctx context.Context) {
 		}
 
 		wg.Add(1)
-		sem <- struct{}{}
 		go func(fd models.Feed) {
 			defer wg.Done()
-			defer func() { <-sem }()
-
-			// Check for cancellation inside goroutine before starting
-			select {
-			case <-ctx.Done():
-				return
-			default:
+			if f.fetchFeedWithLimits(ctx, fd, sem, hostLimit) {
+				f.mu.Lock()
+				f.progress.Current++
+				f.mu.Unlock()
 			}
-
-			f.FetchFeed(ctx, fd)
-			f.mu.Lock()
-			f.progress.Current++
-			f.mu.Unlock()
 		}(feed)
 	}
 
@@ -277,11 +325,24 @@ Finish:
 }
 
 func (f *Fetcher) FetchFeed(ctx context.Context, feed models.Feed) {
-	// Use ParseFeedWithFeed with normal priority for feed refresh
-	parsedFeed, err := f.ParseFeedWithFeed(ctx, &feed, false) // Normal priority for refresh
+	started := time.Now()
+
+	// Normal priority refresh, sending stored validators so unchanged feeds answer 304
+	parsedFeed, info, err := f.parseFeedWithInfo(ctx, &feed, false, true)
+	if errors.Is(err, ErrNotModified) {
+		// Nothing changed since the last fetch; treat as a successful refresh
+		f.recordFetchSuccess(feed, info, started, 0)
+		f.handlePermanentRedirect(feed, info)
+		utils.DebugLog("Feed not modified: %s", feed.Title)
+		return
+	}
 	if err != nil {
+		// A cancelled refresh says nothing about the feed's health
+		if ctx.Err() != nil {
+			return
+		}
 		log.Printf("Error parsing feed %s: %v", feed.URL, err)
-		f.db.UpdateFeedError(feed.ID, err.Error())
+		f.recordFetchFailure(feed, info, started, err)
 		// Add error to progress for immediate feedback
 		f.mu.Lock()
 		if f.progress.Errors == nil {
@@ -292,8 +353,8 @@ func (f *Fetcher) FetchFeed(ctx context.Context, feed models.Feed) {
 		return
 	}
 
-	// Clear any previous error on successful fetch
-	f.db.UpdateFeedError(feed.ID, "")
+	// Clear any previous error and backoff on successful fetch
+	f.recordFetchSuccess(feed, info, started, len(parsedFeed.Items))
 
 	// Update Feed Image if available and not set
 	if feed.ImageURL == "" && parsedFeed.Image != nil {
@@ -315,28 +376,74 @@ func (f *Fetcher) FetchFeed(ctx context.Context, feed models.Feed) {
 	default:
 	}
 
-	if len(articlesToSave) > 0 {
-		if err := f.db.SaveArticles(ctx, articlesToSave); err != nil {
-			log.Printf("Error saving articles for feed %s: %v", feed.Title, err)
-		} else {
-			// Apply rules to newly saved articles
-			// We fetch the recent articles for this feed since SaveArticles doesn't return IDs
-			// This is limited to the number of articles we just saved
-			savedArticles, err := f.db.GetArticles("", feed.ID, "", false, len(articlesToSave), 0)
-			if err == nil && len(savedArticles) > 0 {
-				engine := rules.NewEngine(f.db)
-				affected, err := engine.ApplyRulesToArticles(savedArticles)
-				if err != nil {
-					log.Printf("Error applying rules for feed %s: %v", feed.Title, err)
-				} else if affected > 0 {
-					utils.DebugLog("Applied rules to %d articles in feed %s", affected, feed.Title)
-				}
-			}
+	saved := f.saveFeedArticles(ctx, feed, articlesToSave)
+
+	// Only remember validators once the items are stored, so a failed save is retried in full
+	if saved && info != nil {
+		if err := f.db.UpdateFeedValidators(feed.ID, info.ETag, info.LastModified); err != nil {
+			log.Printf("Error saving cache validators for feed %s: %v", feed.Title, err)
 		}
 	}
+
+	// Move the subscription once the feed has settled on a new permanent location
+	f.handlePermanentRedirect(feed, info)
+	// Let the feed's hub push updates if it advertises one
+	f.ensureWebSubSubscription(ctx, feed, info)
 	utils.DebugLog("Updated feed: %s", feed.Title)
 }
 
+// saveFeedArticles stores processed articles of a feed and applies the rules to them.
+// Returns false if saving failed.
+func (f *Fetcher) saveFeedArticles(ctx context.Context, feed models.Feed, articlesToSave []*models.Article) bool {
+	if len(articlesToSave) == 0 {
+		return true
+	}
+	if err := f.db.SaveArticles(ctx, articlesToSave); err != nil {
+		log.Printf("Error saving articles for feed %s: %v", feed.Title, err)
+		return false
+	}
+
+	// Link stories already saved from other feeds before rules run, so rules get the last word
+	f.linkDuplicates(feed, articlesToSave)
+	f.queueFullText(feed, articlesToSave)
+
+	// Apply rules to newly saved articles
+	// We fetch the recent articles for this feed since SaveArticles doesn't return IDs
+	// This is limited to the number of articles we just saved
+	savedArticles, err := f.db.GetArticles("", feed.ID, "", false, len(articlesToSave), 0)
+	if err == nil && len(savedArticles) > 0 {
+		engine := rules.NewEngine(f.db)
+		affected, err := engine.ApplyRulesToArticles(savedArticles)
+		if err != nil {
+			log.Printf("Error applying rules for feed %s: %v", feed.Title, err)
+		} else if affected > 0 {
+			utils.DebugLog("Applied rules to %d articles in feed %s", affected, feed.Title)
+		}
+	}
+	return true
+}
+
+// fetchFeedWithLimits fetches a feed once both a slot for its host and a global slot are free.
+// The host slot is taken first so feeds waiting on a busy host do not hold global slots
+// that other hosts could use. Returns false if ctx was cancelled before the fetch started.
+func (f *Fetcher) fetchFeedWithLimits(ctx context.Context, feed models.Feed, sem chan struct{}, hostLimit int) bool {
+	release, ok := f.hostLimiter.acquire(ctx, utils.URLHost(feed.URL), hostLimit)
+	if !ok {
+		return false
+	}
+	defer release()
+
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+	defer func() { <-sem }()
+
+	f.FetchFeed(ctx, feed)
+	return true
+}
+
 // FetchSingleFeed fetches a single feed with progress tracking.
 // This is used when adding a new feed, refreshing a single feed from the context menu,
 // or when the scheduler triggers individual feed refreshes.
@@ -369,8 +476,11 @@ func (f *Fetcher) FetchSingleFeed(ctx context.Context, feed models.Feed) {
 	// Setup translator based on settings
 	f.setupTranslator()
 
-	// Fetch the feed
-	f.FetchFeed(ctx, feed)
+	// Fetch the feed, waiting for a free slot if its host is already busy
+	if release, ok := f.hostLimiter.acquire(ctx, utils.URLHost(feed.URL), f.getHostConcurrencyLimit()); ok {
+		f.FetchFeed(ctx, feed)
+		release()
+	}
 
 	// Remove from queue and update progress
 	f.queueMu.Lock()
@@ -426,7 +536,11 @@ func (f *Fetcher) FetchFeedsByIDs(ctx context.Context, feedIDs []int64) {
 
 	var wg sync.WaitGroup
 	concurrency := f.getConcurrencyLimit(len(feedIDs))
+	if f.lowDataMode() {
+		concurrency = min(concurrency, lowDataConcurrency)
+	}
 	sem := make(chan struct{}, concurrency) // Limit concurrency based on network speed
+	hostLimit := f.getHostConcurrencyLimit()
 
 	for _, feedID := range feedIDs {
 		// Check for cancellation
@@ -447,21 +561,13 @@ func (f *Fetcher) FetchFeedsByIDs(ctx context.Context, feedIDs []int64) {
 		}
 
 		wg.Add(1)
-		sem <- struct{}{}
 		go func(fd models.Feed) {
 			defer wg.Done()
-			defer func() { <-sem }()
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
+			if f.fetchFeedWithLimits(ctx, fd, sem, hostLimit) {
+				f.mu.Lock()
+				f.progress.Current++
+				f.mu.Unlock()
 			}
-
-			f.FetchFeed(ctx, fd)
-			f.mu.Lock()
-			f.progress.Current++
-			f.mu.Unlock()
 		}(*feed)
 	}
 
@@ -0,0 +1,94 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestFetchFeed_ConditionalGet(t *testing.T) {
+	db := setupDBForFeedTests(t)
+
+	rss := `<?xml version="1.0"?><rss><channel><title>Cond</title>` +
+		`<item><title>one</title><link>http://example.com/1</link><guid>1</guid></item>` +
+		`</channel></rss>`
+	const etag = `"v1"`
+	const lastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+	var notModified int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(rss))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(db, nil)
+	id, err := db.AddFeed(&models.Feed{Title: "cond", URL: srv.URL})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+
+	feed, _ := db.GetFeedByID(id)
+	f.FetchFeed(context.Background(), *feed)
+
+	feed, _ = db.GetFeedByID(id)
+	if feed.ETag != etag || feed.LastModified != lastModified {
+		t.Fatalf("validators not stored: etag=%q last_modified=%q", feed.ETag, feed.LastModified)
+	}
+
+	// Second refresh should be answered with 304 and leave the feed healthy
+	db.UpdateFeedError(id, "previous failure")
+	feed, _ = db.GetFeedByID(id)
+	f.FetchFeed(context.Background(), *feed)
+
+	if atomic.LoadInt32(&notModified) != 1 {
+		t.Fatalf("expected one 304 response, got %d", notModified)
+	}
+	feed, _ = db.GetFeedByID(id)
+	if feed.LastError != "" {
+		t.Errorf("expected error cleared after 304, got %q", feed.LastError)
+	}
+	if feed.ETag != etag {
+		t.Errorf("expected validators kept after 304, got %q", feed.ETag)
+	}
+
+	articles, err := db.GetArticles("all", id, "", false, 10, 0)
+	if err != nil {
+		t.Fatalf("GetArticles error: %v", err)
+	}
+	if len(articles) != 1 {
+		t.Errorf("expected 1 article, got %d", len(articles))
+	}
+}
+
+func TestFetchFeedURL_RejectsOversizedFeed(t *testing.T) {
+	defer func(size int64) { maxFeedSize = size }(maxFeedSize)
+	maxFeedSize = 64
+
+	rss := `<?xml version="1.0"?><rss><channel><title>Too large</title>` +
+		`<item><title>one</title><link>http://example.com/1</link><guid>1</guid></item>` +
+		`</channel></rss>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(rss))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(setupDBForFeedTests(t), nil)
+	_, _, err := f.fetchFeedURL(context.Background(), srv.Client(), &models.Feed{URL: srv.URL}, false)
+	if err == nil || !strings.Contains(err.Error(), "larger than 64 bytes") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+}
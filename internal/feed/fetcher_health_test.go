@@ -0,0 +1,97 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestFailureBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, FailureBackoffBase},
+		{2, 2 * FailureBackoffBase},
+		{4, 8 * FailureBackoffBase},
+		{30, MaxFailureBackoff},
+	}
+	for _, tt := range tests {
+		if got := FailureBackoff(tt.failures); got != tt.want {
+			t.Errorf("FailureBackoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestFetchFeed_TracksFailuresAndPauses(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	db.SetSetting("feed_pause_after_failures", "2")
+
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0"?><rss><channel><title>H</title><item><title>a</title><link>http://example.com/a</link></item></channel></rss>`))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(db, nil)
+	id, err := db.AddFeed(&models.Feed{Title: "health", URL: srv.URL})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+
+	// First failure backs off but keeps the feed active
+	feed, _ := db.GetFeedByID(id)
+	f.FetchFeed(context.Background(), *feed)
+	feed, _ = db.GetFeedByID(id)
+	if feed.ConsecutiveFailures != 1 || feed.IsPaused {
+		t.Fatalf("after 1 failure: failures=%d paused=%v", feed.ConsecutiveFailures, feed.IsPaused)
+	}
+	if !feed.NextRetryAt.After(time.Now()) {
+		t.Errorf("expected next retry in the future, got %v", feed.NextRetryAt)
+	}
+
+	// Second failure reaches the threshold
+	f.FetchFeed(context.Background(), *feed)
+	feed, _ = db.GetFeedByID(id)
+	if feed.ConsecutiveFailures != 2 || !feed.IsPaused {
+		t.Fatalf("after 2 failures: failures=%d paused=%v", feed.ConsecutiveFailures, feed.IsPaused)
+	}
+
+	history, err := db.GetFeedFetchHistory(id, 10)
+	if err != nil {
+		t.Fatalf("GetFeedFetchHistory error: %v", err)
+	}
+	if len(history) != 2 || history[0].HTTPStatus != http.StatusInternalServerError || history[0].Error == "" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+
+	// A successful (manual) fetch resets the failure state
+	status = http.StatusOK
+	f.FetchFeed(context.Background(), *feed)
+	feed, _ = db.GetFeedByID(id)
+	if feed.ConsecutiveFailures != 0 || feed.IsPaused || feed.LastError != "" || !feed.NextRetryAt.IsZero() {
+		t.Errorf("expected healthy feed after success, got %+v", feed)
+	}
+	history, _ = db.GetFeedFetchHistory(id, 1)
+	if len(history) != 1 || history[0].HTTPStatus != http.StatusOK || history[0].ItemCount != 1 {
+		t.Errorf("unexpected latest history entry: %+v", history)
+	}
+
+	// HTTP 410 pauses immediately
+	status = http.StatusGone
+	f.FetchFeed(context.Background(), *feed)
+	feed, _ = db.GetFeedByID(id)
+	if !feed.IsPaused || feed.ConsecutiveFailures != 1 {
+		t.Errorf("expected feed paused after 410, got paused=%v failures=%d", feed.IsPaused, feed.ConsecutiveFailures)
+	}
+}
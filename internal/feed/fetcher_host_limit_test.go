@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestHostLimiter_LimitsPerHostOnly(t *testing.T) {
+	l := newHostLimiter()
+	ctx := context.Background()
+
+	release, ok := l.acquire(ctx, "a.example.com", 1)
+	if !ok {
+		t.Fatal("expected first slot for host a")
+	}
+
+	// Another host is not affected
+	releaseB, ok := l.acquire(ctx, "b.example.com", 1)
+	if !ok {
+		t.Fatal("expected slot for host b")
+	}
+	releaseB()
+
+	// The same host has to wait until the slot is released
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, ok := l.acquire(waitCtx, "a.example.com", 1); ok {
+		t.Fatal("expected second acquire for host a to block")
+	}
+
+	release()
+	if release, ok := l.acquire(ctx, "a.example.com", 1); !ok {
+		t.Fatal("expected slot for host a after release")
+	} else {
+		release()
+	}
+
+	// Feeds without a host are never limited
+	for i := 0; i < 3; i++ {
+		if _, ok := l.acquire(waitCtx, "", 1); !ok {
+			t.Fatal("expected empty host to be unlimited")
+		}
+	}
+}
+
+func TestFetchAll_RespectsPerHostConcurrency(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	db.SetSetting("max_concurrent_refreshes", "5")
+	db.SetSetting("max_concurrent_per_host", "1")
+
+	var active, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := atomic.AddInt32(&active, 1)
+		for {
+			prev := atomic.LoadInt32(&peak)
+			if cur <= prev || atomic.CompareAndSwapInt32(&peak, prev, cur) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0"?><rss><channel><title>t</title></channel></rss>`))
+	}))
+	defer srv.Close()
+
+	var ids []int64
+	for i := 0; i < 4; i++ {
+		id, err := db.AddFeed(&models.Feed{Title: "f", URL: fmt.Sprintf("%s/feed%d", srv.URL, i)})
+		if err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	f := NewFetcher(db, nil)
+	f.FetchAll(context.Background())
+	if peak != 1 {
+		t.Fatalf("expected at most 1 request to the host at a time, got %d", peak)
+	}
+
+	atomic.StoreInt32(&peak, 0)
+	f.FetchFeedsByIDs(context.Background(), ids)
+	if peak != 1 {
+		t.Fatalf("expected at most 1 request to the host at a time in FetchFeedsByIDs, got %d", peak)
+	}
+}
+
+func TestHostLimiter_LimitChangeKeepsFetchesInFlight(t *testing.T) {
+	l := newHostLimiter()
+	ctx := context.Background()
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, ok := l.acquire(ctx, "a.example.com", 2)
+		if !ok {
+			t.Fatalf("expected slot %d for host a", i+1)
+		}
+		releases = append(releases, release)
+	}
+
+	// Lowering the limit counts the fetches still in flight
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, ok := l.acquire(waitCtx, "a.example.com", 1); ok {
+		t.Fatal("expected acquire to block while two fetches are in flight")
+	}
+	releases[0]()
+	waitCtx2, cancel2 := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel2()
+	if _, ok := l.acquire(waitCtx2, "a.example.com", 1); ok {
+		t.Fatal("expected acquire to block while one fetch is in flight")
+	}
+
+	// A waiting fetch starts once a slot is released
+	done := make(chan func())
+	go func() {
+		release, _ := l.acquire(ctx, "a.example.com", 1)
+		done <- release
+	}()
+	time.Sleep(20 * time.Millisecond)
+	releases[1]()
+	select {
+	case release := <-done:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("expected waiting acquire to proceed after release")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.hosts) != 0 {
+		t.Errorf("expected idle hosts to be forgotten, got %d", len(l.hosts))
+	}
+}
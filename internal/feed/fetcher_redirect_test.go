@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+const redirectTestRSS = `<?xml version="1.0"?><rss><channel><title>R</title>` +
+	`<item><title>one</title><link>http://example.com/1</link><guid>1</guid></item>` +
+	`<item><title>two</title><link>http://example.com/2</link><guid>2</guid></item>` +
+	`</channel></rss>`
+
+func newRedirectTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/temp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(redirectTestRSS))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchFeed_PermanentRedirectUpdatesURL(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	db.SetSetting("feed_redirect_confirmations", "2")
+	srv := newRedirectTestServer(t)
+	f := NewFetcher(db, nil)
+
+	id, err := db.AddFeed(&models.Feed{Title: "moving", URL: srv.URL + "/old"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+	tempID, _ := db.AddFeed(&models.Feed{Title: "temporary", URL: srv.URL + "/temp"})
+
+	for i := 0; i < 2; i++ {
+		for _, feedID := range []int64{id, tempID} {
+			feed, _ := db.GetFeedByID(feedID)
+			f.FetchFeed(context.Background(), *feed)
+		}
+		feed, _ := db.GetFeedByID(id)
+		if i == 0 && feed.URL != srv.URL+"/old" {
+			t.Fatalf("URL changed before confirmation threshold: %s", feed.URL)
+		}
+	}
+
+	feed, _ := db.GetFeedByID(id)
+	if feed.URL != srv.URL+"/new" {
+		t.Fatalf("expected URL to follow the permanent redirects, got %s", feed.URL)
+	}
+	temp, _ := db.GetFeedByID(tempID)
+	if temp.URL != srv.URL+"/temp" {
+		t.Errorf("temporary redirect must not change the URL, got %s", temp.URL)
+	}
+
+	changes, err := db.GetFeedURLChanges(id)
+	if err != nil {
+		t.Fatalf("GetFeedURLChanges error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].OldURL != srv.URL+"/old" || changes[0].NewURL != srv.URL+"/new" || changes[0].MergedInto != 0 {
+		t.Errorf("unexpected URL changes: %+v", changes)
+	}
+}
+
+func TestFetchFeed_PermanentRedirectMergesIntoExistingFeed(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	db.SetSetting("feed_redirect_confirmations", "1")
+	srv := newRedirectTestServer(t)
+	f := NewFetcher(db, nil)
+
+	targetID, _ := db.AddFeed(&models.Feed{Title: "target", URL: srv.URL + "/new"})
+	target, _ := db.GetFeedByID(targetID)
+	f.FetchFeed(context.Background(), *target)
+
+	oldID, _ := db.AddFeed(&models.Feed{Title: "old", URL: srv.URL + "/old"})
+	db.SaveArticle(&models.Article{FeedID: oldID, Title: "only in old", URL: "http://example.com/3", GUID: "3"})
+	db.SaveArticle(&models.Article{FeedID: oldID, Title: "one", URL: "http://example.com/1", GUID: "1", AudioURL: "http://example.com/1.mp3", IsFavorite: true, IsReadLater: true, IsRead: true, Summary: "cached summary"})
+	db.SaveArticle(&models.Article{FeedID: oldID, Title: "one, revised", URL: "http://example.com/1", GUID: "1"})
+	oldArticles, _ := db.GetArticles("", oldID, "", false, 10, 0)
+	for _, a := range oldArticles {
+		if a.GUID == "1" {
+			if err := db.QueueEpisodeDownload(a.ID); err != nil {
+				t.Fatalf("QueueEpisodeDownload error: %v", err)
+			}
+		}
+	}
+	outputID, err := db.CreateOutputFeed(&models.OutputFeed{Name: "old only", Token: "tok", Source: models.OutputSourceFeed, SourceValue: strconv.FormatInt(oldID, 10)})
+	if err != nil {
+		t.Fatalf("CreateOutputFeed error: %v", err)
+	}
+
+	old, _ := db.GetFeedByID(oldID)
+	f.FetchFeed(context.Background(), *old)
+
+	if _, err := db.GetFeedByID(oldID); err == nil {
+		t.Fatalf("expected redirected feed to be merged away")
+	}
+
+	articles, err := db.GetArticles("", targetID, "", false, 10, 0)
+	if err != nil {
+		t.Fatalf("GetArticles error: %v", err)
+	}
+	if len(articles) != 3 {
+		t.Fatalf("expected 3 articles after merge, got %d", len(articles))
+	}
+	for _, a := range articles {
+		if a.GUID != "1" {
+			continue
+		}
+		if !a.IsFavorite || !a.IsReadLater || !a.IsRead {
+			t.Errorf("expected user state to survive the merge: favorite %v read later %v read %v", a.IsFavorite, a.IsReadLater, a.IsRead)
+		}
+		if got, _ := db.GetArticleByID(a.ID); got.Summary != "cached summary" {
+			t.Errorf("expected cached summary to survive the merge, got %q", got.Summary)
+		}
+		if revisions, _ := db.GetArticleRevisions(a.ID); len(revisions) < 2 {
+			t.Errorf("expected revisions to move to the surviving copy, got %d", len(revisions))
+		}
+		if download, _ := db.GetEpisodeDownload(a.ID); download == nil {
+			t.Errorf("expected the episode download to move to the surviving copy")
+		}
+	}
+
+	if of, _ := db.GetOutputFeedByID(outputID); of == nil || of.SourceValue != strconv.FormatInt(targetID, 10) {
+		t.Errorf("expected the output feed to follow the merge, got %+v", of)
+	}
+
+	changes, _ := db.GetFeedURLChanges(targetID)
+	if len(changes) != 1 || changes[0].FeedID != oldID || changes[0].MergedInto != targetID {
+		t.Errorf("unexpected URL changes: %+v", changes)
+	}
+}
@@ -0,0 +1,169 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestAddSubscriptionWithOptions_SendsCredentials(t *testing.T) {
+	var mu sync.Mutex
+	var leaked http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		leaked = r.Header.Clone()
+		mu.Unlock()
+		w.Write([]byte(redirectTestRSS))
+	}))
+	t.Cleanup(other.Close)
+	// Same server, but reached under a different host name
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "alice" || pass != "s3cret" || r.Header.Get("X-Api-Key") != "k1" ||
+			r.Header.Get("Cookie") != "session=abc" || r.Header.Get("User-Agent") != "PrivateReader/1.0" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/elsewhere" {
+			http.Redirect(w, r, otherURL+"/feed", http.StatusFound)
+			return
+		}
+		w.Write([]byte(redirectTestRSS))
+	}))
+	t.Cleanup(private.Close)
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	opts := models.FeedRequestOptions{
+		AuthType:      models.FeedAuthBasic,
+		AuthUsername:  "alice",
+		AuthSecret:    "s3cret",
+		CustomHeaders: `{"X-Api-Key": "k1"}`,
+		Cookies:       "session=abc",
+		UserAgent:     "PrivateReader/1.0",
+	}
+
+	if _, err := f.AddSubscription(private.URL+"/feed", "", ""); err == nil {
+		t.Fatal("expected subscription without credentials to fail")
+	}
+	id, err := f.AddSubscriptionWithOptions(private.URL+"/feed", "", "", opts)
+	if err != nil {
+		t.Fatalf("AddSubscriptionWithOptions error: %v", err)
+	}
+
+	feed, _ := db.GetFeedByID(id)
+	if feed.AuthType != models.FeedAuthBasic || !feed.HasRequestSecrets {
+		t.Fatalf("request options not stored: %+v", feed)
+	}
+	f.FetchFeed(context.Background(), *feed)
+	feed, _ = db.GetFeedByID(id)
+	if feed.LastError != "" {
+		t.Fatalf("fetch with stored options failed: %s", feed.LastError)
+	}
+
+	// Credentials stay with the feed's host when a request is redirected elsewhere
+	client, err := f.getHTTPClient(*feed)
+	if err != nil {
+		t.Fatalf("getHTTPClient error: %v", err)
+	}
+	resp, err := client.Get(private.URL + "/elsewhere")
+	if err != nil {
+		t.Fatalf("redirected request error: %v", err)
+	}
+	resp.Body.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if leaked == nil {
+		t.Fatal("redirect target was not requested")
+	}
+	if leaked.Get("Authorization") != "" || leaked.Get("X-Api-Key") != "" || leaked.Get("Cookie") != "" {
+		t.Errorf("credentials sent to another host: %v", leaked)
+	}
+	if leaked.Get("User-Agent") != "PrivateReader/1.0" {
+		t.Errorf("User-Agent override not applied to other host, got %q", leaked.Get("User-Agent"))
+	}
+}
+
+func TestValidateRequestOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    models.FeedRequestOptions
+		wantErr bool
+	}{
+		{"empty", models.FeedRequestOptions{}, false},
+		{"bearer", models.FeedRequestOptions{AuthType: models.FeedAuthBearer, AuthSecret: "t"}, false},
+		{"unknown auth", models.FeedRequestOptions{AuthType: "digest"}, true},
+		{"headers object", models.FeedRequestOptions{CustomHeaders: `{"A": "b"}`}, false},
+		{"headers array", models.FeedRequestOptions{CustomHeaders: `["A"]`}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRequestOptions(tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRequestOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// resolverFunc resolves URLs with a function, for tests.
+type resolverFunc func(u *url.URL) (string, bool)
+
+func (resolverFunc) Name() string { return "test" }
+
+func (r resolverFunc) Resolve(_ context.Context, u *url.URL, _ ResolverEnv) (string, bool, error) {
+	feedURL, ok := r(u)
+	return feedURL, ok, nil
+}
+
+func TestAddSubscriptionWithOptions_KeepsCredentialsOnEnteredHost(t *testing.T) {
+	var mu sync.Mutex
+	var leaked http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		leaked = r.Header.Clone()
+		mu.Unlock()
+		w.Write([]byte(redirectTestRSS))
+	}))
+	t.Cleanup(other.Close)
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(redirectTestRSS))
+	}))
+	t.Cleanup(private.Close)
+
+	// A resolver that takes the feed host from the URL, like a remote Mastodon account
+	saved := urlResolvers
+	t.Cleanup(func() { urlResolvers = saved })
+	urlResolvers = []URLResolver{resolverFunc(func(u *url.URL) (string, bool) {
+		return otherURL + "/feed", u.Path == "/@alice@elsewhere"
+	})}
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	opts := models.FeedRequestOptions{AuthType: models.FeedAuthBearer, AuthSecret: "t0ken"}
+	id, err := f.AddSubscriptionWithOptions(private.URL+"/@alice@elsewhere", "", "", opts)
+	if err != nil {
+		t.Fatalf("AddSubscriptionWithOptions error: %v", err)
+	}
+	if feed, _ := db.GetFeedByID(id); feed.URL != private.URL+"/@alice@elsewhere" {
+		t.Errorf("subscribed to %q, want the entered URL", feed.URL)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if leaked != nil {
+		t.Errorf("resolved feed on another host was requested with headers %v", leaked)
+	}
+}
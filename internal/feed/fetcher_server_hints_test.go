@@ -0,0 +1,116 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(time.Hour).Format(http.TimeFormat), time.Hour},
+		{now.Add(-time.Hour).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"max-age=600", 10 * time.Minute},
+		{"public, MAX-AGE=60, must-revalidate", time.Minute},
+		{"no-cache, max-age=600", 0},
+		{"max-age=abc", 0},
+	}
+	for _, tt := range tests {
+		if got := parseMaxAge(tt.value); got != tt.want {
+			t.Errorf("parseMaxAge(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFetchFeed_RecordsServerHints(t *testing.T) {
+	db := setupDBForFeedTests(t)
+
+	var status int
+	var headers map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers {
+			w.Header().Set(k, v)
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0"?><rss><channel><title>H</title><item><title>a</title><link>http://example.com/a</link></item></channel></rss>`))
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(db, nil)
+	id, err := db.AddFeed(&models.Feed{Title: "hints", URL: srv.URL})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+	fetch := func() *models.Feed {
+		feed, _ := db.GetFeedByID(id)
+		f.FetchFeed(context.Background(), *feed)
+		feed, _ = db.GetFeedByID(id)
+		return feed
+	}
+
+	// 429 with Retry-After defers the feed and its host
+	status, headers = http.StatusTooManyRequests, map[string]string{"Retry-After": "120"}
+	feed := fetch()
+	if d := time.Until(feed.DeferredUntil); d < time.Minute || d > 2*time.Minute {
+		t.Errorf("expected feed deferred by ~2m, got %v", d)
+	}
+	if !strings.Contains(feed.DeferReason, "429") {
+		t.Errorf("unexpected defer reason %q", feed.DeferReason)
+	}
+	hosts, err := db.GetHostDeferrals(time.Now())
+	if err != nil {
+		t.Fatalf("GetHostDeferrals error: %v", err)
+	}
+	if _, ok := hosts[utils.URLHost(srv.URL)]; !ok {
+		t.Errorf("expected host deferral for %s, got %+v", utils.URLHost(srv.URL), hosts)
+	}
+
+	// Cache-Control max-age on success defers only the feed
+	status, headers = http.StatusOK, map[string]string{"Cache-Control": "public, max-age=3600"}
+	feed = fetch()
+	if d := time.Until(feed.DeferredUntil); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("expected feed deferred by ~1h, got %v", d)
+	}
+	if feed.DeferReason != "Cache-Control max-age=3600" {
+		t.Errorf("unexpected defer reason %q", feed.DeferReason)
+	}
+
+	// No hints clears the deferral
+	status, headers = http.StatusOK, nil
+	feed = fetch()
+	if !feed.DeferredUntil.IsZero() || feed.DeferReason != "" {
+		t.Errorf("expected deferral cleared, got %v %q", feed.DeferredUntil, feed.DeferReason)
+	}
+}
@@ -0,0 +1,319 @@
+package feed
+
+import (
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// DefaultFullTextWorkers is the number of articles whose full text is fetched at once,
+	// used when full_text_workers is missing or invalid
+	DefaultFullTextWorkers = 3
+	// Upper bound for full_text_workers
+	maxFullTextWorkers = 10
+	// DefaultFullTextHostInterval is the time between two requests to the same host,
+	// used when full_text_host_interval_ms is missing or invalid
+	DefaultFullTextHostInterval = 2 * time.Second
+	// Attempts before an article is marked failed
+	fullTextMaxAttempts = 3
+	// Delay before the first retry; it quadruples with every further attempt
+	fullTextRetryDelay = time.Minute
+	// How often articles due for a retry are looked for
+	fullTextPollInterval = time.Minute
+	// Timeout of a single page fetch
+	fullTextTimeout = 30 * time.Second
+)
+
+var (
+	errNotHTMLPage   = errors.New("URL is not a HTML document")
+	errEmptyFullText = errors.New("no article content found on the page")
+)
+
+// fullTextHTTPError is returned when an article page answers with an unexpected status.
+type fullTextHTTPError struct {
+	StatusCode int
+}
+
+func (e *fullTextHTTPError) Error() string {
+	return fmt.Sprintf("fetch page: HTTP %d", e.StatusCode)
+}
+
+// ExtractFullText fetches an article page with client and extracts its main content as HTML.
+// rule is the extraction rule of the article's site, or nil to let readability find the content.
+// With a next-page selector, the following pages of the article are fetched and appended as well.
+func ExtractFullText(ctx context.Context, client *http.Client, articleURL string, rule *models.ExtractionRule) (string, error) {
+	pageURL, err := url.ParseRequestURI(articleURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if rule == nil {
+		doc, err := fetchArticlePage(ctx, client, pageURL)
+		if err != nil {
+			return "", err
+		}
+		return readabilityContent(doc, pageURL)
+	}
+
+	var pages []string
+	visited := map[string]bool{pageURL.String(): true}
+	for {
+		doc, err := fetchArticlePage(ctx, client, pageURL)
+		if err != nil {
+			if len(pages) == 0 {
+				return "", err
+			}
+			// Keep the pages fetched so far
+			utils.DebugLog("Full text of %s: page %s: %v", articleURL, pageURL, err)
+			break
+		}
+		content, next, err := extractWithRule(doc, pageURL, rule)
+		if err != nil {
+			return "", err
+		}
+		pages = append(pages, content)
+		if next == nil || visited[next.String()] || len(pages) >= maxArticlePages {
+			break
+		}
+		visited[next.String()] = true
+		pageURL = next
+	}
+	return strings.Join(pages, "\n"), nil
+}
+
+// fetchArticlePage fetches and parses an article page, decoding it to UTF-8.
+func fetchArticlePage(ctx context.Context, client *http.Client, pageURL *url.URL) (*html.Node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &fullTextHTTPError{StatusCode: resp.StatusCode}
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.Contains(contentType, "text/html") {
+		return nil, errNotHTMLPage
+	}
+
+	body, err := charset.NewReader(resp.Body, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("decode page: %w", err)
+	}
+	doc, err := html.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse page: %w", err)
+	}
+	return doc, nil
+}
+
+// readabilityContent extracts the main content of a page with readability and renders it as HTML.
+func readabilityContent(doc *html.Node, pageURL *url.URL) (string, error) {
+	article, err := readability.FromDocument(doc, pageURL)
+	if err != nil {
+		return "", fmt.Errorf("readability parse: %w", err)
+	}
+
+	// Render the article content as HTML
+	var buf bytes.Buffer
+	if err := article.RenderHTML(&buf); err != nil {
+		return "", fmt.Errorf("render HTML: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// isPermanentFullTextError reports whether retrying a failed full-text fetch is pointless.
+func isPermanentFullTextError(err error) bool {
+	var httpErr *fullTextHTTPError
+	if errors.As(err, &httpErr) {
+		code := httpErr.StatusCode
+		return code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+	}
+	return errors.Is(err, errNotHTMLPage) || errors.Is(err, errEmptyFullText) || strings.HasPrefix(err.Error(), "invalid URL")
+}
+
+// queueFullText queues the newly inserted articles of feeds that fetch full text.
+func (f *Fetcher) queueFullText(feed models.Feed, articles []*models.Article) {
+	if !feed.FetchFullText {
+		return
+	}
+	var ids []int64
+	for _, a := range articles {
+		// Only articles inserted by this refresh have an ID
+		if a.ID != 0 && a.URL != "" {
+			ids = append(ids, a.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if err := f.db.QueueFullTextFetch(ids); err != nil {
+		log.Printf("Error queueing full-text fetch for feed %s: %v", feed.Title, err)
+		return
+	}
+	select {
+	case f.fullTextWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunFullTextWorkers fetches the full text of queued articles until ctx is cancelled.
+func (f *Fetcher) RunFullTextWorkers(ctx context.Context) {
+	ticker := time.NewTicker(fullTextPollInterval)
+	defer ticker.Stop()
+
+	for {
+		f.processFullTextQueue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-f.fullTextWake:
+		}
+	}
+}
+
+// processFullTextQueue fetches the full text of every article that is due, with at most
+// full_text_workers fetches at a time.
+func (f *Fetcher) processFullTextQueue(ctx context.Context) {
+	workers := f.getFullTextWorkers()
+	seen := make(map[int64]bool)
+	for ctx.Err() == nil {
+		jobs, err := f.db.GetDueFullTextJobs(workers * 4)
+		if err != nil {
+			log.Printf("Error reading full-text queue: %v", err)
+			return
+		}
+
+		snap := f.policySnapshot()
+		categories := make(map[int64]string)
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, workers)
+		started := 0
+		for _, job := range jobs {
+			// An article whose status could not be saved would otherwise be picked up forever
+			if seen[job.ArticleID] {
+				continue
+			}
+			seen[job.ArticleID] = true
+			// Low data mode, quiet hours and an exhausted budget hold back prefetching
+			if prefetchHeld(snap, f.feedCategory(categories, job.FeedID)) {
+				if err := f.db.DeferFullTextFetch(job.ArticleID, time.Now().Add(policyRecheckDelay)); err != nil {
+					log.Printf("Error deferring full-text fetch of article %d: %v", job.ArticleID, err)
+				}
+				continue
+			}
+			started++
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(job database.FullTextJob) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				f.fetchFullText(ctx, job)
+			}(job)
+		}
+		wg.Wait()
+		if started == 0 {
+			return
+		}
+	}
+}
+
+// fetchFullText fetches the full text of one article and records the outcome.
+func (f *Fetcher) fetchFullText(ctx context.Context, job database.FullTextJob) {
+	if !f.fullTextLimiter.wait(ctx, utils.URLHost(job.URL), f.getFullTextHostInterval()) {
+		return
+	}
+
+	client, err := f.fullTextClient(job.FeedID)
+	var content string
+	if err == nil {
+		rule, ruleErr := f.db.FindExtractionRule(utils.URLHost(job.URL))
+		if ruleErr != nil {
+			log.Printf("Error loading extraction rule for %s: %v", job.URL, ruleErr)
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, fullTextTimeout)
+		content, err = ExtractFullText(reqCtx, client, job.URL, rule)
+		cancel()
+	}
+	if ctx.Err() != nil {
+		// Shutting down: the article stays pending and is fetched next time
+		return
+	}
+	if err == nil {
+		content = utils.CleanHTML(content)
+		if strings.TrimSpace(content) == "" {
+			err = errEmptyFullText
+		}
+	}
+
+	if err != nil {
+		var retryAt time.Time
+		if attempts := job.Attempts + 1; attempts < fullTextMaxAttempts && !isPermanentFullTextError(err) {
+			retryAt = time.Now().Add(fullTextRetryDelay << (2 * (attempts - 1)))
+		}
+		utils.DebugLog("Full-text fetch of article %d (%s) failed: %v", job.ArticleID, job.URL, err)
+		if err := f.db.SetFullTextFailure(job.ArticleID, err.Error(), retryAt); err != nil {
+			log.Printf("Error saving full-text status of article %d: %v", job.ArticleID, err)
+		}
+		return
+	}
+	if err := f.db.SetFullTextContent(job.ArticleID, content); err != nil {
+		log.Printf("Error saving full text of article %d: %v", job.ArticleID, err)
+	}
+}
+
+// fullTextClient returns the HTTP client of an article's feed, so its proxy and request options apply.
+func (f *Fetcher) fullTextClient(feedID int64) (*http.Client, error) {
+	feed, err := f.db.GetFeedByID(feedID)
+	if err != nil {
+		return CreateHTTPClient("")
+	}
+	return f.getMeteredHTTPClient(*feed)
+}
+
+// getFullTextWorkers returns the number of articles whose full text is fetched at once.
+func (f *Fetcher) getFullTextWorkers() int {
+	workersStr, err := f.db.GetSetting("full_text_workers")
+	if err != nil {
+		return DefaultFullTextWorkers
+	}
+	workers, err := strconv.Atoi(workersStr)
+	if err != nil || workers < 1 {
+		return DefaultFullTextWorkers
+	}
+	return min(workers, maxFullTextWorkers)
+}
+
+// getFullTextHostInterval returns the minimum time between two full-text fetches from the same host.
+func (f *Fetcher) getFullTextHostInterval() time.Duration {
+	intervalStr, err := f.db.GetSetting("full_text_host_interval_ms")
+	if err != nil {
+		return DefaultFullTextHostInterval
+	}
+	ms, err := strconv.Atoi(intervalStr)
+	if err != nil || ms < 0 {
+		return DefaultFullTextHostInterval
+	}
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestFullTextFetch_StoresContentAndStatus(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/flaky":
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintf(w, `<html><head><title>Story</title></head><body><nav>Menu</nav><article><h1>Story</h1>%s</article></body></html>`,
+				strings.Repeat("<p>The whole story, with every paragraph the feed left out of its summary.</p>", 10))
+		}
+	}))
+	defer server.Close()
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	_ = db.SetSetting("full_text_host_interval_ms", "0")
+
+	feedID, err := db.AddFeed(&models.Feed{Title: "Truncated", URL: server.URL + "/feed.xml"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+	if err := db.SetFeedFetchFullText(feedID, true); err != nil {
+		t.Fatalf("SetFeedFetchFullText error: %v", err)
+	}
+	feed, _ := db.GetFeedByID(feedID)
+	if !feed.FetchFullText {
+		t.Fatal("fetch_full_text not stored")
+	}
+
+	articles := []*models.Article{
+		{FeedID: feedID, Title: "Story", URL: server.URL + "/story", Content: "<p>Summary…</p>", PublishedAt: time.Now()},
+		{FeedID: feedID, Title: "Gone", URL: server.URL + "/missing", PublishedAt: time.Now()},
+		{FeedID: feedID, Title: "Flaky", URL: server.URL + "/flaky", PublishedAt: time.Now()},
+	}
+	if err := db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+	f.queueFullText(*feed, articles)
+	f.processFullTextQueue(context.Background())
+
+	content, _ := db.GetArticleContent(articles[0].ID)
+	if !strings.Contains(content, "every paragraph the feed left out") || strings.Contains(content, "Menu") {
+		t.Errorf("content = %q", content)
+	}
+	story, _ := db.GetArticleByID(articles[0].ID)
+	if story.FullText == nil || story.FullText.Status != models.FullTextOK {
+		t.Errorf("story status = %+v", story.FullText)
+	}
+
+	gone, _ := db.GetFullTextStatus(articles[1].ID)
+	if gone == nil || gone.Status != models.FullTextFailed || gone.Attempts != 1 {
+		t.Errorf("missing page status = %+v", gone)
+	}
+	flaky, _ := db.GetFullTextStatus(articles[2].ID)
+	if flaky == nil || flaky.Status != models.FullTextPending || flaky.Attempts != 1 || !strings.Contains(flaky.Error, "503") {
+		t.Errorf("flaky page status = %+v", flaky)
+	}
+
+	// The retry is not due yet, and the story is not fetched again
+	before := requests.Load()
+	f.processFullTextQueue(context.Background())
+	if after := requests.Load(); after != before {
+		t.Errorf("made %d more requests before the retry was due", after-before)
+	}
+
+	// Refreshing the feed with its truncated item keeps the full text
+	if err := db.SaveArticles(context.Background(), []*models.Article{{FeedID: feedID, Title: "Story", URL: server.URL + "/story", Content: "<p>Summary…</p>", PublishedAt: time.Now()}}); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+	content, _ = db.GetArticleContent(articles[0].ID)
+	if !strings.Contains(content, "every paragraph the feed left out") {
+		t.Errorf("full text replaced on refresh: %q", content)
+	}
+}
+
+func TestFullTextFetch_OnlyFeedsThatAskForIt(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Full", URL: "https://example.com/feed.xml"})
+	feed, _ := db.GetFeedByID(feedID)
+
+	articles := []*models.Article{{FeedID: feedID, Title: "A", URL: "https://example.com/a", PublishedAt: time.Now()}}
+	_ = db.SaveArticles(context.Background(), articles)
+	f.queueFullText(*feed, articles)
+
+	if status, _ := db.GetFullTextStatus(articles[0].ID); status != nil {
+		t.Errorf("queued an article of a feed without full-text fetching: %+v", status)
+	}
+}
+
+func TestHostRateLimiter(t *testing.T) {
+	l := newHostRateLimiter()
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if !l.wait(ctx, "example.com", 30*time.Millisecond) {
+			t.Fatal("wait returned false")
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("three requests took %v, want at least 60ms", elapsed)
+	}
+
+	// Other hosts are not held up
+	start = time.Now()
+	l.wait(ctx, "other.example", 30*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("first request to another host waited %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if l.wait(cancelled, "example.com", time.Hour) {
+		t.Error("wait on a cancelled context returned true")
+	}
+}
+
+func TestHostRateLimiterForgetsPassedHosts(t *testing.T) {
+	l := newHostRateLimiter()
+	ctx := context.Background()
+	for _, host := range []string{"a.example", "b.example", "c.example"} {
+		l.wait(ctx, host, time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	l.wait(ctx, "d.example", time.Millisecond)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.next) != 1 {
+		t.Errorf("expected only the latest host to be tracked, got %v", l.next)
+	}
+}
@@ -0,0 +1,117 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+const (
+	// Backoff after the first failed fetch; doubled for each further consecutive failure
+	FailureBackoffBase = 5 * time.Minute
+	// Upper bound for the failure backoff
+	MaxFailureBackoff = 24 * time.Hour
+	// Pause threshold used when the feed_pause_after_failures setting is missing or invalid
+	DefaultPauseAfterFailures = 10
+)
+
+// FailureBackoff returns how long to wait before retrying a feed
+// that has failed the given number of times in a row.
+func FailureBackoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	backoff := FailureBackoffBase
+	for i := 1; i < failures; i++ {
+		backoff *= 2
+		if backoff >= MaxFailureBackoff {
+			return MaxFailureBackoff
+		}
+	}
+	return backoff
+}
+
+// fetchStatusCode returns the HTTP status of a fetch, or 0 if no response was received.
+func fetchStatusCode(info *fetchInfo, err error) int {
+	if info != nil && info.StatusCode != 0 {
+		return info.StatusCode
+	}
+	var httpErr gofeed.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// recordFetchSuccess clears the failure state of a feed and logs the fetch in its history.
+func (f *Fetcher) recordFetchSuccess(feed models.Feed, info *fetchInfo, started time.Time, itemCount int) {
+	if err := f.db.MarkFeedFetchSucceeded(feed.ID); err != nil {
+		log.Printf("Error updating health of feed %s: %v", feed.Title, err)
+	}
+	f.recordFetchHistory(feed, info, nil, started, itemCount)
+	f.recordServerHints(feed, info)
+}
+
+// recordFetchFailure increments the failure count of a feed, schedules the next retry
+// with exponential backoff and pauses the feed when it looks dead.
+func (f *Fetcher) recordFetchFailure(feed models.Feed, info *fetchInfo, started time.Time, fetchErr error) {
+	failures := feed.ConsecutiveFailures + 1
+	nextRetry := time.Now().Add(FailureBackoff(failures))
+	if err := f.db.MarkFeedFetchFailed(feed.ID, fetchErr.Error(), failures, nextRetry); err != nil {
+		log.Printf("Error updating health of feed %s: %v", feed.Title, err)
+	}
+	f.recordFetchHistory(feed, info, fetchErr, started, 0)
+	f.recordServerHints(feed, info)
+
+	if reason := f.pauseReason(fetchStatusCode(info, fetchErr), failures); reason != "" && !feed.IsPaused {
+		log.Printf("Pausing feed %s: %s", feed.Title, reason)
+		if err := f.db.PauseFeed(feed.ID, reason); err != nil {
+			log.Printf("Error pausing feed %s: %v", feed.Title, err)
+		}
+	}
+}
+
+// pauseReason returns why a feed should be paused, or "" if it should keep being fetched.
+func (f *Fetcher) pauseReason(statusCode int, failures int) string {
+	if statusCode == http.StatusGone {
+		return "feed is gone (HTTP 410)"
+	}
+
+	threshold := DefaultPauseAfterFailures
+	if thresholdStr, err := f.db.GetSetting("feed_pause_after_failures"); err == nil {
+		if n, err := strconv.Atoi(thresholdStr); err == nil && n >= 0 {
+			threshold = n
+		}
+	}
+	// 0 disables pausing after repeated failures
+	if threshold > 0 && failures >= threshold {
+		return fmt.Sprintf("%d consecutive failed fetches", failures)
+	}
+	return ""
+}
+
+// recordFetchHistory appends the outcome of a fetch to the feed's history.
+func (f *Fetcher) recordFetchHistory(feed models.Feed, info *fetchInfo, fetchErr error, started time.Time, itemCount int) {
+	entry := models.FetchHistoryEntry{
+		FeedID:     feed.ID,
+		FetchedAt:  started,
+		HTTPStatus: fetchStatusCode(info, fetchErr),
+		DurationMs: time.Since(started).Milliseconds(),
+		ItemCount:  itemCount,
+	}
+	if fetchErr != nil {
+		entry.Error = fetchErr.Error()
+	}
+	if info != nil {
+		entry.Stderr = info.ScriptStderr
+	}
+	if err := f.db.RecordFeedFetch(entry); err != nil {
+		log.Printf("Error recording fetch history for feed %s: %v", feed.Title, err)
+	}
+}
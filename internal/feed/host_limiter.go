@@ -0,0 +1,144 @@
+package feed
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Simultaneous requests to a single host, used when max_concurrent_per_host is missing or invalid
+	DefaultConcurrencyPerHost = 2
+	// Upper bound for the per-host limit
+	maxConcurrencyPerHost = 20
+)
+
+// hostLimiter bounds the number of simultaneous fetches per host,
+// on top of the global concurrency limit.
+type hostLimiter struct {
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+// hostSlots tracks the fetches of one host. Hosts without fetches in flight or waiting are forgotten.
+type hostSlots struct {
+	active  int
+	waiting int
+	freed   chan struct{} // Closed and replaced whenever a fetch finishes
+}
+
+func newHostLimiter() *hostLimiter {
+	return &hostLimiter{hosts: make(map[string]*hostSlots)}
+}
+
+// acquire blocks until fewer than limit fetches from host are in flight or ctx is cancelled.
+// On success the returned release function must be called once the fetch is done.
+// A changed limit applies to the fetches in flight: a lower one makes new fetches wait
+// until enough of them have finished. An empty host (e.g. script feeds) is never limited.
+func (l *hostLimiter) acquire(ctx context.Context, host string, limit int) (func(), bool) {
+	if host == "" {
+		return func() {}, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &hostSlots{freed: make(chan struct{})}
+		l.hosts[host] = h
+	}
+	for h.active >= limit {
+		freed := h.freed
+		h.waiting++
+		l.mu.Unlock()
+		select {
+		case <-freed:
+			l.mu.Lock()
+			h.waiting--
+		case <-ctx.Done():
+			l.mu.Lock()
+			h.waiting--
+			l.forgetIdle(host, h)
+			return nil, false
+		}
+	}
+	h.active++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			h.active--
+			close(h.freed)
+			h.freed = make(chan struct{})
+			l.forgetIdle(host, h)
+		})
+	}, true
+}
+
+// forgetIdle removes a host nobody is fetching from or waiting for. l.mu must be held.
+func (l *hostLimiter) forgetIdle(host string, h *hostSlots) {
+	if h.active == 0 && h.waiting == 0 {
+		delete(l.hosts, host)
+	}
+}
+
+// hostRateLimiter spaces out requests to the same host. Hosts whose next slot has passed are forgotten.
+type hostRateLimiter struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func newHostRateLimiter() *hostRateLimiter {
+	return &hostRateLimiter{next: make(map[string]time.Time)}
+}
+
+// wait blocks until a request to host may start, at least interval after the previous one,
+// or ctx is cancelled. Returns false if ctx was cancelled.
+func (l *hostRateLimiter) wait(ctx context.Context, host string, interval time.Duration) bool {
+	if host == "" || interval <= 0 {
+		return ctx.Err() == nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	for h, next := range l.next {
+		if !next.After(now) {
+			delete(l.next, h)
+		}
+	}
+	start := l.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	l.next[host] = start.Add(interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// getHostConcurrencyLimit returns the maximum number of simultaneous fetches per host.
+// Low data mode fetches one feed per host at a time.
+func (f *Fetcher) getHostConcurrencyLimit() int {
+	if f.lowDataMode() {
+		return 1
+	}
+	limitStr, err := f.db.GetSetting("max_concurrent_per_host")
+	if err != nil {
+		return DefaultConcurrencyPerHost
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 {
+		return DefaultConcurrencyPerHost
+	}
+	return min(limit, maxConcurrencyPerHost)
+}
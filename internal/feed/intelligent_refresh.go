@@ -14,6 +14,30 @@ const (
 	MaxRefreshInterval = 3 * time.Hour
 	// Default interval if no history
 	DefaultRefreshInterval = 30 * time.Minute
+	// MaxPredictedRefreshInterval bounds the interval of feeds with a publishing model, which
+	// may skip the hours they do not publish in, such as the night
+	MaxPredictedRefreshInterval = 12 * time.Hour
+
+	// How far back publish times are modelled
+	publishingHistory = 90 * 24 * time.Hour
+	// Most recent articles used to build the model
+	maxModelArticles = 500
+	// Most recent articles whose average gap is used without a model
+	maxAverageArticles = 100
+	// Articles needed before the publishing model is used
+	minModelArticles = 10
+	// Age at which an article counts half as much as a new one
+	publishingHalfLife = 14 * 24 * time.Hour
+	// Articles expected between two checks; half an article keeps checking twice as often as the feed publishes
+	expectedArticlesPerCheck = 0.5
+	hoursPerWeek             = 7 * 24
+)
+
+// How a refresh interval was calculated
+const (
+	RefreshMethodDefault         = "default"          // No history
+	RefreshMethodAverageInterval = "average_interval" // Half the average gap between articles
+	RefreshMethodHourOfWeek      = "hour_of_week"     // Publishing model by hour of week
 )
 
 // IntelligentRefreshCalculator calculates optimal refresh intervals based on feed activity
@@ -21,56 +45,154 @@ type IntelligentRefreshCalculator struct {
 	db *database.DB
 }
 
+// PublishingModel is how many articles a feed is expected to publish in each hour of the week,
+// learned from the publish times of its articles with recent articles weighing more.
+type PublishingModel struct {
+	Articles     int       `json:"articles"`       // Articles of the last 90 days the model is built from
+	HalfLifeDays float64   `json:"half_life_days"` // Age at which an article counts half
+	HourlyRates  []float64 `json:"hourly_rates"`   // Expected articles per hour of week, from Sunday 00:00 local time
+	WeeklyRate   float64   `json:"weekly_rate"`    // Expected articles per week
+}
+
+// RefreshPrediction is when a feed is expected to publish next and when it is refreshed for it.
+type RefreshPrediction struct {
+	FeedID          int64            `json:"feed_id"`
+	Method          string           `json:"method"`
+	Model           *PublishingModel `json:"model,omitempty"`        // nil without enough history
+	IntervalSeconds int64            `json:"interval_seconds"`       // Time between the last refresh and the next
+	NextPostAt      *time.Time       `json:"next_post_at,omitempty"` // When half an article is expected since the last refresh
+	NextRefreshAt   time.Time        `json:"next_refresh_at"`        // Before any backoff or server deferral
+	Interval        time.Duration    `json:"-"`
+}
+
 // NewIntelligentRefreshCalculator creates a new calculator
 func NewIntelligentRefreshCalculator(db *database.DB) *IntelligentRefreshCalculator {
 	return &IntelligentRefreshCalculator{db: db}
 }
 
 // CalculateInterval calculates the optimal refresh interval for a feed
-// based on its recent article publication frequency
+// based on when it usually publishes
 func (irc *IntelligentRefreshCalculator) CalculateInterval(feed models.Feed) time.Duration {
-	// Get recent articles (last 30 days) to analyze frequency
-	articles, err := irc.db.GetArticles("", feed.ID, "", false, 100, 0)
-	if err != nil || len(articles) == 0 {
-		return DefaultRefreshInterval
+	return irc.Predict(feed, time.Now()).Interval
+}
+
+// Predict calculates the refresh interval of a feed counted from its last refresh. Feeds with
+// enough history are refreshed when their publishing model expects half an article since the
+// last refresh, so the hours they rarely publish in are skipped. Other feeds are refreshed at
+// half their average gap between articles.
+func (irc *IntelligentRefreshCalculator) Predict(feed models.Feed, now time.Time) RefreshPrediction {
+	p := RefreshPrediction{FeedID: feed.ID, Method: RefreshMethodDefault, Interval: DefaultRefreshInterval}
+	from := feed.LastUpdated
+	if from.IsZero() || from.After(now) {
+		from = now
 	}
 
-	// Calculate average time between articles
-	avgInterval := irc.calculateAverageInterval(articles)
+	times, err := irc.db.GetFeedPublishTimes(feed.ID, maxModelArticles)
+	if err == nil && len(times) > 0 {
+		if model := buildPublishingModel(times, now); model != nil {
+			p.Method = RefreshMethodHourOfWeek
+			p.Model = model
+			if next, ok := model.nextPost(from, 7*24*time.Hour); ok {
+				p.NextPostAt = &next
+				p.Interval = next.Sub(from)
+			} else {
+				p.Interval = MaxPredictedRefreshInterval
+			}
+			p.Interval = clampInterval(p.Interval, MaxPredictedRefreshInterval)
+		} else {
+			p.Method = RefreshMethodAverageInterval
+			// Apply smart scaling: refresh more frequently than publication rate
+			p.Interval = clampInterval(calculateAverageInterval(times[:min(len(times), maxAverageArticles)])/2, MaxRefreshInterval)
+		}
+	}
 
-	// Apply smart scaling: refresh more frequently than publication rate
-	// but with reasonable bounds
-	optimalInterval := avgInterval / 2
+	p.IntervalSeconds = int64(p.Interval / time.Second)
+	p.NextRefreshAt = from.Add(p.Interval)
+	return p
+}
 
-	// Clamp to min/max bounds
-	if optimalInterval < MinRefreshInterval {
-		return MinRefreshInterval
+func clampInterval(interval, maxInterval time.Duration) time.Duration {
+	return min(max(interval, MinRefreshInterval), maxInterval)
+}
+
+// buildPublishingModel models the publish times of a feed, newest first, by hour of week. It
+// returns nil if there are too few articles.
+func buildPublishingModel(times []time.Time, now time.Time) *PublishingModel {
+	weights := make([]float64, hoursPerWeek)
+	var oldest time.Time
+	articles := 0
+	for _, t := range times {
+		if t.IsZero() || t.After(now) {
+			continue
+		}
+		if now.Sub(t) > publishingHistory {
+			break
+		}
+		weights[hourOfWeek(t)] += math.Exp2(-now.Sub(t).Hours() / publishingHalfLife.Hours())
+		oldest = t
+		articles++
 	}
-	if optimalInterval > MaxRefreshInterval {
-		return MaxRefreshInterval
+	if articles < minModelArticles {
+		return nil
 	}
 
-	return optimalInterval
+	// Weeks of history the decayed counts cover, so they become rates per week. A feed
+	// known for less than a week is treated as if it had been quiet for the rest of it.
+	span := max(now.Sub(oldest), 7*24*time.Hour)
+	halfLifeWeeks := publishingHalfLife.Hours() / (7 * 24)
+	weeks := halfLifeWeeks / math.Ln2 * (1 - math.Exp2(-span.Hours()/publishingHalfLife.Hours()))
+
+	model := &PublishingModel{
+		Articles:     articles,
+		HalfLifeDays: publishingHalfLife.Hours() / 24,
+		HourlyRates:  make([]float64, hoursPerWeek),
+	}
+	for i, w := range weights {
+		model.HourlyRates[i] = w / weeks
+		model.WeeklyRate += model.HourlyRates[i]
+	}
+	return model
+}
+
+// hourOfWeek returns the hour of the week of t in local time, 0 being Sunday 00:00.
+func hourOfWeek(t time.Time) int {
+	t = t.Local()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// nextPost returns when the model expects half an article to have been published since from,
+// if that is within limit.
+func (m *PublishingModel) nextPost(from time.Time, limit time.Duration) (time.Time, bool) {
+	expected := 0.0
+	t := from.Local()
+	for t.Sub(from) < limit {
+		hourStart := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+		hourEnd := hourStart.Add(time.Hour)
+		rate := m.HourlyRates[hourOfWeek(t)]
+		hours := hourEnd.Sub(t).Hours()
+		if rate > 0 && expected+rate*hours >= expectedArticlesPerCheck {
+			at := t.Add(time.Duration((expectedArticlesPerCheck - expected) / rate * float64(time.Hour)))
+			return at, at.Sub(from) <= limit
+		}
+		expected += rate * hours
+		t = hourEnd
+	}
+	return time.Time{}, false
 }
 
 // calculateAverageInterval computes the average time between article publications
-func (irc *IntelligentRefreshCalculator) calculateAverageInterval(articles []models.Article) time.Duration {
-	if len(articles) < 2 {
+func calculateAverageInterval(times []time.Time) time.Duration {
+	if len(times) < 2 {
 		return DefaultRefreshInterval
 	}
 
-	// Note: Articles should be ordered by published_at DESC from the database query.
-	// If ordering is not guaranteed, explicit sorting should be added here.
+	// Publish times are ordered newest first by the database query.
 	// Calculate intervals between consecutive articles
 	var totalInterval time.Duration
 	validIntervals := 0
 
-	for i := 0; i < len(articles)-1; i++ {
-		// Articles already have time.Time for PublishedAt
-		published1 := articles[i].PublishedAt
-		published2 := articles[i+1].PublishedAt
-
-		interval := published1.Sub(published2)
+	for i := 0; i < len(times)-1; i++ {
+		interval := times[i].Sub(times[i+1])
 		// Only count positive intervals (skip negative or zero)
 		if interval > 0 {
 			totalInterval += interval
@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// saveArticlesAt saves an article of the feed for each publish time.
+func saveArticlesAt(t *testing.T, f *Fetcher, feedID int64, times []time.Time) {
+	t.Helper()
+	var articles []*models.Article
+	for i, at := range times {
+		articles = append(articles, &models.Article{FeedID: feedID, Title: fmt.Sprintf("Post %d", i), URL: fmt.Sprintf("https://example.com/%d", i), PublishedAt: at})
+	}
+	if err := f.db.SaveArticles(context.Background(), articles); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+}
+
+func TestPredict_WeekdayMorningPublisher(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Mornings", URL: "https://example.com/feed.xml"})
+
+	// Wednesday 8 May 2024; the feed posts three times every weekday morning between 8 and 9
+	now := time.Date(2024, 5, 8, 12, 0, 0, 0, time.Local)
+	var times []time.Time
+	for day := 1; day <= 28; day++ {
+		date := now.AddDate(0, 0, -day)
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+		for _, minute := range []int{5, 25, 45} {
+			times = append(times, time.Date(date.Year(), date.Month(), date.Day(), 8, minute, 0, 0, time.Local))
+		}
+	}
+	saveArticlesAt(t, f, feedID, times)
+
+	// Refreshed Wednesday evening: nothing is expected before Thursday morning
+	feed, _ := db.GetFeedByID(feedID)
+	feed.LastUpdated = time.Date(2024, 5, 8, 21, 0, 0, 0, time.Local)
+	p := f.refreshCalculator.Predict(*feed, feed.LastUpdated)
+	if p.Method != RefreshMethodHourOfWeek || p.Model == nil || p.Model.Articles != len(times) {
+		t.Fatalf("prediction = %+v", p)
+	}
+	thursday8 := time.Date(2024, 5, 9, 8, 0, 0, 0, time.Local)
+	if p.NextPostAt == nil || p.NextPostAt.Before(thursday8) || !p.NextPostAt.Before(thursday8.Add(time.Hour)) {
+		t.Errorf("next post at %v, want Thursday between 8 and 9", p.NextPostAt)
+	}
+	if p.Interval <= MaxRefreshInterval || !p.NextRefreshAt.Equal(feed.LastUpdated.Add(p.Interval)) {
+		t.Errorf("interval %v, next refresh %v: want the night skipped", p.Interval, p.NextRefreshAt)
+	}
+	if rate := p.Model.HourlyRates[hourOfWeek(thursday8)]; rate < 2 || rate > 4 {
+		t.Errorf("Thursday 8:00 rate = %.2f, want about 3 articles", rate)
+	}
+
+	// Refreshed during the morning burst: checked again soon
+	feed.LastUpdated = time.Date(2024, 5, 9, 8, 0, 0, 0, time.Local)
+	if p := f.refreshCalculator.Predict(*feed, feed.LastUpdated); p.Interval > 15*time.Minute {
+		t.Errorf("interval during the burst = %v", p.Interval)
+	}
+
+	// Friday evening: the weekend is skipped up to the longest interval
+	feed.LastUpdated = time.Date(2024, 5, 10, 18, 0, 0, 0, time.Local)
+	p = f.refreshCalculator.Predict(*feed, feed.LastUpdated)
+	if p.Interval != MaxPredictedRefreshInterval || p.NextPostAt == nil || p.NextPostAt.Weekday() != time.Monday {
+		t.Errorf("weekend: interval %v, next post %v", p.Interval, p.NextPostAt)
+	}
+}
+
+func TestPredict_RecentBehaviourWeighsMore(t *testing.T) {
+	now := time.Date(2024, 5, 8, 12, 0, 0, 0, time.Local)
+	var times []time.Time
+	// Posted at 6:00 daily until two months ago, at 18:00 daily since a week ago
+	for day := 1; day <= 7; day++ {
+		d := now.AddDate(0, 0, -day)
+		times = append(times, time.Date(d.Year(), d.Month(), d.Day(), 18, 0, 0, 0, time.Local))
+	}
+	for day := 60; day <= 80; day++ {
+		d := now.AddDate(0, 0, -day)
+		times = append(times, time.Date(d.Year(), d.Month(), d.Day(), 6, 0, 0, 0, time.Local))
+	}
+
+	model := buildPublishingModel(times, now)
+	if model == nil {
+		t.Fatal("no model")
+	}
+	evening, morning := model.HourlyRates[hourOfWeek(times[0])], model.HourlyRates[hourOfWeek(times[0].Add(-12*time.Hour))]
+	if evening <= morning*5 {
+		t.Errorf("evening rate %.3f, morning rate %.3f: recent posts should dominate", evening, morning)
+	}
+}
+
+func TestPredict_FallsBackWithoutEnoughHistory(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	feedID, _ := db.AddFeed(&models.Feed{Title: "Sparse", URL: "https://example.com/feed.xml"})
+	feed, _ := db.GetFeedByID(feedID)
+
+	if p := f.refreshCalculator.Predict(*feed, time.Now()); p.Method != RefreshMethodDefault || p.Interval != DefaultRefreshInterval {
+		t.Errorf("no articles: %+v", p)
+	}
+
+	now := time.Now()
+	saveArticlesAt(t, f, feedID, []time.Time{now.Add(-2 * time.Hour), now.Add(-4 * time.Hour), now.Add(-6 * time.Hour)})
+	p := f.refreshCalculator.Predict(*feed, now)
+	if p.Method != RefreshMethodAverageInterval || p.Model != nil || p.Interval != time.Hour {
+		t.Errorf("three articles: method %s, interval %v", p.Method, p.Interval)
+	}
+}
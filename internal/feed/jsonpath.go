@@ -0,0 +1,251 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// parseFeedWithJSONPath parses a JSON+JSONPath feed. Such feeds store JSONPath expressions in the XPath fields
+// of models.Feed: XPathItem selects the items, the other fields are evaluated against each item.
+func (f *Fetcher) parseFeedWithJSONPath(ctx context.Context, feed *models.Feed) (*gofeed.Feed, error) {
+	if feed.XPathItem == "" {
+		return nil, fmt.Errorf("JSONPath item expression is required for JSON-based feeds")
+	}
+
+	httpClient, err := f.getMeteredHTTPClient(*feed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	body, err := fetchScrapedDocument(ctx, httpClient, feed.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedFeed, err := parseJSONDocument(body, feed, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsedFeed.Items) == 0 {
+		return nil, fmt.Errorf("no items found with JSONPath: %s", feed.XPathItem)
+	}
+	return parsedFeed, nil
+}
+
+// jsonFeedPaths holds the compiled JSONPath expressions of a feed; fields without an expression are nil.
+type jsonFeedPaths struct {
+	item, title, content, uri, author, timestamp, thumbnail, categories, uid *utils.JSONPath
+}
+
+func compileJSONFeedPaths(feed *models.Feed) (*jsonFeedPaths, error) {
+	paths := &jsonFeedPaths{}
+	for _, field := range []struct {
+		name string
+		expr string
+		dst  **utils.JSONPath
+	}{
+		{"xpath_item", feed.XPathItem, &paths.item},
+		{"xpath_item_title", feed.XPathItemTitle, &paths.title},
+		{"xpath_item_content", feed.XPathItemContent, &paths.content},
+		{"xpath_item_uri", feed.XPathItemUri, &paths.uri},
+		{"xpath_item_author", feed.XPathItemAuthor, &paths.author},
+		{"xpath_item_timestamp", feed.XPathItemTimestamp, &paths.timestamp},
+		{"xpath_item_thumbnail", feed.XPathItemThumbnail, &paths.thumbnail},
+		{"xpath_item_categories", feed.XPathItemCategories, &paths.categories},
+		{"xpath_item_uid", feed.XPathItemUid, &paths.uid},
+	} {
+		if strings.TrimSpace(field.expr) == "" {
+			continue
+		}
+		jp, err := utils.CompileJSONPath(field.expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath for %s: %w", field.name, err)
+		}
+		*field.dst = jp
+	}
+	return paths, nil
+}
+
+// parseJSONDocument extracts the items of a JSON+JSONPath feed from a fetched document.
+// warn, if not nil, is told about fields of each item that look misconfigured.
+func parseJSONDocument(body []byte, feed *models.Feed, warn func(item int, field, message string)) (*gofeed.Feed, error) {
+	paths, err := compileJSONFeedPaths(feed)
+	if err != nil {
+		return nil, err
+	}
+	if paths.item == nil {
+		return nil, fmt.Errorf("JSONPath item expression is required for JSON-based feeds")
+	}
+
+	// Keep numbers as written so large numeric IDs are not rounded
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	// The item expression may select the array itself or its elements
+	items := paths.item.Find(doc)
+	if len(items) == 1 {
+		if arr, ok := items[0].([]any); ok {
+			items = arr
+		}
+	}
+
+	baseURL, _ := url.Parse(feed.URL)
+	parsedFeed := &gofeed.Feed{
+		Title:       feed.Title,
+		Link:        feed.URL,
+		Description: feed.Description,
+		Items:       make([]*gofeed.Item, 0, len(items)),
+	}
+	for i, item := range items {
+		var itemWarn xpathWarnFunc
+		if warn != nil {
+			itemWarn = func(field, message string) { warn(i, field, message) }
+		}
+		parsedFeed.Items = append(parsedFeed.Items, extractItemFromJSON(item, paths, feed, baseURL, itemWarn))
+	}
+	return parsedFeed, nil
+}
+
+// extractItemFromJSON extracts a gofeed.Item from a JSON value selected by the item expression
+func extractItemFromJSON(item any, paths *jsonFeedPaths, feed *models.Feed, baseURL *url.URL, warn xpathWarnFunc) *gofeed.Item {
+	gofeedItem := &gofeed.Item{}
+
+	gofeedItem.Title, _ = jsonString(item, paths.title)
+	if gofeedItem.Title == "" {
+		warn.add("xpath_item_title", "title is empty")
+	}
+	gofeedItem.Content, _ = jsonString(item, paths.content)
+
+	gofeedItem.Link, _ = jsonString(item, paths.uri)
+
+	if author, _ := jsonString(item, paths.author); author != "" {
+		gofeedItem.Author = &gofeed.Person{Name: author}
+	}
+
+	if paths.timestamp != nil {
+		values := paths.timestamp.Find(item)
+		if len(values) == 0 {
+			warn.add("xpath_item_timestamp", "expression matched nothing")
+		} else if parsedTime, err := parseJSONTime(values[0], feed.XPathItemTimeFormat); err == nil {
+			gofeedItem.PublishedParsed = &parsedTime
+		} else {
+			warn.add("xpath_item_timestamp", err.Error())
+		}
+	}
+
+	if imageURL, _ := jsonString(item, paths.thumbnail); imageURL != "" {
+		if !strings.HasPrefix(imageURL, "http") {
+			warn.add("xpath_item_thumbnail", fmt.Sprintf("relative URL %q resolved against the feed URL", imageURL))
+			imageURL = resolveScrapedURL(baseURL, imageURL)
+		}
+		gofeedItem.Image = &gofeed.Image{URL: imageURL}
+	}
+
+	if paths.categories != nil {
+		for _, value := range paths.categories.Find(item) {
+			// A single match may be the array of categories
+			values, ok := value.([]any)
+			if !ok {
+				values = []any{value}
+			}
+			for _, v := range values {
+				if s := jsonScalarString(v); s != "" {
+					gofeedItem.Categories = append(gofeedItem.Categories, s)
+				}
+			}
+		}
+	}
+
+	gofeedItem.GUID, _ = jsonString(item, paths.uid)
+
+	finalizeScrapedItem(gofeedItem, feed, baseURL, warn, "json")
+	return gofeedItem
+}
+
+// jsonString returns the first value a path selects in item as a string. found is false if nothing matched.
+func jsonString(item any, path *utils.JSONPath) (value string, found bool) {
+	if path == nil {
+		return "", false
+	}
+	values := path.Find(item)
+	if len(values) == 0 {
+		return "", false
+	}
+	return jsonScalarString(values[0]), true
+}
+
+// jsonScalarString formats a JSON value as text. Objects and arrays are returned as JSON.
+func jsonScalarString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// parseJSONTime parses a JSON timestamp. format may list several layouts separated by "|";
+// "unix" and "unixms" read epoch seconds and milliseconds. Without a format, numbers are read
+// as epoch seconds or milliseconds depending on their size and strings with common layouts.
+func parseJSONTime(value any, format string) (time.Time, error) {
+	raw := jsonScalarString(value)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("timestamp is empty")
+	}
+
+	if format == "" {
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			if n > 1e11 {
+				return time.UnixMilli(int64(n)).UTC(), nil
+			}
+			return time.Unix(int64(n), 0).UTC(), nil
+		}
+		if t, err := parseScrapedTime(raw, ""); err == nil {
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("cannot parse %q with any known time format", raw)
+	}
+
+	for _, layout := range strings.Split(format, "|") {
+		layout = strings.TrimSpace(layout)
+		switch layout {
+		case "unix", "unixms":
+			n, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				continue
+			}
+			if layout == "unixms" {
+				return time.UnixMilli(int64(n)).UTC(), nil
+			}
+			return time.Unix(int64(n), 0).UTC(), nil
+		default:
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q with time format %q", raw, format)
+}
@@ -0,0 +1,110 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+const jsonAPITestBody = `{"result": {"posts": [
+	{"id": 9007199254740993, "headline": "Launch day", "path": "/news/launch", "body": "<p>We launched</p>",
+	 "by": {"name": "Ann"}, "ts": 1709287200, "cover": "https://cdn.example.com/1.png", "tags": ["news", "product"]},
+	{"id": 2, "headline": "Roadmap", "path": "https://example.com/roadmap", "ts": "soon"}
+]}}`
+
+func TestParseJSONDocument(t *testing.T) {
+	feed := &models.Feed{
+		URL:                 "https://example.com/api/posts",
+		Type:                "JSON+JSONPath",
+		XPathItem:           "$.result.posts",
+		XPathItemTitle:      "headline",
+		XPathItemUri:        "path",
+		XPathItemContent:    "body",
+		XPathItemAuthor:     "by.name",
+		XPathItemTimestamp:  "ts",
+		XPathItemTimeFormat: "2006-01-02|unix",
+		XPathItemThumbnail:  "cover",
+		XPathItemCategories: "tags",
+		XPathItemUid:        "id",
+	}
+
+	var warnings []XPathWarning
+	parsed, err := parseJSONDocument([]byte(jsonAPITestBody), feed, func(item int, field, message string) {
+		warnings = append(warnings, XPathWarning{Item: item, Field: field, Message: message})
+	})
+	if err != nil {
+		t.Fatalf("parseJSONDocument error: %v", err)
+	}
+	if len(parsed.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(parsed.Items))
+	}
+
+	first := parsed.Items[0]
+	if first.Title != "Launch day" || first.Link != "https://example.com/news/launch" || first.Content != "<p>We launched</p>" {
+		t.Errorf("unexpected first item: %+v", first)
+	}
+	if first.GUID != "9007199254740993" {
+		t.Errorf("numeric id lost precision: %q", first.GUID)
+	}
+	if first.Author == nil || first.Author.Name != "Ann" {
+		t.Errorf("author = %+v", first.Author)
+	}
+	if first.PublishedParsed == nil || !first.PublishedParsed.Equal(time.Unix(1709287200, 0)) {
+		t.Errorf("published = %v", first.PublishedParsed)
+	}
+	if first.Image == nil || len(first.Categories) != 2 {
+		t.Errorf("image/categories = %+v %v", first.Image, first.Categories)
+	}
+
+	// A relative link and an unparseable timestamp are reported
+	if len(warnings) != 2 || warnings[0].Field != "xpath_item_uri" || warnings[1].Item != 1 || warnings[1].Field != "xpath_item_timestamp" {
+		t.Errorf("warnings = %+v", warnings)
+	}
+
+	// Selecting the elements instead of the array gives the same items
+	feed.XPathItem = "$.result.posts[*]"
+	parsed, _ = parseJSONDocument([]byte(jsonAPITestBody), feed, nil)
+	if len(parsed.Items) != 2 {
+		t.Errorf("expected 2 items with [*], got %d", len(parsed.Items))
+	}
+
+	feed.XPathItemTitle = "$.a["
+	if _, err := parseJSONDocument([]byte(jsonAPITestBody), feed, nil); err == nil {
+		t.Error("expected error for invalid JSONPath")
+	}
+}
+
+func TestParseFeed_JSONPathUsesRequestOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "k1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(jsonAPITestBody))
+	}))
+	defer srv.Close()
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	id, err := f.AddXPathSubscription(srv.URL+"/api", "", "API", "JSON+JSONPath", "$.result.posts", "headline", "", "path", "", "", "", "", "", "id")
+	if err != nil {
+		t.Fatalf("AddXPathSubscription error: %v", err)
+	}
+	if err := db.UpdateFeedRequestOptions(id, models.FeedRequestOptions{CustomHeaders: `{"X-Api-Key": "k1"}`}); err != nil {
+		t.Fatalf("UpdateFeedRequestOptions error: %v", err)
+	}
+	feed, _ := db.GetFeedByID(id)
+
+	parsed, err := f.parseFeedWithFeedInternal(context.Background(), feed, false)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(parsed.Items) != 2 || parsed.Items[1].Link != "https://example.com/roadmap" {
+		t.Errorf("unexpected items: %+v", parsed.Items)
+	}
+}
@@ -0,0 +1,557 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"golang.org/x/net/html/charset"
+)
+
+// MailFeedType is the type of feeds read from a local Maildir or mbox.
+const MailFeedType = "Mail"
+
+// Only the most recent messages of a mailbox are read
+const maxMailMessages = 500
+
+// Messages larger than this are skipped
+const maxMailMessageBytes = 25 << 20
+
+// The identifier of a List-Id header, e.g. "Weekly News <weekly.news.example.com>"
+var listIDPattern = regexp.MustCompile(`<([^<>]+)>\s*$`)
+
+var mailWordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// mailMessage is a newsletter message read from a mailbox.
+type mailMessage struct {
+	MessageID string
+	Subject   string
+	FromName  string
+	FromAddr  string
+	Date      time.Time
+	ListID    string // Identifier of the List-Id header, lower case
+	ListName  string // Phrase of the List-Id header
+	HTML      string // HTML body, or the plain text body converted to HTML
+}
+
+// groupKey returns the feed a message belongs to: its mailing list, else its sender address.
+func (m *mailMessage) groupKey() string {
+	if m.ListID != "" {
+		return m.ListID
+	}
+	return m.FromAddr
+}
+
+// groupTitle returns a feed title for the message's group.
+func (m *mailMessage) groupTitle() string {
+	switch {
+	case m.ListName != "":
+		return m.ListName
+	case m.FromName != "":
+		return m.FromName
+	case m.ListID != "":
+		return m.ListID
+	}
+	return m.FromAddr
+}
+
+// MailGroup is a set of messages of a mailbox that can be subscribed to as a feed.
+type MailGroup struct {
+	Key    string    `json:"key"` // List-Id, or the sender address for messages without one
+	Title  string    `json:"title"`
+	Count  int       `json:"count"`
+	Latest time.Time `json:"latest"`
+	// URL of the feed for this group
+	URL        string `json:"url"`
+	Subscribed bool   `json:"subscribed"`
+}
+
+// MailFeedURL returns the URL of a mail feed reading the given mailbox path.
+// group selects the messages of one List-Id or sender; "" reads all messages.
+func MailFeedURL(path, group string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		// Windows paths such as C:/Mail become mail:///C:/Mail
+		p = "/" + p
+	}
+	u := url.URL{Scheme: "mail", Path: p}
+	if group != "" {
+		u.RawQuery = url.Values{"group": {group}}.Encode()
+	}
+	return u.String()
+}
+
+// parseMailFeedURL returns the mailbox path and group of a mail feed URL.
+func parseMailFeedURL(feedURL string) (string, string, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil || u.Scheme != "mail" || u.Path == "" {
+		return "", "", fmt.Errorf("invalid mail feed URL %q", feedURL)
+	}
+	p := u.Path
+	if len(p) >= 3 && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p), u.Query().Get("group"), nil
+}
+
+// parseFeedWithMail turns the messages of a Maildir or mbox into feed items.
+func (f *Fetcher) parseFeedWithMail(ctx context.Context, feed *models.Feed) (*gofeed.Feed, error) {
+	path, group, err := parseMailFeedURL(feed.URL)
+	if err != nil {
+		return nil, err
+	}
+	path, err = f.mailboxPath(path)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := readMailbox(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedFeed := &gofeed.Feed{
+		Title:       feed.Title,
+		Description: feed.Description,
+		Link:        feed.Link,
+		FeedType:    "mail",
+		Items:       make([]*gofeed.Item, 0),
+	}
+	for _, msg := range messages {
+		if group != "" && msg.groupKey() != group {
+			continue
+		}
+		if parsedFeed.Title == "" {
+			parsedFeed.Title = msg.groupTitle()
+		}
+		parsedFeed.Items = append(parsedFeed.Items, mailItem(msg))
+	}
+	return parsedFeed, nil
+}
+
+// mailItem converts a message to a feed item. Messages have no web address,
+// so the link is a mid: URL (RFC 2392) of the Message-ID.
+func mailItem(msg *mailMessage) *gofeed.Item {
+	id := msg.MessageID
+	if id == "" {
+		sum := sha1.Sum([]byte(msg.FromAddr + msg.Subject + msg.Date.String()))
+		id = hex.EncodeToString(sum[:]) + "@mrrss.local"
+	}
+	item := &gofeed.Item{
+		Title:   msg.Subject,
+		Content: utils.CleanHTML(msg.HTML),
+		Link:    "mid:" + url.PathEscape(id),
+		GUID:    id,
+	}
+	if item.Title == "" {
+		item.Title = "(no subject)"
+	}
+	if msg.FromName != "" || msg.FromAddr != "" {
+		item.Author = &gofeed.Person{Name: msg.FromName, Email: msg.FromAddr}
+		if item.Author.Name == "" {
+			item.Author.Name = msg.FromAddr
+		}
+	}
+	if !msg.Date.IsZero() {
+		published := msg.Date
+		item.PublishedParsed = &published
+		item.Published = published.Format(time.RFC1123Z)
+	}
+	return item
+}
+
+// ListMailGroups returns the mailing lists and senders found in a mailbox, most recent first,
+// and whether each is already subscribed to. The mailbox must be in the mail directory; a
+// relative path is taken relative to it.
+func (f *Fetcher) ListMailGroups(ctx context.Context, path string) ([]MailGroup, error) {
+	path, err := f.mailboxPath(path)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := readMailbox(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	subscribed, err := f.db.GetAllFeedURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*MailGroup)
+	for _, msg := range messages {
+		key := msg.groupKey()
+		if key == "" {
+			continue
+		}
+		group, ok := byKey[key]
+		if !ok {
+			group = &MailGroup{Key: key, URL: MailFeedURL(path, key)}
+			group.Subscribed = subscribed[group.URL]
+			byKey[key] = group
+		}
+		group.Count++
+		if !msg.Date.Before(group.Latest) {
+			group.Latest = msg.Date
+			group.Title = msg.groupTitle()
+		}
+	}
+
+	groups := make([]MailGroup, 0, len(byKey))
+	for _, group := range byKey {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if !groups[i].Latest.Equal(groups[j].Latest) {
+			return groups[i].Latest.After(groups[j].Latest)
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
+
+// AddMailSubscriptions subscribes to the given groups of a mailbox, one feed each,
+// or to all of its groups if none are given, and returns the feed IDs.
+func (f *Fetcher) AddMailSubscriptions(ctx context.Context, path string, category string, groups []string) ([]int64, error) {
+	found, err := f.ListMailGroups(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		wanted[g] = true
+	}
+
+	var ids []int64
+	for _, group := range found {
+		if len(groups) > 0 && !wanted[group.Key] {
+			continue
+		}
+		delete(wanted, group.Key)
+		id, err := f.db.AddFeed(&models.Feed{
+			Title:    group.Title,
+			URL:      group.URL,
+			Category: category,
+			Type:     MailFeedType,
+		})
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	if len(wanted) > 0 {
+		missing := make([]string, 0, len(wanted))
+		for key := range wanted {
+			missing = append(missing, key)
+		}
+		sort.Strings(missing)
+		return ids, fmt.Errorf("no messages from %s in %s", strings.Join(missing, ", "), path)
+	}
+	return ids, nil
+}
+
+// mailboxPath resolves a mailbox path against the mail directory and rejects paths outside
+// it, so the API cannot be used to read or probe arbitrary files.
+func (f *Fetcher) mailboxPath(path string) (string, error) {
+	if f.mailDir == "" {
+		return "", fmt.Errorf("mail directory is not available")
+	}
+	mailDir := filepath.Clean(f.mailDir)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(mailDir, path)
+	}
+	path = filepath.Clean(path)
+	relPath, err := filepath.Rel(mailDir, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid mailbox path: mailbox must be within the mail directory %s", mailDir)
+	}
+	return path, nil
+}
+
+// readMailbox reads the most recent messages of a Maildir (a directory with cur/ and new/)
+// or an mbox file, oldest first. Messages that cannot be parsed are skipped.
+func readMailbox(ctx context.Context, path string) ([]*mailMessage, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open mailbox: %w", err)
+	}
+	if info.IsDir() {
+		return readMaildir(ctx, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open mailbox: %w", err)
+	}
+	defer file.Close()
+	return readMbox(ctx, file)
+}
+
+func readMaildir(ctx context.Context, dir string) ([]*mailMessage, error) {
+	type entry struct {
+		path    string
+		modTime time.Time
+	}
+	var entries []entry
+	found := false
+	for _, sub := range []string{"new", "cur"} {
+		files, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, file := range files {
+			// Skip dotfiles left behind by sync tools
+			if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil || info.Size() > maxMailMessageBytes {
+				continue
+			}
+			entries = append(entries, entry{filepath.Join(dir, sub, file.Name()), info.ModTime()})
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%s is not a Maildir (no cur/ or new/ directory)", dir)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+	if len(entries) > maxMailMessages {
+		entries = entries[len(entries)-maxMailMessages:]
+	}
+
+	messages := make([]*mailMessage, 0, len(entries))
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(e.path)
+		if err != nil {
+			continue
+		}
+		if msg, err := parseMailMessage(bytes.NewReader(data)); err == nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// readMbox splits an mbox on its "From " separator lines, unquoting ">From " lines (mboxrd).
+func readMbox(ctx context.Context, r io.Reader) ([]*mailMessage, error) {
+	reader := bufio.NewReaderSize(r, 64<<10)
+	var raw [][]byte
+	var current *bytes.Buffer
+	tooLarge := false
+	prevBlank := true
+
+	flush := func() {
+		if current != nil && !tooLarge {
+			raw = append(raw, current.Bytes())
+			// Keep memory bounded for large archives
+			if len(raw) > maxMailMessages {
+				raw = raw[1:]
+			}
+		}
+		current, tooLarge = nil, false
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if prevBlank && bytes.HasPrefix(line, []byte("From ")) {
+				flush()
+				current = &bytes.Buffer{}
+			} else if current != nil && !tooLarge {
+				if unquoted := bytes.TrimLeft(line, ">"); len(unquoted) < len(line) && bytes.HasPrefix(unquoted, []byte("From ")) {
+					line = line[1:]
+				}
+				current.Write(line)
+				tooLarge = current.Len() > maxMailMessageBytes
+			}
+			prevBlank = len(bytes.TrimRight(line, "\r\n")) == 0
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+	}
+	flush()
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no messages found; the file is not an mbox or is empty")
+	}
+
+	messages := make([]*mailMessage, 0, len(raw))
+	for _, data := range raw {
+		if msg, err := parseMailMessage(bytes.NewReader(data)); err == nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// parseMailMessage parses the headers and body of a message. The HTML part is preferred;
+// a message with only plain text gets it as escaped paragraphs.
+func parseMailMessage(r io.Reader) (*mailMessage, error) {
+	m, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &mailMessage{
+		MessageID: strings.Trim(strings.TrimSpace(m.Header.Get("Message-Id")), "<>"),
+		Subject:   decodeMailHeader(m.Header.Get("Subject")),
+	}
+	if from, err := mail.ParseAddress(decodeMailHeader(m.Header.Get("From"))); err == nil {
+		msg.FromName = from.Name
+		msg.FromAddr = strings.ToLower(from.Address)
+	}
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date
+	}
+	if listID := decodeMailHeader(m.Header.Get("List-Id")); listID != "" {
+		if match := listIDPattern.FindStringSubmatchIndex(listID); match != nil {
+			msg.ListID = strings.ToLower(strings.TrimSpace(listID[match[2]:match[3]]))
+			msg.ListName = strings.Trim(strings.TrimSpace(listID[:match[0]]), `"`)
+		} else {
+			msg.ListID = strings.ToLower(strings.TrimSpace(listID))
+		}
+	}
+
+	htmlBody, textBody := mailBodies(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body)
+	switch {
+	case htmlBody != "":
+		msg.HTML = htmlBody
+	case textBody != "":
+		msg.HTML = textToHTML(textBody)
+	}
+	return msg, nil
+}
+
+// mailBodies returns the first text/html and text/plain parts of a message body, walking multipart containers.
+func mailBodies(contentType, transferEncoding string, body io.Reader) (htmlBody, textBody string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				return htmlBody, textBody
+			}
+			// Attached files are not part of the newsletter's text
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+			h, t := mailBodies(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if htmlBody == "" {
+				htmlBody = h
+			}
+			if textBody == "" {
+				textBody = t
+			}
+		}
+	}
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return "", ""
+	}
+
+	var decoded io.Reader = body
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "quoted-printable":
+		decoded = quotedprintable.NewReader(body)
+	case "base64":
+		decoded = base64.NewDecoder(base64.StdEncoding, newBase64Cleaner(body))
+	}
+	if cs := params["charset"]; cs != "" {
+		if converted, err := charset.NewReaderLabel(cs, decoded); err == nil {
+			decoded = converted
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(decoded, maxMailMessageBytes))
+	if err != nil && len(data) == 0 {
+		return "", ""
+	}
+	if mediaType == "text/html" {
+		return string(data), ""
+	}
+	return "", string(data)
+}
+
+// base64Cleaner drops the line breaks and spaces of a base64 body so it can be decoded as one stream.
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func newBase64Cleaner(r io.Reader) io.Reader {
+	return &base64Cleaner{r: r}
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	for {
+		n, err := c.r.Read(p)
+		kept := 0
+		for _, b := range p[:n] {
+			if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+				p[kept] = b
+				kept++
+			}
+		}
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// decodeMailHeader decodes RFC 2047 encoded words, leaving the value as is if it is malformed.
+func decodeMailHeader(value string) string {
+	decoded, err := mailWordDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// textToHTML turns a plain text body into HTML paragraphs.
+func textToHTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var b strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(para), "\n", "<br>"))
+		b.WriteString("</p>")
+	}
+	return b.String()
+}
@@ -0,0 +1,177 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMbox = "From weekly@news.example Mon Jan  1 09:00:00 2024\r\n" +
+	"From: \"Weekly News\" <weekly@news.example>\r\n" +
+	"To: me@example.com\r\n" +
+	"Subject: =?UTF-8?Q?Caf=C3=A9_edition?=\r\n" +
+	"Date: Mon, 01 Jan 2024 09:00:00 +0000\r\n" +
+	"Message-ID: <issue-1@news.example>\r\n" +
+	"List-Id: Weekly News <weekly.news.example>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Plain version\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"<p style=3D\"color: red\">Hello <b>caf=C3=A9</b></p><script>track()</script>\r\n" +
+	"--b1--\r\n" +
+	"\r\n" +
+	"From digest@other.example Tue Jan  2 09:00:00 2024\r\n" +
+	"From: Digest <digest@other.example>\r\n" +
+	"Subject: Daily digest\r\n" +
+	"Date: Tue, 02 Jan 2024 09:00:00 +0000\r\n" +
+	"Message-ID: <d-2@other.example>\r\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+	"\r\n" +
+	"First line\r\n" +
+	">From the archive\r\n" +
+	"\r\n" +
+	"Second <paragraph>\r\n" +
+	"\r\n" +
+	"From weekly@news.example Mon Jan  8 09:00:00 2024\r\n" +
+	"From: \"Weekly News\" <weekly@news.example>\r\n" +
+	"Subject: Second issue\r\n" +
+	"Date: Mon, 08 Jan 2024 09:00:00 +0000\r\n" +
+	"Message-ID: <issue-2@news.example>\r\n" +
+	"List-Id: <WEEKLY.news.example>\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PHA+SXNzdWUg\r\n" +
+	"dHdvPC9wPg==\r\n"
+
+func TestReadMbox(t *testing.T) {
+	messages, err := readMbox(context.Background(), strings.NewReader(testMbox))
+	if err != nil {
+		t.Fatalf("readMbox error: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+
+	first := messages[0]
+	if first.Subject != "Café edition" || first.FromName != "Weekly News" || first.FromAddr != "weekly@news.example" {
+		t.Errorf("unexpected headers: %+v", first)
+	}
+	if first.ListID != "weekly.news.example" || first.ListName != "Weekly News" || first.MessageID != "issue-1@news.example" {
+		t.Errorf("unexpected list: %q %q %q", first.ListID, first.ListName, first.MessageID)
+	}
+	if !strings.Contains(first.HTML, "<b>café</b>") {
+		t.Errorf("HTML part not preferred or not decoded: %q", first.HTML)
+	}
+
+	digest := messages[1]
+	if digest.groupKey() != "digest@other.example" {
+		t.Errorf("message without List-Id grouped as %q", digest.groupKey())
+	}
+	if digest.HTML != "<p>First line<br>From the archive</p><p>Second &lt;paragraph&gt;</p>" {
+		t.Errorf("plain text body = %q", digest.HTML)
+	}
+
+	if messages[2].ListID != "weekly.news.example" || messages[2].HTML != "<p>Issue two</p>" {
+		t.Errorf("base64 message = %q %q", messages[2].ListID, messages[2].HTML)
+	}
+}
+
+func TestParseFeedWithMail_Maildir(t *testing.T) {
+	mailDir := t.TempDir()
+	dir := filepath.Join(mailDir, "News")
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		os.MkdirAll(filepath.Join(dir, sub), 0755)
+	}
+	// Split the mbox into one file per message
+	for i, raw := range strings.Split(testMbox, "\r\n\r\nFrom ") {
+		// Drop the "From " separator line
+		raw = raw[strings.Index(raw, "\r\n")+2:]
+		os.WriteFile(filepath.Join(dir, "cur", fmt.Sprintf("msg%d:2,S", i)), []byte(raw), 0644)
+	}
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	f.mailDir = mailDir
+
+	groups, err := f.ListMailGroups(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("ListMailGroups error: %v", err)
+	}
+	if len(groups) != 2 || groups[0].Key != "weekly.news.example" || groups[0].Count != 2 || groups[1].Key != "digest@other.example" {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+
+	// Relative paths are taken relative to the mail directory
+	ids, err := f.AddMailSubscriptions(context.Background(), "News", "Newsletters", []string{"weekly.news.example"})
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("AddMailSubscriptions = %v, %v", ids, err)
+	}
+	feed, err := db.GetFeedByID(ids[0])
+	if err != nil {
+		t.Fatalf("GetFeedByID error: %v", err)
+	}
+	if feed.Type != MailFeedType || feed.Title != "Weekly News" || feed.Category != "Newsletters" {
+		t.Errorf("unexpected feed: %+v", feed)
+	}
+
+	parsed, err := f.parseFeedWithFeedInternal(context.Background(), feed, false)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(parsed.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(parsed.Items))
+	}
+	item := parsed.Items[0]
+	if item.Link != "mid:issue-1@news.example" || item.Author == nil || item.Author.Name != "Weekly News" || item.PublishedParsed == nil {
+		t.Errorf("unexpected item: %+v", item)
+	}
+	// Content goes through utils.CleanHTML
+	if strings.Contains(item.Content, "style=") || strings.Contains(item.Content, "<script>") {
+		t.Errorf("content not cleaned: %q", item.Content)
+	}
+
+	if _, err := f.AddMailSubscriptions(context.Background(), dir, "", []string{"nobody@example.com"}); err == nil {
+		t.Error("expected error for an unknown group")
+	}
+	os.Mkdir(filepath.Join(mailDir, "Empty"), 0755)
+	if _, err := f.ListMailGroups(context.Background(), "Empty"); err == nil {
+		t.Error("expected error for a directory that is not a Maildir")
+	}
+
+	// Mailboxes outside the mail directory are refused without being opened
+	outside := t.TempDir()
+	for _, path := range []string{outside, "../" + filepath.Base(outside), filepath.Join(dir, "..", "..")} {
+		if _, err := f.ListMailGroups(context.Background(), path); err == nil || !strings.Contains(err.Error(), "within the mail directory") {
+			t.Errorf("ListMailGroups(%q) error = %v, want a path error", path, err)
+		}
+	}
+	feed.URL = MailFeedURL(outside, "")
+	if _, err := f.parseFeedWithFeedInternal(context.Background(), feed, false); err == nil || !strings.Contains(err.Error(), "within the mail directory") {
+		t.Errorf("parsing a mail feed outside the mail directory: error = %v", err)
+	}
+}
+
+func TestMailFeedURL(t *testing.T) {
+	u := MailFeedURL("/home/me/Mail/News", "weekly.news.example")
+	if u != "mail:///home/me/Mail/News?group=weekly.news.example" {
+		t.Errorf("MailFeedURL = %q", u)
+	}
+	path, group, err := parseMailFeedURL(u)
+	if err != nil || path != filepath.FromSlash("/home/me/Mail/News") || group != "weekly.news.example" {
+		t.Errorf("parseMailFeedURL = %q %q %v", path, group, err)
+	}
+	if _, _, err := parseMailFeedURL("https://example.com/feed"); err == nil {
+		t.Error("expected error for a non-mail URL")
+	}
+}
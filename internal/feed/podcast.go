@@ -0,0 +1,96 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// extractPodcastEpisode returns the episode details of a podcast item: the iTunes duration,
+// episode and season numbers, and chapters from a Podcasting 2.0 <podcast:chapters> link
+// or inline Podlove Simple Chapters (<psc:chapters>). It returns nil if there are none.
+func extractPodcastEpisode(item *gofeed.Item) *models.PodcastEpisode {
+	ep := &models.PodcastEpisode{}
+	if it := item.ITunesExt; it != nil {
+		ep.Duration = parseEpisodeDuration(it.Duration)
+		ep.Episode, _ = strconv.Atoi(strings.TrimSpace(it.Episode))
+		ep.Season, _ = strconv.Atoi(strings.TrimSpace(it.Season))
+		ep.EpisodeType = strings.ToLower(strings.TrimSpace(it.EpisodeType))
+	}
+	if link := firstExtension(item, "podcast", "chapters"); link != nil {
+		ep.ChaptersURL = strings.TrimSpace(link.Attrs["url"])
+		ep.ChaptersType = strings.TrimSpace(link.Attrs["type"])
+	}
+	if psc := firstExtension(item, "psc", "chapters"); psc != nil {
+		for _, c := range psc.Children["chapter"] {
+			start, ok := parseNormalPlayTime(c.Attrs["start"])
+			if !ok {
+				continue
+			}
+			ep.Chapters = append(ep.Chapters, models.Chapter{
+				Start: start,
+				Title: strings.TrimSpace(c.Attrs["title"]),
+				URL:   strings.TrimSpace(c.Attrs["href"]),
+				Image: strings.TrimSpace(c.Attrs["image"]),
+			})
+		}
+	}
+
+	if ep.Duration == 0 && ep.Episode == 0 && ep.Season == 0 && ep.EpisodeType == "" && ep.ChaptersURL == "" && len(ep.Chapters) == 0 {
+		return nil
+	}
+	return ep
+}
+
+// firstExtension returns the first prefix:name extension element of an item, or nil.
+func firstExtension(item *gofeed.Item, prefix, name string) *ext.Extension {
+	if item.Extensions == nil {
+		return nil
+	}
+	if elems := item.Extensions[prefix][name]; len(elems) > 0 {
+		return &elems[0]
+	}
+	return nil
+}
+
+// parseEpisodeDuration parses an itunes:duration value, either seconds or [HH:]MM:SS, into seconds.
+func parseEpisodeDuration(value string) int {
+	seconds, ok := parseNormalPlayTime(value)
+	if !ok {
+		return 0
+	}
+	return int(seconds)
+}
+
+// parseNormalPlayTime parses a time such as "90", "01:30", "1:01:30" or "00:01:30.500" into seconds.
+func parseNormalPlayTime(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	var total float64
+	for i, part := range parts {
+		var n float64
+		var err error
+		if i == len(parts)-1 {
+			n, err = strconv.ParseFloat(part, 64)
+		} else {
+			var whole int
+			whole, err = strconv.Atoi(part)
+			n = float64(whole)
+		}
+		if err != nil || !(n >= 0) || math.IsInf(n, 0) {
+			return 0, false
+		}
+		total = total*60 + n
+	}
+	return total, true
+}
@@ -0,0 +1,85 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestParseNormalPlayTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"90", 90, true},
+		{"01:30", 90, true},
+		{"1:01:30", 3690, true},
+		{"00:01:30.500", 90.5, true},
+		{" 45 ", 45, true},
+		{"", 0, false},
+		{"1:2:3:4", 0, false},
+		{"-5", 0, false},
+		{"NaN", 0, false},
+		{"1h", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseNormalPlayTime(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseNormalPlayTime(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExtractPodcastEpisode(t *testing.T) {
+	const rss = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"
+     xmlns:podcast="https://podcastindex.org/namespace/1.0" xmlns:psc="http://podlove.org/simple-chapters">
+<channel><title>Show</title>
+<item>
+  <title>Episode 7</title><guid>ep7</guid>
+  <enclosure url="https://example.com/ep7.mp3" type="audio/mpeg" length="1000"/>
+  <itunes:duration>1:02:03</itunes:duration>
+  <itunes:episode>7</itunes:episode>
+  <itunes:season>3</itunes:season>
+  <itunes:episodeType>Bonus</itunes:episodeType>
+  <podcast:chapters url="https://example.com/ep7.json" type="application/json+chapters"/>
+  <psc:chapters version="1.2">
+    <psc:chapter start="00:00:00" title="Intro"/>
+    <psc:chapter start="00:05:30.250" title="Interview" href="https://example.com/guest" image="https://example.com/guest.jpg"/>
+    <psc:chapter start="later" title="Broken"/>
+  </psc:chapters>
+</item>
+<item>
+  <title>Plain audio</title><guid>plain</guid>
+  <enclosure url="https://example.com/plain.mp3" type="audio/mpeg" length="1000"/>
+</item>
+</channel></rss>`
+
+	parsed, err := gofeed.NewParser().Parse(strings.NewReader(rss))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	ep := extractPodcastEpisode(parsed.Items[0])
+	if ep == nil {
+		t.Fatal("expected episode details")
+	}
+	if ep.Duration != 3723 || ep.Episode != 7 || ep.Season != 3 || ep.EpisodeType != "bonus" {
+		t.Errorf("episode = %+v", ep)
+	}
+	if ep.ChaptersURL != "https://example.com/ep7.json" || ep.ChaptersType != "application/json+chapters" {
+		t.Errorf("chapters link = %q %q", ep.ChaptersURL, ep.ChaptersType)
+	}
+	if len(ep.Chapters) != 2 {
+		t.Fatalf("chapters = %+v", ep.Chapters)
+	}
+	if c := ep.Chapters[1]; c.Start != 330.25 || c.Title != "Interview" || c.URL != "https://example.com/guest" || c.Image != "https://example.com/guest.jpg" {
+		t.Errorf("chapter = %+v", c)
+	}
+
+	if ep := extractPodcastEpisode(parsed.Items[1]); ep != nil {
+		t.Errorf("expected no details for a plain audio item, got %+v", ep)
+	}
+}
@@ -0,0 +1,89 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Number of consecutive fetches that must be permanently redirected to the same URL
+// before the feed URL is updated, used when the feed_redirect_confirmations setting is missing or invalid
+const DefaultRedirectConfirmations = 3
+
+// trackPermanentRedirects returns a copy of client that remembers where permanent redirects lead.
+// The returned function reports the last URL reached while every hop so far was a 301 or 308;
+// a temporary redirect anywhere before it means the original URL must be kept.
+func trackPermanentRedirects(client *http.Client) (*http.Client, func() string) {
+	tracked := *client
+	target := ""
+	allPermanent := true
+
+	tracked.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if client.CheckRedirect != nil {
+			if err := client.CheckRedirect(req, via); err != nil {
+				return err
+			}
+		} else if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		if allPermanent && req.Response != nil &&
+			(req.Response.StatusCode == http.StatusMovedPermanently || req.Response.StatusCode == http.StatusPermanentRedirect) {
+			target = req.URL.String()
+		} else {
+			allPermanent = false
+		}
+		return nil
+	}
+
+	return &tracked, func() string { return target }
+}
+
+// handlePermanentRedirect updates the feed URL once enough consecutive fetches were
+// permanently redirected to the same location. If the new URL is already subscribed,
+// the feed is merged into that subscription.
+func (f *Fetcher) handlePermanentRedirect(feed models.Feed, info *fetchInfo) {
+	if info == nil {
+		return
+	}
+
+	target := info.PermanentRedirect
+	if target == "" || target == feed.URL {
+		if err := f.db.ClearPermanentRedirect(feed.ID); err != nil {
+			log.Printf("Error clearing redirect state of feed %s: %v", feed.Title, err)
+		}
+		return
+	}
+
+	count, err := f.db.NotePermanentRedirect(feed.ID, target)
+	if err != nil {
+		log.Printf("Error recording redirect of feed %s: %v", feed.Title, err)
+		return
+	}
+	if count < f.redirectConfirmations() {
+		return
+	}
+
+	mergedInto, err := f.db.ApplyFeedURLChange(feed.ID, feed.URL, target)
+	if err != nil {
+		log.Printf("Error updating URL of feed %s to %s: %v", feed.Title, target, err)
+		return
+	}
+	if mergedInto != 0 {
+		log.Printf("Feed %s moved to %s, merged into existing feed %d", feed.Title, target, mergedInto)
+	} else {
+		log.Printf("Feed %s moved permanently from %s to %s", feed.Title, feed.URL, target)
+	}
+}
+
+// redirectConfirmations returns the configured number of redirected fetches required before a feed URL is updated.
+func (f *Fetcher) redirectConfirmations() int {
+	if value, err := f.db.GetSetting("feed_redirect_confirmations"); err == nil {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return DefaultRedirectConfirmations
+}
@@ -0,0 +1,117 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"MrRSS/internal/refreshpolicy"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// How long prefetching held back by a refresh policy waits before it is looked at again
+	policyRecheckDelay = 15 * time.Minute
+	// Feeds refreshed at once in low data mode
+	lowDataConcurrency = 2
+)
+
+// GetRefreshPolicies returns the service that decides when background refreshing may download data.
+func (f *Fetcher) GetRefreshPolicies() *refreshpolicy.Service {
+	return f.policies
+}
+
+// policySnapshot reads the refresh policies and today's data usage. Without policies, or if
+// they cannot be read, nothing is restricted.
+func (f *Fetcher) policySnapshot() *refreshpolicy.Snapshot {
+	snap, err := f.policies.Snapshot(time.Now())
+	if err != nil {
+		log.Printf("Error reading refresh policies: %v", err)
+	}
+	return snap
+}
+
+// lowDataMode reports whether the global refresh policy asks to save data right now.
+func (f *Fetcher) lowDataMode() bool {
+	return f.policySnapshot().Decide("").LowData
+}
+
+// prefetchHeld reports whether the policies hold back prefetching for the feeds of a category.
+func prefetchHeld(snap *refreshpolicy.Snapshot, category string) bool {
+	d := snap.Decide(category)
+	return d.LowData || d.Paused
+}
+
+// feedCategory returns the category of a feed, remembering it in cache.
+func (f *Fetcher) feedCategory(cache map[int64]string, feedID int64) string {
+	if category, ok := cache[feedID]; ok {
+		return category
+	}
+	var category string
+	if feed, err := f.db.GetFeedByID(feedID); err == nil {
+		category = feed.Category
+	}
+	cache[feedID] = category
+	return category
+}
+
+// getMeteredHTTPClient returns the HTTP client of a feed whose responses count towards the data
+// usage of the feed's category.
+func (f *Fetcher) getMeteredHTTPClient(feed models.Feed) (*http.Client, error) {
+	client, err := f.getHTTPClient(feed)
+	if err != nil {
+		return nil, err
+	}
+	return f.withMetering(client, feed.Category), nil
+}
+
+// withMetering returns a copy of client whose response bodies count towards the data usage of category.
+func (f *Fetcher) withMetering(client *http.Client, category string) *http.Client {
+	if f.policies == nil || client == nil {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	metered := *client
+	metered.Transport = &meteredTransport{base: base, category: category, policies: f.policies}
+	return &metered
+}
+
+// meteredTransport records the size of the response bodies it reads.
+type meteredTransport struct {
+	base     http.RoundTripper
+	category string
+	policies *refreshpolicy.Service
+}
+
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+	resp.Body = &meteredBody{ReadCloser: resp.Body, record: func(n int64) { t.policies.Record(t.category, n) }}
+	return resp, nil
+}
+
+// meteredBody counts the bytes read and records them once, when the body is closed.
+type meteredBody struct {
+	io.ReadCloser
+	record func(int64)
+	n      int64
+	once   sync.Once
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *meteredBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.record(b.n) })
+	return err
+}
@@ -0,0 +1,94 @@
+package feed
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/refreshpolicy"
+)
+
+func TestMeteredClient_RecordsResponseSizes(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+
+	client, err := f.getMeteredHTTPClient(models.Feed{URL: server.URL, Category: "News"})
+	if err != nil {
+		t.Fatalf("getMeteredHTTPClient error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get error: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		resp.Body.Close()
+	}
+
+	usage, err := db.GetDataUsage(refreshpolicy.Day(time.Now()))
+	if err != nil {
+		t.Fatalf("GetDataUsage error: %v", err)
+	}
+	if usage["News"] != 2*int64(len(body)) {
+		t.Errorf("usage = %v, want %d bytes for News", usage, 2*len(body))
+	}
+}
+
+func TestLowDataMode_HoldsFullTextAndLimitsHosts(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	_ = db.SetSetting("full_text_host_interval_ms", "0")
+
+	if err := db.SaveRefreshPolicy(models.RefreshPolicy{LowData: true}); err != nil {
+		t.Fatalf("SaveRefreshPolicy error: %v", err)
+	}
+	if err := db.SaveRefreshPolicy(models.RefreshPolicy{Category: "Unmetered"}); err != nil {
+		t.Fatalf("SaveRefreshPolicy error: %v", err)
+	}
+	if got := f.getHostConcurrencyLimit(); got != 1 {
+		t.Errorf("host limit in low data mode = %d, want 1", got)
+	}
+
+	heldID, _ := db.AddFeed(&models.Feed{Title: "Held", URL: server.URL + "/held.xml"})
+	freeID, _ := db.AddFeed(&models.Feed{Title: "Free", URL: server.URL + "/free.xml", Category: "Unmetered"})
+	for _, id := range []int64{heldID, freeID} {
+		_ = db.SetFeedFetchFullText(id, true)
+	}
+	held, _ := db.GetFeedByID(heldID)
+	free, _ := db.GetFeedByID(freeID)
+	heldArticles := []*models.Article{{FeedID: heldID, Title: "Held", URL: server.URL + "/held", PublishedAt: time.Now()}}
+	freeArticles := []*models.Article{{FeedID: freeID, Title: "Free", URL: server.URL + "/free", PublishedAt: time.Now()}}
+	if err := db.SaveArticles(context.Background(), append(heldArticles, freeArticles...)); err != nil {
+		t.Fatalf("SaveArticles error: %v", err)
+	}
+	f.queueFullText(*held, heldArticles)
+	f.queueFullText(*free, freeArticles)
+	f.processFullTextQueue(context.Background())
+
+	if requests != 1 {
+		t.Errorf("made %d requests, want only the one of the category without low data", requests)
+	}
+	status, _ := db.GetFullTextStatus(heldArticles[0].ID)
+	if status == nil || status.Status != models.FullTextPending || status.Attempts != 0 {
+		t.Errorf("held article status = %+v, want pending without attempts", status)
+	}
+}
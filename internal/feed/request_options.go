@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ValidateRequestOptions checks the authentication type and that custom headers are a JSON object of strings.
+func ValidateRequestOptions(opts models.FeedRequestOptions) error {
+	switch opts.AuthType {
+	case "", models.FeedAuthBasic, models.FeedAuthBearer:
+	default:
+		return fmt.Errorf("unsupported auth type %q", opts.AuthType)
+	}
+	_, err := parseCustomHeaders(opts.CustomHeaders)
+	return err
+}
+
+// parseCustomHeaders parses custom headers given as a JSON object, e.g. {"X-Api-Key": "..."}.
+func parseCustomHeaders(headersJSON string) (map[string]string, error) {
+	if strings.TrimSpace(headersJSON) == "" {
+		return nil, nil
+	}
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
+		return nil, fmt.Errorf("custom headers must be a JSON object of strings: %w", err)
+	}
+	return headers, nil
+}
+
+// requestOptionsTransport adds a feed's User-Agent override to every request, and its credentials,
+// custom headers and cookies to requests for the feed's own host only, so they never leak to
+// redirect targets or third-party article pages.
+type requestOptionsTransport struct {
+	base    http.RoundTripper
+	host    string
+	opts    models.FeedRequestOptions
+	headers map[string]string
+}
+
+func (t *requestOptionsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	if t.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", t.opts.UserAgent)
+	}
+	if t.host != "" && strings.EqualFold(req.URL.Hostname(), t.host) {
+		for name, value := range t.headers {
+			req.Header.Set(name, value)
+		}
+		switch t.opts.AuthType {
+		case models.FeedAuthBasic:
+			req.SetBasicAuth(t.opts.AuthUsername, t.opts.AuthSecret)
+		case models.FeedAuthBearer:
+			req.Header.Set("Authorization", "Bearer "+t.opts.AuthSecret)
+		}
+		if t.opts.Cookies != "" {
+			if existing := req.Header.Get("Cookie"); existing != "" {
+				req.Header.Set("Cookie", existing+"; "+t.opts.Cookies)
+			} else {
+				req.Header.Set("Cookie", t.opts.Cookies)
+			}
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// withRequestOptions returns a copy of client that sends the given request options for feedURL.
+func withRequestOptions(client *http.Client, feedURL string, opts models.FeedRequestOptions) (*http.Client, error) {
+	if opts.IsZero() {
+		return client, nil
+	}
+	if err := ValidateRequestOptions(opts); err != nil {
+		return nil, err
+	}
+	headers, _ := parseCustomHeaders(opts.CustomHeaders)
+
+	host := ""
+	if u, err := url.Parse(feedURL); err == nil {
+		host = u.Hostname()
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	wrapped := *client
+	wrapped.Transport = &requestOptionsTransport{base: base, host: host, opts: opts, headers: headers}
+	return &wrapped, nil
+}
+
+// hasRequestOptions reports whether a stored feed has request options that must be loaded before fetching it.
+func hasRequestOptions(feed models.Feed) bool {
+	return feed.ID != 0 && (feed.AuthType != "" || feed.UserAgent != "" || feed.HasRequestSecrets)
+}
@@ -3,10 +3,13 @@ package feed
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,9 +26,33 @@ func NewScriptExecutor(scriptsDir string) *ScriptExecutor {
 	return &ScriptExecutor{scriptsDir: scriptsDir}
 }
 
+// Defaults for script runs without explicit limits
+const (
+	DefaultScriptTimeout        = 30 * time.Second
+	DefaultScriptMaxOutputBytes = 5 << 20
+	// Stderr kept for error messages and the fetch history
+	scriptStderrLimit = 4 << 10
+)
+
+// ScriptOptions controls how a feed script is run.
+type ScriptOptions struct {
+	Args           []string          // Command-line arguments passed after the script path
+	Env            map[string]string // Extra environment variables, added to the app's environment
+	Timeout        time.Duration     // 0 = DefaultScriptTimeout
+	MaxOutputBytes int64             // Maximum size of stdout, 0 = DefaultScriptMaxOutputBytes
+}
+
 // ExecuteScript runs the given script and parses the output as an RSS feed
 // The script should output valid RSS/Atom XML to stdout
 func (e *ScriptExecutor) ExecuteScript(ctx context.Context, scriptPath string) (*gofeed.Feed, error) {
+	feed, _, err := e.ExecuteScriptWithOptions(ctx, scriptPath, ScriptOptions{})
+	return feed, err
+}
+
+// ExecuteScriptWithOptions runs the given script with arguments, environment and limits
+// and parses its output, which may be RSS/Atom XML, a JSON Feed or a simple JSON item list
+// (see parseScriptOutput). It also returns what the script wrote to stderr, truncated.
+func (e *ScriptExecutor) ExecuteScriptWithOptions(ctx context.Context, scriptPath string, opts ScriptOptions) (*gofeed.Feed, string, error) {
 	// Construct full path
 	fullPath := filepath.Join(e.scriptsDir, scriptPath)
 	fullPath = filepath.Clean(fullPath)
@@ -37,11 +64,19 @@ func (e *ScriptExecutor) ExecuteScript(ctx context.Context, scriptPath string) (
 	// Use filepath.Rel to prevent directory traversal attacks
 	relPath, err := filepath.Rel(cleanScriptsDir, fullPath)
 	if err != nil || strings.HasPrefix(relPath, "..") || strings.Contains(relPath, string(filepath.Separator)+"..") {
-		return nil, fmt.Errorf("invalid script path: script must be within scripts directory")
+		return nil, "", fmt.Errorf("invalid script path: script must be within scripts directory")
 	}
 
-	// Create a context with timeout (30 seconds for script execution)
-	execCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultScriptTimeout
+	}
+	maxOutput := opts.MaxOutputBytes
+	if maxOutput <= 0 {
+		maxOutput = DefaultScriptMaxOutputBytes
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// Prepare command based on OS and file extension
@@ -55,54 +90,105 @@ func (e *ScriptExecutor) ExecuteScript(ctx context.Context, scriptPath string) (
 		if runtime.GOOS == "windows" {
 			pythonCmd = "python"
 		}
-		cmd = exec.CommandContext(execCtx, pythonCmd, fullPath)
+		cmd = exec.CommandContext(execCtx, pythonCmd, append([]string{fullPath}, opts.Args...)...)
 	case ".sh":
 		// Shell script (Unix-like systems)
 		if runtime.GOOS == "windows" {
-			return nil, fmt.Errorf("shell scripts are not supported on Windows")
+			return nil, "", fmt.Errorf("shell scripts are not supported on Windows")
 		}
-		cmd = exec.CommandContext(execCtx, "bash", fullPath)
+		cmd = exec.CommandContext(execCtx, "bash", append([]string{fullPath}, opts.Args...)...)
 	case ".ps1":
 		// PowerShell script (Windows)
 		if runtime.GOOS != "windows" {
-			cmd = exec.CommandContext(execCtx, "pwsh", "-File", fullPath)
+			cmd = exec.CommandContext(execCtx, "pwsh", append([]string{"-File", fullPath}, opts.Args...)...)
 		} else {
-			cmd = exec.CommandContext(execCtx, "powershell.exe", "-ExecutionPolicy", "Bypass", "-File", fullPath)
+			cmd = exec.CommandContext(execCtx, "powershell.exe", append([]string{"-ExecutionPolicy", "Bypass", "-File", fullPath}, opts.Args...)...)
 		}
 	case ".js":
 		// Node.js script
-		cmd = exec.CommandContext(execCtx, "node", fullPath)
+		cmd = exec.CommandContext(execCtx, "node", append([]string{fullPath}, opts.Args...)...)
 	case ".rb":
 		// Ruby script
-		cmd = exec.CommandContext(execCtx, "ruby", fullPath)
+		cmd = exec.CommandContext(execCtx, "ruby", append([]string{fullPath}, opts.Args...)...)
 	default:
 		// Try to execute directly (for compiled binaries)
-		cmd = exec.CommandContext(execCtx, fullPath)
+		cmd = exec.CommandContext(execCtx, fullPath, opts.Args...)
 	}
 
 	// Set working directory to the scripts directory
 	cmd.Dir = e.scriptsDir
+	if len(opts.Env) > 0 {
+		cmd.Env = os.Environ()
+		for _, name := range sortedKeys(opts.Env) {
+			cmd.Env = append(cmd.Env, name+"="+opts.Env[name])
+		}
+	}
 
-	// Capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Capture stdout and stderr; output over the limit stops the script
+	stdout := &cappedBuffer{limit: maxOutput, onOverflow: cancel}
+	stderr := &cappedBuffer{limit: scriptStderrLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Child processes of a killed script may keep the pipes open; don't wait for them
+	cmd.WaitDelay = 2 * time.Second
 
 	// Execute the script
-	if err := cmd.Run(); err != nil {
-		stderrStr := stderr.String()
+	runErr := cmd.Run()
+	stderrStr := strings.TrimSpace(stderr.String())
+	if stderr.overflowed {
+		stderrStr += "\n[stderr truncated]"
+	}
+	if stdout.overflowed {
+		return nil, stderrStr, fmt.Errorf("script output exceeds the limit of %d bytes", maxOutput)
+	}
+	if runErr != nil {
+		if errors.Is(execCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			runErr = fmt.Errorf("timed out after %s", timeout)
+		}
 		if stderrStr != "" {
-			return nil, fmt.Errorf("script execution failed: %v, stderr: %s", err, stderrStr)
+			return nil, stderrStr, fmt.Errorf("script execution failed: %v, stderr: %s", runErr, stderrStr)
 		}
-		return nil, fmt.Errorf("script execution failed: %v", err)
+		return nil, stderrStr, fmt.Errorf("script execution failed: %v", runErr)
 	}
 
-	// Parse the output as RSS/Atom feed
-	fp := gofeed.NewParser()
-	feed, err := fp.ParseString(stdout.String())
+	feed, err := parseScriptOutput(stdout.Bytes())
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse script output as feed: %v", err)
+		return nil, stderrStr, fmt.Errorf("failed to parse script output as feed: %v", err)
+	}
+
+	return feed, stderrStr, nil
+}
+
+// cappedBuffer keeps at most limit bytes of what is written to it and drops the rest.
+// The buffer is not embedded so io.Copy cannot bypass Write through bytes.Buffer.ReadFrom.
+type cappedBuffer struct {
+	buf        bytes.Buffer
+	limit      int64
+	overflowed bool
+	onOverflow func()
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - int64(b.buf.Len()); int64(len(p)) > room {
+		b.buf.Write(p[:max(room, 0)])
+		if !b.overflowed && b.onOverflow != nil {
+			b.onOverflow()
+		}
+		b.overflowed = true
+		// Report the full length so the copy from the pipe keeps draining it
+		return len(p), nil
 	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) Bytes() []byte  { return b.buf.Bytes() }
+func (b *cappedBuffer) String() string { return b.buf.String() }
 
-	return feed, nil
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -3,6 +3,7 @@ package feed
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -110,3 +111,66 @@ print("This should not print")
 		t.Error("ExecuteScript() should return error for timeout")
 	}
 }
+
+func writeTestScript(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to create test script: %v", err)
+	}
+}
+
+func TestScriptExecutor_ExecuteScriptWithOptions_ArgsEnvAndStderr(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "list.sh", `#!/bin/bash
+echo "fetching $1 with key $API_KEY" >&2
+printf '[{"title": "%s", "link": "https://example.com/%s", "published": 1700000000}]' "$2" "$1"
+`)
+
+	executor := NewScriptExecutor(tempDir)
+	feed, stderr, err := executor.ExecuteScriptWithOptions(context.Background(), "list.sh", ScriptOptions{
+		Args: []string{"golang", "Hello world"},
+		Env:  map[string]string{"API_KEY": "k1"},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteScriptWithOptions() error: %v", err)
+	}
+	if len(feed.Items) != 1 || feed.Items[0].Title != "Hello world" || feed.Items[0].Link != "https://example.com/golang" {
+		t.Fatalf("unexpected items: %+v", feed.Items)
+	}
+	if feed.Items[0].PublishedParsed == nil || feed.Items[0].PublishedParsed.Unix() != 1700000000 {
+		t.Errorf("published = %v", feed.Items[0].PublishedParsed)
+	}
+	if stderr != "fetching golang with key k1" {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+func TestScriptExecutor_ExecuteScriptWithOptions_Limits(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "flood.sh", "#!/bin/bash\nwhile true; do echo '<rss><channel><title>x</title></channel></rss>'; done\n")
+	writeTestScript(t, tempDir, "slow.sh", "#!/bin/bash\necho 'still working' >&2\nsleep 30\n")
+	executor := NewScriptExecutor(tempDir)
+
+	started := time.Now()
+	_, _, err := executor.ExecuteScriptWithOptions(context.Background(), "flood.sh", ScriptOptions{MaxOutputBytes: 1024})
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit of 1024 bytes") {
+		t.Errorf("expected output limit error, got %v", err)
+	}
+
+	_, stderr, err := executor.ExecuteScriptWithOptions(context.Background(), "slow.sh", ScriptOptions{Timeout: 200 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") || !strings.Contains(err.Error(), "still working") {
+		t.Errorf("expected timeout error with stderr, got %v", err)
+	}
+	if stderr != "still working" {
+		t.Errorf("stderr = %q", stderr)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Errorf("limits were not enforced promptly: %v", elapsed)
+	}
+}
@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxScriptTimeout is the longest timeout a script feed may be given.
+const MaxScriptTimeout = 10 * time.Minute
+
+// ValidateScriptOptions checks the timeout and the environment variable names of a script feed.
+func ValidateScriptOptions(opts models.FeedScriptOptions) error {
+	if opts.Timeout < 0 || time.Duration(opts.Timeout)*time.Second > MaxScriptTimeout {
+		return fmt.Errorf("script timeout must be between 0 and %d seconds", int(MaxScriptTimeout.Seconds()))
+	}
+	for name, value := range opts.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if strings.ContainsRune(value, 0) {
+			return fmt.Errorf("environment variable %s contains a NUL character", name)
+		}
+	}
+	for _, arg := range opts.Args {
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("script argument %q contains a NUL character", arg)
+		}
+	}
+	return nil
+}
+
+// ScriptFeedURL returns the placeholder URL of a script feed. Arguments are part of it,
+// so one script can back several feeds.
+func ScriptFeedURL(scriptPath string, args []string) string {
+	if len(args) == 0 {
+		return "script://" + scriptPath
+	}
+	return "script://" + scriptPath + "?" + url.Values{"arg": args}.Encode()
+}
+
+// scriptRunOptions returns how a feed's script is run: its stored arguments, environment
+// and timeout, with the global timeout and output limit settings as defaults.
+func (f *Fetcher) scriptRunOptions(feed models.Feed) (ScriptOptions, error) {
+	opts := ScriptOptions{
+		Args:    feed.ScriptArgs,
+		Timeout: time.Duration(feed.ScriptTimeout) * time.Second,
+	}
+	if feed.HasScriptEnv && feed.ID != 0 {
+		stored, err := f.db.GetFeedScriptOptions(feed.ID)
+		if err != nil {
+			return opts, fmt.Errorf("failed to load script options: %w", err)
+		}
+		opts.Env = stored.Env
+	}
+	if opts.Timeout <= 0 {
+		if s, err := f.db.GetSetting("script_timeout_seconds"); err == nil {
+			if n, err := strconv.Atoi(s); err == nil && n > 0 {
+				opts.Timeout = min(time.Duration(n)*time.Second, MaxScriptTimeout)
+			}
+		}
+	}
+	if s, err := f.db.GetSetting("script_max_output_kb"); err == nil {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 0 {
+			opts.MaxOutputBytes = n << 10
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// parseScriptOutput parses what a feed script printed. Besides RSS/Atom XML and JSON Feed
+// (https://jsonfeed.org), scripts may print a simple JSON list of items, either as an array
+// or as an object with "title", "link", "description" and "items":
+//
+//	[{"title": "...", "link": "...", "content": "...", "published": "2024-01-02T15:04:05Z"}]
+//
+// Items may also set "id", "author", "updated", "image" and "categories"; "url", "summary",
+// "date" and "tags" are accepted as aliases. Dates are RFC 3339 strings or epoch seconds/milliseconds.
+func parseScriptOutput(output []byte) (*gofeed.Feed, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(output, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("script printed nothing")
+	}
+	if trimmed[0] != '{' && trimmed[0] != '[' {
+		return gofeed.NewParser().Parse(bytes.NewReader(trimmed))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	parsedFeed := &gofeed.Feed{FeedType: "json"}
+	var items []any
+	switch v := doc.(type) {
+	case []any:
+		items = v
+	case map[string]any:
+		if version, _ := v["version"].(string); strings.HasPrefix(version, "https://jsonfeed.org/version/") {
+			return gofeed.NewParser().Parse(bytes.NewReader(trimmed))
+		}
+		parsedFeed.Title = jsonField(v, "title")
+		parsedFeed.Link = jsonField(v, "link", "url", "home_page_url")
+		parsedFeed.Description = jsonField(v, "description")
+		if image := jsonField(v, "image", "icon"); image != "" {
+			parsedFeed.Image = &gofeed.Image{URL: image}
+		}
+		var ok bool
+		if items, ok = v["items"].([]any); !ok {
+			return nil, fmt.Errorf(`JSON object has no "items" array`)
+		}
+	}
+
+	for i, raw := range items {
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("item %d is not a JSON object", i)
+		}
+		item, err := scriptJSONItem(obj)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		parsedFeed.Items = append(parsedFeed.Items, item)
+	}
+	return parsedFeed, nil
+}
+
+// scriptJSONItem converts an item of the simple JSON format to a gofeed.Item.
+func scriptJSONItem(obj map[string]any) (*gofeed.Item, error) {
+	item := &gofeed.Item{
+		Title:       jsonField(obj, "title"),
+		Link:        jsonField(obj, "link", "url"),
+		Content:     jsonField(obj, "content", "content_html", "content_text"),
+		Description: jsonField(obj, "description", "summary"),
+		GUID:        jsonField(obj, "id", "guid"),
+	}
+	if item.Link == "" && item.GUID == "" {
+		return nil, fmt.Errorf(`either "link" or "id" is required`)
+	}
+	if item.GUID == "" {
+		item.GUID = item.Link
+	}
+
+	switch author := obj["author"].(type) {
+	case string:
+		if author != "" {
+			item.Author = &gofeed.Person{Name: author}
+		}
+	case map[string]any:
+		if name := jsonField(author, "name"); name != "" {
+			item.Author = &gofeed.Person{Name: name, Email: jsonField(author, "email")}
+		}
+	}
+
+	if value, name := jsonFirst(obj, "published", "date", "date_published", "pubDate"); value != nil {
+		published, err := parseJSONTime(value, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		item.PublishedParsed = &published
+		item.Published = jsonScalarString(value)
+	}
+	if value, name := jsonFirst(obj, "updated", "date_modified"); value != nil {
+		updated, err := parseJSONTime(value, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		item.UpdatedParsed = &updated
+		item.Updated = jsonScalarString(value)
+	}
+
+	if image := jsonField(obj, "image", "thumbnail"); image != "" {
+		item.Image = &gofeed.Image{URL: image}
+	}
+
+	value, _ := jsonFirst(obj, "categories", "tags")
+	switch v := value.(type) {
+	case string:
+		item.Categories = []string{v}
+	case []any:
+		for _, c := range v {
+			if s := jsonScalarString(c); s != "" {
+				item.Categories = append(item.Categories, s)
+			}
+		}
+	}
+	return item, nil
+}
+
+// jsonFirst returns the value of the first of names present in obj, and that name.
+func jsonFirst(obj map[string]any, names ...string) (any, string) {
+	for _, name := range names {
+		if value, ok := obj[name]; ok && value != nil {
+			return value, name
+		}
+	}
+	return nil, ""
+}
+
+// jsonField returns the first non-empty value of names in obj as a string.
+func jsonField(obj map[string]any, names ...string) string {
+	for _, name := range names {
+		if s := jsonScalarString(obj[name]); s != "" {
+			return s
+		}
+	}
+	return ""
+}
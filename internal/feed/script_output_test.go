@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"testing"
+)
+
+func TestParseScriptOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		wantTitle string
+		wantItems []string // links
+		wantErr   bool
+	}{
+		{
+			name:      "RSS",
+			output:    `<rss version="2.0"><channel><title>RSS</title><item><title>A</title><link>https://example.com/a</link><guid>a</guid></item></channel></rss>`,
+			wantTitle: "RSS",
+			wantItems: []string{"https://example.com/a"},
+		},
+		{
+			name:      "JSON Feed",
+			output:    `{"version": "https://jsonfeed.org/version/1.1", "title": "JF", "items": [{"id": "1", "url": "https://example.com/1", "content_html": "<p>x</p>"}]}`,
+			wantTitle: "JF",
+			wantItems: []string{"https://example.com/1"},
+		},
+		{
+			name:      "simple item list",
+			output:    "\n[{\"title\": \"One\", \"url\": \"https://example.com/1\", \"tags\": [\"go\"]}, {\"id\": \"two\", \"date\": \"2024-01-02T15:04:05Z\"}]\n",
+			wantItems: []string{"https://example.com/1", ""},
+		},
+		{
+			name:      "simple object",
+			output:    `{"title": "Simple", "link": "https://example.com", "items": [{"title": "One", "link": "https://example.com/1", "author": {"name": "Ann"}}]}`,
+			wantTitle: "Simple",
+			wantItems: []string{"https://example.com/1"},
+		},
+		{name: "empty", output: "  \n", wantErr: true},
+		{name: "item without link or id", output: `[{"title": "lost"}]`, wantErr: true},
+		{name: "object without items", output: `{"title": "nothing"}`, wantErr: true},
+		{name: "bad date", output: `[{"id": "1", "published": "soon"}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed, err := parseScriptOutput([]byte(tt.output))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got feed %+v", feed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseScriptOutput() error: %v", err)
+			}
+			if feed.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", feed.Title, tt.wantTitle)
+			}
+			if len(feed.Items) != len(tt.wantItems) {
+				t.Fatalf("got %d items, want %d", len(feed.Items), len(tt.wantItems))
+			}
+			for i, link := range tt.wantItems {
+				if feed.Items[i].Link != link {
+					t.Errorf("item %d link = %q, want %q", i, feed.Items[i].Link, link)
+				}
+				if feed.Items[i].GUID == "" {
+					t.Errorf("item %d has no GUID", i)
+				}
+			}
+		})
+	}
+}
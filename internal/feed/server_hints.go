@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Deferral after a 429 response that does not say when to come back
+	DefaultRateLimitDeferral = 15 * time.Minute
+	// Upper bound for any deferral requested by a server, so a bogus header cannot silence a feed
+	MaxServerDeferral = 24 * time.Hour
+)
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+// Returns 0 if the header is missing, invalid or already in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// parseMaxAge returns the max-age of a Cache-Control header.
+// Returns 0 if there is none or the response must not be cached.
+func parseMaxAge(value string) time.Duration {
+	var maxAge time.Duration
+	for _, directive := range strings.Split(value, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store" || directive == "no-cache":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(directive, "max-age="), `"`))
+			if err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return maxAge
+}
+
+// recordServerHints stores when the server asked us to fetch the feed again.
+// Rate limiting (429, or 503 with Retry-After) defers the feed and every other feed on the same host;
+// Cache-Control max-age on a successful response defers only this feed.
+func (f *Fetcher) recordServerHints(feed models.Feed, info *fetchInfo) {
+	if info == nil {
+		return
+	}
+
+	now := time.Now()
+	var until time.Time
+	var reason string
+	switch {
+	case info.StatusCode == http.StatusTooManyRequests || (info.StatusCode == http.StatusServiceUnavailable && info.RetryAfter > 0):
+		wait := info.RetryAfter
+		if wait <= 0 {
+			wait = DefaultRateLimitDeferral
+		}
+		wait = min(wait, MaxServerDeferral)
+		until = now.Add(wait)
+		if info.RetryAfter > 0 {
+			reason = fmt.Sprintf("HTTP %d with Retry-After %s", info.StatusCode, wait.Round(time.Second))
+		} else {
+			reason = fmt.Sprintf("HTTP %d, waiting %s", info.StatusCode, wait.Round(time.Second))
+		}
+		if host := utils.URLHost(feed.URL); host != "" {
+			if err := f.db.SetHostDeferral(host, until, reason); err != nil {
+				log.Printf("Error deferring host %s: %v", host, err)
+			}
+		}
+	case info.MaxAge > 0 && (info.StatusCode == http.StatusNotModified || (info.StatusCode >= 200 && info.StatusCode < 300)):
+		until = now.Add(min(info.MaxAge, MaxServerDeferral))
+		reason = fmt.Sprintf("Cache-Control max-age=%d", int(info.MaxAge.Seconds()))
+	}
+
+	if until.IsZero() && feed.DeferredUntil.IsZero() {
+		return
+	}
+	if err := f.db.SetFeedDeferral(feed.ID, until, reason); err != nil {
+		log.Printf("Error deferring feed %s: %v", feed.Title, err)
+	}
+}
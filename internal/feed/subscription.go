@@ -4,8 +4,12 @@ import (
 	"MrRSS/internal/models"
 	"MrRSS/internal/utils"
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -20,15 +24,44 @@ import (
 
 // AddSubscription adds a new feed subscription and returns the feed ID.
 func (f *Fetcher) AddSubscription(url string, category string, customTitle string) (int64, error) {
+	return f.AddSubscriptionWithOptions(url, category, customTitle, models.FeedRequestOptions{})
+}
+
+// AddSubscriptionWithOptions adds a new feed subscription that is fetched with the given
+// credentials, headers, cookies and User-Agent, and returns the feed ID.
+// Pages of sites known to a URL resolver (see RegisterURLResolver) are subscribed to through
+// their feed, and other web pages through the feed they link to. With request options, only
+// feeds on the host the user entered are followed, so credentials are not sent elsewhere.
+func (f *Fetcher) AddSubscriptionWithOptions(url string, category string, customTitle string, opts models.FeedRequestOptions) (int64, error) {
 	utils.DebugLog("AddSubscription: Starting to add feed from URL: %s", url)
 
-	// Try standard parsing first
-	utils.DebugLog("AddSubscription: Attempting standard RSS parsing for URL: %s", url)
-	parsedFeed, err := f.fp.ParseURL(url)
+	ctx := context.Background()
+	enteredURL := url
+	var parsedFeed *gofeed.Feed
+	var err error
+	if resolved, resolveErr := f.ResolveFeedURL(ctx, url); resolveErr != nil {
+		utils.DebugLog("AddSubscription: Resolving %s failed: %v", url, resolveErr)
+	} else if resolved != url && !mayFollowWithOptions(opts, enteredURL, resolved) {
+		utils.DebugLog("AddSubscription: Not following %s to %s on another host with request options", url, resolved)
+	} else if resolved != url {
+		if parsedFeed, err = f.parseSubscriptionURL(resolved, opts); err == nil {
+			url = resolved
+		} else {
+			// Resolvers that only look at the URL shape may guess wrong
+			utils.DebugLog("AddSubscription: Resolved feed %s failed, using %s: %v", resolved, url, err)
+			parsedFeed = nil
+		}
+	}
+
+	if parsedFeed == nil {
+		// Try standard parsing first
+		utils.DebugLog("AddSubscription: Attempting standard RSS parsing for URL: %s", url)
+		parsedFeed, err = f.parseSubscriptionURL(url, opts)
+	}
 	if err != nil {
 		utils.DebugLog("AddSubscription: Standard RSS parsing failed for %s: %v", url, err)
 
-		// Only attempt JavaScript execution for certain types of errors
+		// Only attempt discovery and JavaScript execution for certain types of errors
 		errStr := err.Error()
 		shouldTryJS := false
 
@@ -39,15 +72,27 @@ func (f *Fetcher) AddSubscription(url string, category string, customTitle strin
 			strings.Contains(errStr, "expected element type") ||
 			strings.Contains(errStr, "Failed to detect feed type") {
 			shouldTryJS = true
-			utils.DebugLog("AddSubscription: Error indicates HTML content, will attempt JavaScript execution")
+			utils.DebugLog("AddSubscription: Error indicates HTML content, will attempt feed autodiscovery and JavaScript execution")
 		} else {
 			utils.DebugLog("AddSubscription: Error does not indicate HTML content, skipping JavaScript execution")
 		}
 
 		if shouldTryJS {
+			// The page may link to its feed
+			if feedURL, findErr := f.feedFinder.FindFeed(ctx, url); findErr == nil && feedURL != url && mayFollowWithOptions(opts, enteredURL, feedURL) {
+				utils.DebugLog("AddSubscription: Discovered feed %s on %s", feedURL, url)
+				if parsedFeed, err = f.parseSubscriptionURL(feedURL, opts); err == nil {
+					url = feedURL
+				}
+			}
+		}
+
+		if err == nil {
+			utils.DebugLog("AddSubscription: Feed autodiscovery succeeded")
+		} else if shouldTryJS {
 			// If standard parsing fails with parsing errors, try executing JavaScript in browser
 			utils.DebugLog("AddSubscription: Attempting JavaScript execution for URL: %s", url)
-			parsedFeed, err = f.parseFeedWithJavaScript(context.Background(), url, false) // Normal priority for subscription addition
+			parsedFeed, err = f.parseFeedWithJavaScript(ctx, url, false) // Normal priority for subscription addition
 			if err != nil {
 				utils.DebugLog("AddSubscription: JavaScript execution also failed: %v", err)
 				return 0, fmt.Errorf("both standard parsing and JavaScript execution failed: %w", err)
@@ -79,22 +124,65 @@ func (f *Fetcher) AddSubscription(url string, category string, customTitle strin
 		feed.ImageURL = parsedFeed.Image.URL
 	}
 
-	return f.db.AddFeed(feed)
+	feedID, err := f.db.AddFeed(feed)
+	if err != nil || opts.IsZero() {
+		return feedID, err
+	}
+	return feedID, f.db.UpdateFeedRequestOptions(feedID, opts)
+}
+
+// mayFollowWithOptions reports whether the feed found at feedURL for the URL the user entered
+// may be subscribed to with opts. Request options are only sent to the host the user entered.
+func mayFollowWithOptions(opts models.FeedRequestOptions, enteredURL, feedURL string) bool {
+	return opts.IsZero() || utils.URLHost(enteredURL) == utils.URLHost(feedURL)
+}
+
+// parseSubscriptionURL fetches and parses a feed that is not stored yet.
+func (f *Fetcher) parseSubscriptionURL(feedURL string, opts models.FeedRequestOptions) (*gofeed.Feed, error) {
+	if opts.IsZero() {
+		return f.fp.ParseURL(feedURL)
+	}
+	return f.parseURLWithOptions(feedURL, opts)
+}
+
+// parseURLWithOptions fetches and parses a feed that is not stored yet with the given request options.
+func (f *Fetcher) parseURLWithOptions(feedURL string, opts models.FeedRequestOptions) (*gofeed.Feed, error) {
+	feed := &models.Feed{URL: feedURL}
+	client, err := f.getHTTPClient(*feed)
+	if err != nil {
+		return nil, err
+	}
+	client, err = withRequestOptions(client, feedURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	parsedFeed, _, err := f.fetchFeedURL(ctx, client, feed, false)
+	return parsedFeed, err
 }
 
 // AddScriptSubscription adds a new feed subscription that uses a custom script
 // and returns the feed ID.
 func (f *Fetcher) AddScriptSubscription(scriptPath string, category string, customTitle string) (int64, error) {
+	return f.AddScriptSubscriptionWithOptions(scriptPath, category, customTitle, models.FeedScriptOptions{})
+}
+
+// AddScriptSubscriptionWithOptions adds a new script feed that runs with the given
+// arguments, environment and timeout, and returns the feed ID.
+func (f *Fetcher) AddScriptSubscriptionWithOptions(scriptPath string, category string, customTitle string, opts models.FeedScriptOptions) (int64, error) {
 	// Validate script path
 	if f.scriptExecutor == nil {
 		return 0, &ScriptError{Message: "script executor not initialized"}
 	}
 
 	// Execute script to get initial feed info
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	parsedFeed, err := f.scriptExecutor.ExecuteScript(ctx, scriptPath)
+	runOpts, err := f.scriptRunOptions(models.Feed{ScriptArgs: opts.Args, ScriptTimeout: opts.Timeout})
+	if err != nil {
+		return 0, err
+	}
+	runOpts.Env = opts.Env
+	parsedFeed, _, err := f.scriptExecutor.ExecuteScriptWithOptions(context.Background(), scriptPath, runOpts)
 	if err != nil {
 		return 0, err
 	}
@@ -104,12 +192,9 @@ func (f *Fetcher) AddScriptSubscription(scriptPath string, category string, cust
 		title = customTitle
 	}
 
-	// Use a placeholder URL for script-based feeds
-	url := "script://" + scriptPath
-
 	feed := &models.Feed{
 		Title:       title,
-		URL:         url,
+		URL:         ScriptFeedURL(scriptPath, opts.Args),
 		Link:        parsedFeed.Link,
 		Description: parsedFeed.Description,
 		Category:    category,
@@ -120,11 +205,15 @@ func (f *Fetcher) AddScriptSubscription(scriptPath string, category string, cust
 		feed.ImageURL = parsedFeed.Image.URL
 	}
 
-	return f.db.AddFeed(feed)
+	feedID, err := f.db.AddFeed(feed)
+	if err != nil || (len(opts.Args) == 0 && len(opts.Env) == 0 && opts.Timeout == 0) {
+		return feedID, err
+	}
+	return feedID, f.db.UpdateFeedScriptOptions(feedID, opts)
 }
 
-// AddXPathSubscription adds a new feed subscription that uses XPath expressions
-// and returns the feed ID.
+// AddXPathSubscription adds a new feed subscription that uses XPath expressions,
+// or CSS selectors or JSONPath expressions for the HTML+CSS and JSON+JSONPath types, and returns the feed ID.
 func (f *Fetcher) AddXPathSubscription(url string, category string, customTitle string, feedType string, xpathItem string, xpathItemTitle string, xpathItemContent string, xpathItemUri string, xpathItemAuthor string, xpathItemTimestamp string, xpathItemTimeFormat string, xpathItemThumbnail string, xpathItemCategories string, xpathItemUid string) (int64, error) {
 	title := customTitle
 	if title == "" {
@@ -192,13 +281,23 @@ func (f *Fetcher) ParseFeedWithFeed(ctx context.Context, feed *models.Feed, prio
 
 // parseFeedWithFeedInternal does the actual parsing work
 func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Feed, priority bool) (*gofeed.Feed, error) {
+	parsedFeed, _, err := f.parseFeedWithInfo(ctx, feed, priority, false)
+	return parsedFeed, err
+}
+
+// parseFeedWithInfo parses a feed and also returns HTTP response details for URL-based feeds.
+// When conditional is true, the feed's stored ETag/Last-Modified validators are sent and
+// ErrNotModified is returned if the server reports no changes.
+// The returned fetchInfo is nil for XPath, CSS, JSONPath, mail and JavaScript-rendered feeds;
+// for script feeds it only carries the script's stderr.
+func (f *Fetcher) parseFeedWithInfo(ctx context.Context, feed *models.Feed, priority bool, conditional bool) (*gofeed.Feed, *fetchInfo, error) {
 	utils.DebugLog("parseFeedWithFeedInternal: Starting parsing for URL: %s, scriptPath: %s, type: %s, priority: %v", feed.URL, feed.ScriptPath, feed.Type, priority)
 
 	if feed.ScriptPath != "" {
 		utils.DebugLog("parseFeedWithFeedInternal: Using script execution for %s", feed.ScriptPath)
 		// Execute the custom script to fetch feed
 		if f.scriptExecutor == nil {
-			return nil, &ScriptError{Message: "Script executor not initialized"}
+			return nil, nil, &ScriptError{Message: "Script executor not initialized"}
 		}
 
 		// For high priority requests, use shorter timeout
@@ -209,7 +308,12 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 			defer cancel()
 		}
 
-		return f.scriptExecutor.ExecuteScript(scriptCtx, feed.ScriptPath)
+		runOpts, err := f.scriptRunOptions(*feed)
+		if err != nil {
+			return nil, nil, err
+		}
+		parsedFeed, stderr, err := f.scriptExecutor.ExecuteScriptWithOptions(scriptCtx, feed.ScriptPath, runOpts)
+		return parsedFeed, &fetchInfo{ScriptStderr: stderr}, err
 	}
 
 	// Check if this is an XPath-based feed
@@ -223,7 +327,43 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 			defer cancel()
 		}
 
-		return f.parseFeedWithXPath(xpathCtx, feed)
+		parsedFeed, err := f.parseFeedWithXPath(xpathCtx, feed)
+		return parsedFeed, nil, err
+	}
+
+	// Check if this is a CSS selector-based feed
+	if feed.Type == "HTML+CSS" {
+		utils.DebugLog("parseFeedWithFeedInternal: Using CSS selector parsing")
+		cssCtx := ctx
+		if priority {
+			var cancel context.CancelFunc
+			cssCtx, cancel = context.WithTimeout(ctx, 15*time.Second) // Shorter timeout for content fetching
+			defer cancel()
+		}
+
+		parsedFeed, err := f.parseFeedWithCSS(cssCtx, feed)
+		return parsedFeed, nil, err
+	}
+
+	// Check if this is a JSON API feed mapped with JSONPath
+	if feed.Type == "JSON+JSONPath" {
+		utils.DebugLog("parseFeedWithFeedInternal: Using JSONPath parsing")
+		jsonCtx := ctx
+		if priority {
+			var cancel context.CancelFunc
+			jsonCtx, cancel = context.WithTimeout(ctx, 15*time.Second) // Shorter timeout for content fetching
+			defer cancel()
+		}
+
+		parsedFeed, err := f.parseFeedWithJSONPath(jsonCtx, feed)
+		return parsedFeed, nil, err
+	}
+
+	// Check if this is a newsletter feed read from a local mailbox
+	if feed.Type == MailFeedType {
+		utils.DebugLog("parseFeedWithFeedInternal: Reading mailbox for %s", feed.URL)
+		parsedFeed, err := f.parseFeedWithMail(ctx, feed)
+		return parsedFeed, nil, err
 	}
 
 	utils.DebugLog("parseFeedWithFeedInternal: Using traditional URL-based fetching for %s", feed.URL)
@@ -240,17 +380,20 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 	// Get HTTP client with proxy support based on feed settings
 	// This ensures proxy settings (feed-level or global) are respected
 	var parsedFeed *gofeed.Feed
-	httpClient, err := f.getHTTPClient(*feed)
+	var info *fetchInfo
+	httpClient, err := f.getMeteredHTTPClient(*feed)
 	if err != nil {
 		utils.DebugLog("parseFeedWithFeedInternal: Failed to create HTTP client with proxy: %v, using default parser", err)
 		// Fallback to default parser if proxy setup fails
 		parsedFeed, err = f.fp.ParseURLWithContext(feed.URL, fetchCtx)
 	} else {
-		// Create a parser with the proxy-enabled client
-		parser := gofeed.NewParser()
-		parser.Client = httpClient
+		// Fetch with the proxy-enabled client
 		utils.DebugLog("parseFeedWithFeedInternal: Attempting standard RSS parsing for %s (with proxy support if configured)", feed.URL)
-		parsedFeed, err = parser.ParseURLWithContext(feed.URL, fetchCtx)
+		parsedFeed, info, err = f.fetchFeedURL(fetchCtx, httpClient, feed, conditional)
+		if errors.Is(err, ErrNotModified) {
+			utils.DebugLog("parseFeedWithFeedInternal: Feed not modified since last fetch: %s", feed.URL)
+			return nil, info, err
+		}
 	}
 	if err != nil {
 		utils.DebugLog("parseFeedWithFeedInternal: Standard RSS parsing failed: %v", err)
@@ -285,33 +428,54 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 			parsedFeed, err = f.parseFeedWithJavaScript(jsCtx, feed.URL, priority)
 			if err != nil {
 				utils.DebugLog("parseFeedWithFeedInternal: JavaScript execution also failed: %v", err)
-				return nil, fmt.Errorf("both standard parsing and JavaScript execution failed: %w", err)
+				return nil, info, fmt.Errorf("both standard parsing and JavaScript execution failed: %w", err)
 			}
 			utils.DebugLog("parseFeedWithFeedInternal: JavaScript execution succeeded")
 		} else {
 			// For other types of errors (network, etc.), don't try JS execution
 			utils.DebugLog("parseFeedWithFeedInternal: Returning error without JavaScript execution: %v", err)
-			return nil, err
+			return nil, info, err
 		}
 	} else {
 		utils.DebugLog("parseFeedWithFeedInternal: Standard RSS parsing succeeded")
 	}
 
-	return parsedFeed, nil
+	return parsedFeed, info, nil
 }
 
 // parseFeedWithXPath parses a feed using XPath expressions
-func (f *Fetcher) parseFeedWithXPath(_ context.Context, feed *models.Feed) (*gofeed.Feed, error) {
+func (f *Fetcher) parseFeedWithXPath(ctx context.Context, feed *models.Feed) (*gofeed.Feed, error) {
 	if feed.XPathItem == "" {
 		return nil, fmt.Errorf("XPath item expression is required for XPath-based feeds")
 	}
 
-	// Fetch the content
-	httpClient, err := utils.CreateHTTPClient("", 30*time.Second)
+	// Fetch the content with the feed's proxy and request options
+	httpClient, err := f.getMeteredHTTPClient(*feed)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
-	resp, err := httpClient.Get(feed.URL)
+	body, err := fetchScrapedDocument(ctx, httpClient, feed.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedFeed, err := f.parseXPathDocument(body, feed, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsedFeed.Items) == 0 {
+		return nil, fmt.Errorf("no items found with XPath: %s", feed.XPathItem)
+	}
+	return parsedFeed, nil
+}
+
+// fetchScrapedDocument downloads the page or document an XPath or CSS feed is extracted from.
+func fetchScrapedDocument(ctx context.Context, httpClient *http.Client, pageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch content: %w", err)
 	}
@@ -325,6 +489,18 @@ func (f *Fetcher) parseFeedWithXPath(_ context.Context, feed *models.Feed) (*gof
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	return body, nil
+}
+
+// parseXPathDocument extracts the items of an XPath feed from a fetched document.
+// warn, if not nil, is told about fields of each item that look misconfigured.
+func (f *Fetcher) parseXPathDocument(body []byte, feed *models.Feed, warn func(item int, field, message string)) (*gofeed.Feed, error) {
+	itemWarn := func(i int) xpathWarnFunc {
+		if warn == nil {
+			return nil
+		}
+		return func(field, message string) { warn(i, field, message) }
+	}
 
 	// Create gofeed.Feed
 	parsedFeed := &gofeed.Feed{
@@ -342,13 +518,10 @@ func (f *Fetcher) parseFeedWithXPath(_ context.Context, feed *models.Feed) (*gof
 			return nil, fmt.Errorf("failed to parse HTML: %w", err)
 		}
 		items := htmlquery.Find(doc, feed.XPathItem)
-		if len(items) == 0 {
-			return nil, fmt.Errorf("no items found with XPath: %s", feed.XPathItem)
-		}
 
 		// Process HTML items
-		for _, item := range items {
-			gofeedItem := f.extractItemFromHTMLNode(item, feed)
+		for i, item := range items {
+			gofeedItem := f.extractItemFromHTMLNode(item, feed, itemWarn(i))
 			parsedFeed.Items = append(parsedFeed.Items, gofeedItem)
 		}
 	case "XML+XPath":
@@ -357,13 +530,10 @@ func (f *Fetcher) parseFeedWithXPath(_ context.Context, feed *models.Feed) (*gof
 			return nil, fmt.Errorf("failed to parse XML: %w", err)
 		}
 		items := xmlquery.Find(doc, feed.XPathItem)
-		if len(items) == 0 {
-			return nil, fmt.Errorf("no items found with XPath: %s", feed.XPathItem)
-		}
 
 		// Process XML items
-		for _, item := range items {
-			gofeedItem := f.extractItemFromXMLNode(item, feed)
+		for i, item := range items {
+			gofeedItem := f.extractItemFromXMLNode(item, feed, itemWarn(i))
 			parsedFeed.Items = append(parsedFeed.Items, gofeedItem)
 		}
 	default:
@@ -373,9 +543,36 @@ func (f *Fetcher) parseFeedWithXPath(_ context.Context, feed *models.Feed) (*gof
 	return parsedFeed, nil
 }
 
+// finalizeScrapedItem resolves a relative item link against baseURL and gives items without
+// a link a placeholder one, a hash of their GUID or title and content under the feed URL, so
+// scraped articles don't collide in the database. Items without a GUID use their link.
+// prefix names the kind of scraper in placeholder links, e.g. "xpath".
+func finalizeScrapedItem(item *gofeed.Item, feed *models.Feed, baseURL *url.URL, warn xpathWarnFunc, prefix string) {
+	if item.Link != "" && !strings.HasPrefix(item.Link, "http") {
+		warn.add("xpath_item_uri", fmt.Sprintf("relative URL %q resolved against the feed URL", item.Link))
+		item.Link = resolveScrapedURL(baseURL, item.Link)
+	}
+	if item.Link == "" {
+		warn.add("xpath_item_uri", "no link found, a placeholder link is used")
+		uniqueID := item.GUID
+		if uniqueID == "" {
+			uniqueID = item.Title + item.Content
+		}
+		if uniqueID == "" {
+			uniqueID = fmt.Sprintf("%s-article-%d", prefix, time.Now().UnixNano())
+		}
+		sum := sha1.Sum([]byte(uniqueID))
+		item.Link = fmt.Sprintf("%s#%s-%s", feed.URL, prefix, hex.EncodeToString(sum[:6]))
+	}
+	if item.GUID == "" {
+		item.GUID = item.Link
+	}
+}
+
 // extractItemFromHTMLNode extracts a gofeed.Item from an HTML node
-func (f *Fetcher) extractItemFromHTMLNode(item *html.Node, feed *models.Feed) *gofeed.Item {
+func (f *Fetcher) extractItemFromHTMLNode(item *html.Node, feed *models.Feed, warn xpathWarnFunc) *gofeed.Item {
 	gofeedItem := &gofeed.Item{}
+	baseURL, _ := url.Parse(feed.URL)
 
 	// Extract title
 	if feed.XPathItemTitle != "" {
@@ -384,6 +581,10 @@ func (f *Fetcher) extractItemFromHTMLNode(item *html.Node, feed *models.Feed) *g
 		}
 	}
 
+	if gofeedItem.Title == "" {
+		warn.add("xpath_item_title", "title is empty")
+	}
+
 	// Extract content
 	if feed.XPathItemContent != "" {
 		if contentNode := htmlquery.FindOne(item, feed.XPathItemContent); contentNode != nil {
@@ -420,40 +621,7 @@ func (f *Fetcher) extractItemFromHTMLNode(item *html.Node, feed *models.Feed) *g
 		if link == "" && item != nil && item.Data == "a" {
 			link = htmlquery.SelectAttr(item, "href")
 		}
-
-		// Resolve relative URLs to absolute URLs
-		if link != "" && !strings.HasPrefix(link, "http") {
-			baseURL, err := url.Parse(feed.URL)
-			if err == nil {
-				if ref, err := url.Parse(link); err == nil {
-					gofeedItem.Link = baseURL.ResolveReference(ref).String()
-				} else {
-					gofeedItem.Link = link
-				}
-			} else {
-				gofeedItem.Link = link
-			}
-		} else {
-			gofeedItem.Link = link
-		}
-	}
-
-	// If no URI was extracted, generate a unique URL for this article
-	// This ensures each XPath article has a unique URL to prevent database conflicts
-	if gofeedItem.Link == "" {
-		// Use feed URL as base and append a hash of the title or content
-		uniqueID := gofeedItem.Title
-		if uniqueID == "" {
-			// Fallback to content or a timestamp-based ID
-			if gofeedItem.Content != "" {
-				uniqueID = gofeedItem.Content
-			} else {
-				uniqueID = fmt.Sprintf("xpath-article-%d", time.Now().UnixNano())
-			}
-		}
-		// Create a simple hash of the unique identifier
-		hash := fmt.Sprintf("%x", len(uniqueID)) // Simple length-based hash for uniqueness
-		gofeedItem.Link = fmt.Sprintf("%s#xpath-%s", feed.URL, hash)
+		gofeedItem.Link = link
 	}
 
 	// Extract author
@@ -507,8 +675,14 @@ func (f *Fetcher) extractItemFromHTMLNode(item *html.Node, feed *models.Feed) *g
 				}
 				if err == nil {
 					gofeedItem.PublishedParsed = &parsedTime
+				} else if feed.XPathItemTimeFormat != "" {
+					warn.add("xpath_item_timestamp", fmt.Sprintf("cannot parse %q with time format %q", timeStr, feed.XPathItemTimeFormat))
+				} else {
+					warn.add("xpath_item_timestamp", fmt.Sprintf("cannot parse %q with any known time format", timeStr))
 				}
 			}
+		} else {
+			warn.add("xpath_item_timestamp", "expression matched nothing")
 		}
 	}
 
@@ -529,12 +703,8 @@ func (f *Fetcher) extractItemFromHTMLNode(item *html.Node, feed *models.Feed) *g
 			}
 			// Resolve relative URLs to absolute URLs
 			if imageURL != "" && !strings.HasPrefix(imageURL, "http") {
-				baseURL, err := url.Parse(feed.URL)
-				if err == nil {
-					if ref, err := url.Parse(imageURL); err == nil {
-						imageURL = baseURL.ResolveReference(ref).String()
-					}
-				}
+				warn.add("xpath_item_thumbnail", fmt.Sprintf("relative URL %q resolved against the feed URL", imageURL))
+				imageURL = resolveScrapedURL(baseURL, imageURL)
 			}
 			if imageURL != "" {
 				gofeedItem.Image = &gofeed.Image{URL: imageURL}
@@ -563,21 +733,14 @@ func (f *Fetcher) extractItemFromHTMLNode(item *html.Node, feed *models.Feed) *g
 		}
 	}
 
-	// If no UID, generate one from link or title
-	if gofeedItem.GUID == "" {
-		if gofeedItem.Link != "" {
-			gofeedItem.GUID = gofeedItem.Link
-		} else {
-			gofeedItem.GUID = gofeedItem.Title
-		}
-	}
-
+	finalizeScrapedItem(gofeedItem, feed, baseURL, warn, "xpath")
 	return gofeedItem
 }
 
 // extractItemFromXMLNode extracts a gofeed.Item from an XML node
-func (f *Fetcher) extractItemFromXMLNode(item *xmlquery.Node, feed *models.Feed) *gofeed.Item {
+func (f *Fetcher) extractItemFromXMLNode(item *xmlquery.Node, feed *models.Feed, warn xpathWarnFunc) *gofeed.Item {
 	gofeedItem := &gofeed.Item{}
+	baseURL, _ := url.Parse(feed.URL)
 
 	// Extract title
 	if feed.XPathItemTitle != "" {
@@ -586,6 +749,10 @@ func (f *Fetcher) extractItemFromXMLNode(item *xmlquery.Node, feed *models.Feed)
 		}
 	}
 
+	if gofeedItem.Title == "" {
+		warn.add("xpath_item_title", "title is empty")
+	}
+
 	// Extract content
 	if feed.XPathItemContent != "" {
 		if contentNode := xmlquery.FindOne(item, feed.XPathItemContent); contentNode != nil {
@@ -596,43 +763,10 @@ func (f *Fetcher) extractItemFromXMLNode(item *xmlquery.Node, feed *models.Feed)
 	// Extract URI
 	if feed.XPathItemUri != "" {
 		if uriNode := xmlquery.FindOne(item, feed.XPathItemUri); uriNode != nil {
-			link := strings.TrimSpace(uriNode.InnerText())
-			// Resolve relative URLs to absolute URLs
-			if link != "" && !strings.HasPrefix(link, "http") {
-				baseURL, err := url.Parse(feed.URL)
-				if err == nil {
-					if ref, err := url.Parse(link); err == nil {
-						gofeedItem.Link = baseURL.ResolveReference(ref).String()
-					} else {
-						gofeedItem.Link = link
-					}
-				} else {
-					gofeedItem.Link = link
-				}
-			} else {
-				gofeedItem.Link = link
-			}
+			gofeedItem.Link = strings.TrimSpace(uriNode.InnerText())
 		}
 	}
 
-	// If no URI was extracted, generate a unique URL for this article
-	// This ensures each XPath article has a unique URL to prevent database conflicts
-	if gofeedItem.Link == "" {
-		// Use feed URL as base and append a hash of the title or content
-		uniqueID := gofeedItem.Title
-		if uniqueID == "" {
-			// Fallback to content or a timestamp-based ID
-			if gofeedItem.Content != "" {
-				uniqueID = gofeedItem.Content
-			} else {
-				uniqueID = fmt.Sprintf("xpath-article-%d", time.Now().UnixNano())
-			}
-		}
-		// Create a simple hash of the unique identifier
-		hash := fmt.Sprintf("%x", len(uniqueID)) // Simple length-based hash for uniqueness
-		gofeedItem.Link = fmt.Sprintf("%s#xpath-%s", feed.URL, hash)
-	}
-
 	// Extract author
 	if feed.XPathItemAuthor != "" {
 		if authorNode := xmlquery.FindOne(item, feed.XPathItemAuthor); authorNode != nil {
@@ -669,8 +803,14 @@ func (f *Fetcher) extractItemFromXMLNode(item *xmlquery.Node, feed *models.Feed)
 				}
 				if err == nil {
 					gofeedItem.PublishedParsed = &parsedTime
+				} else if feed.XPathItemTimeFormat != "" {
+					warn.add("xpath_item_timestamp", fmt.Sprintf("cannot parse %q with time format %q", timeStr, feed.XPathItemTimeFormat))
+				} else {
+					warn.add("xpath_item_timestamp", fmt.Sprintf("cannot parse %q with any known time format", timeStr))
 				}
 			}
+		} else {
+			warn.add("xpath_item_timestamp", "expression matched nothing")
 		}
 	}
 
@@ -692,12 +832,8 @@ func (f *Fetcher) extractItemFromXMLNode(item *xmlquery.Node, feed *models.Feed)
 			}
 			// Resolve relative URLs to absolute URLs
 			if imageURL != "" && !strings.HasPrefix(imageURL, "http") {
-				baseURL, err := url.Parse(feed.URL)
-				if err == nil {
-					if ref, err := url.Parse(imageURL); err == nil {
-						imageURL = baseURL.ResolveReference(ref).String()
-					}
-				}
+				warn.add("xpath_item_thumbnail", fmt.Sprintf("relative URL %q resolved against the feed URL", imageURL))
+				imageURL = resolveScrapedURL(baseURL, imageURL)
 			}
 			if imageURL != "" {
 				gofeedItem.Image = &gofeed.Image{URL: imageURL}
@@ -726,15 +862,7 @@ func (f *Fetcher) extractItemFromXMLNode(item *xmlquery.Node, feed *models.Feed)
 		}
 	}
 
-	// If no UID, generate one from link or title
-	if gofeedItem.GUID == "" {
-		if gofeedItem.Link != "" {
-			gofeedItem.GUID = gofeedItem.Link
-		} else {
-			gofeedItem.GUID = gofeedItem.Title
-		}
-	}
-
+	finalizeScrapedItem(gofeedItem, feed, baseURL, warn, "xpath")
 	return gofeedItem
 }
 
@@ -0,0 +1,308 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+// DefaultRSSHubInstance is used for sites without feeds of their own when the rsshub_instance setting is empty.
+const DefaultRSSHubInstance = "https://rsshub.app"
+
+// maxResolverPageSize bounds how much of a page resolvers read when looking for IDs.
+const maxResolverPageSize = 4 << 20
+
+// ResolverEnv is what URL resolvers may use besides the URL itself.
+type ResolverEnv struct {
+	// Client fetches pages when the feed URL cannot be derived from the URL alone.
+	Client *http.Client
+	// RSSHub is the base URL of the RSSHub instance used for sites without feeds.
+	RSSHub string
+}
+
+// URLResolver maps the URL of a page on a known site, such as a YouTube channel or a
+// GitHub repository, to the URL of its feed.
+type URLResolver interface {
+	// Name identifies the resolver in logs.
+	Name() string
+	// Resolve returns the feed URL for u, and false if u is not a page the resolver knows.
+	Resolve(ctx context.Context, u *url.URL, env ResolverEnv) (string, bool, error)
+}
+
+var (
+	urlResolversMu sync.RWMutex
+	urlResolvers   []URLResolver
+)
+
+// RegisterURLResolver adds a resolver to the registry. Resolvers are tried in registration order.
+func RegisterURLResolver(r URLResolver) {
+	urlResolversMu.Lock()
+	defer urlResolversMu.Unlock()
+	urlResolvers = append(urlResolvers, r)
+}
+
+func init() {
+	RegisterURLResolver(youTubeResolver{})
+	RegisterURLResolver(redditResolver{})
+	RegisterURLResolver(gitHubResolver{})
+	RegisterURLResolver(bilibiliResolver{})
+	RegisterURLResolver(mastodonResolver{})
+}
+
+// ResolveFeedURL returns the feed URL of a page on a site known to a registered resolver,
+// or rawURL unchanged if no resolver matches it.
+func (f *Fetcher) ResolveFeedURL(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return rawURL, nil
+	}
+
+	client, err := f.getHTTPClient(models.Feed{URL: rawURL})
+	if err != nil {
+		return rawURL, err
+	}
+	env := ResolverEnv{Client: client, RSSHub: DefaultRSSHubInstance}
+	if instance, _ := f.db.GetSetting("rsshub_instance"); instance != "" {
+		env.RSSHub = strings.TrimSuffix(instance, "/")
+	}
+
+	urlResolversMu.RLock()
+	resolvers := append([]URLResolver(nil), urlResolvers...)
+	urlResolversMu.RUnlock()
+
+	for _, r := range resolvers {
+		feedURL, ok, err := r.Resolve(ctx, u, env)
+		if err != nil {
+			return rawURL, fmt.Errorf("%s: %w", r.Name(), err)
+		}
+		if ok {
+			utils.DebugLog("ResolveFeedURL: %s resolved %s to %s", r.Name(), rawURL, feedURL)
+			return feedURL, nil
+		}
+	}
+	return rawURL, nil
+}
+
+// hostIs reports whether host is domain or one of its subdomains.
+func hostIs(host, domain string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// pathSegments returns the non-empty segments of a URL path.
+func pathSegments(u *url.URL) []string {
+	var segments []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
+
+// youTubeResolver resolves channels, handles, legacy user pages and playlists to YouTube's Atom feeds.
+type youTubeResolver struct{}
+
+var youTubeChannelIDPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`<link rel="canonical" href="https://www\.youtube\.com/channel/(UC[\w-]{22})"`),
+	regexp.MustCompile(`<meta itemprop="(?:channelId|identifier)" content="(UC[\w-]{22})"`),
+	regexp.MustCompile(`"externalId":"(UC[\w-]{22})"`),
+	regexp.MustCompile(`"channelId":"(UC[\w-]{22})"`),
+}
+
+func (youTubeResolver) Name() string { return "YouTube" }
+
+func (youTubeResolver) Resolve(ctx context.Context, u *url.URL, env ResolverEnv) (string, bool, error) {
+	if !hostIs(u.Host, "youtube.com") {
+		return "", false, nil
+	}
+	const feedsURL = "https://www.youtube.com/feeds/videos.xml?"
+	segments := pathSegments(u)
+	if len(segments) == 0 {
+		return "", false, nil
+	}
+
+	if list := u.Query().Get("list"); list != "" && (segments[0] == "playlist" || segments[0] == "watch") {
+		return feedsURL + url.Values{"playlist_id": {list}}.Encode(), true, nil
+	}
+	switch {
+	case segments[0] == "feeds":
+		return "", false, nil
+	case segments[0] == "channel" && len(segments) > 1:
+		return feedsURL + url.Values{"channel_id": {segments[1]}}.Encode(), true, nil
+	case segments[0] == "user" && len(segments) > 1:
+		return feedsURL + url.Values{"user": {segments[1]}}.Encode(), true, nil
+	case strings.HasPrefix(segments[0], "@") || (segments[0] == "c" && len(segments) > 1):
+		// Handles and custom URLs only map to a channel ID through the channel page
+		pageURL := "https://www.youtube.com/" + strings.Join(segments[:min(len(segments), 2)], "/")
+		if strings.HasPrefix(segments[0], "@") {
+			pageURL = "https://www.youtube.com/" + segments[0]
+		}
+		channelID, err := findInPage(ctx, env.Client, pageURL, youTubeChannelIDPatterns)
+		if err != nil {
+			return "", false, err
+		}
+		return feedsURL + url.Values{"channel_id": {channelID}}.Encode(), true, nil
+	}
+	return "", false, nil
+}
+
+// findInPage fetches a page and returns the first submatch of the first pattern that matches it.
+func findInPage(ctx context.Context, client *http.Client, pageURL string, patterns []*regexp.Regexp) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept-Language", "en")
+	// Skips the cookie consent page shown in some regions
+	req.AddCookie(&http.Cookie{Name: "CONSENT", Value: "YES+"})
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned status %d", pageURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResolverPageSize))
+	if err != nil {
+		return "", err
+	}
+	for _, re := range patterns {
+		if m := re.FindSubmatch(body); m != nil {
+			return string(m[1]), nil
+		}
+	}
+	return "", fmt.Errorf("no channel ID found on %s", pageURL)
+}
+
+// redditResolver appends .rss to subreddit, user and other Reddit listing URLs.
+type redditResolver struct{}
+
+func (redditResolver) Name() string { return "Reddit" }
+
+func (redditResolver) Resolve(_ context.Context, u *url.URL, _ ResolverEnv) (string, bool, error) {
+	if !hostIs(u.Host, "reddit.com") {
+		return "", false, nil
+	}
+	segments := pathSegments(u)
+	if len(segments) < 2 || strings.HasSuffix(segments[len(segments)-1], ".rss") {
+		return "", false, nil
+	}
+	switch segments[0] {
+	case "r", "user":
+	case "u":
+		segments[0] = "user"
+	default:
+		return "", false, nil
+	}
+	feedURL := "https://www.reddit.com/" + strings.Join(segments, "/") + "/.rss"
+	if u.RawQuery != "" {
+		feedURL += "?" + u.RawQuery
+	}
+	return feedURL, true, nil
+}
+
+// gitHubResolver resolves users to their activity feed and repositories to their releases,
+// tags or commits feeds.
+type gitHubResolver struct{}
+
+var gitHubReservedOwners = map[string]bool{
+	"about": true, "apps": true, "collections": true, "explore": true, "features": true, "issues": true,
+	"login": true, "marketplace": true, "notifications": true, "orgs": true, "pricing": true, "pulls": true,
+	"search": true, "settings": true, "sponsors": true, "topics": true, "trending": true,
+}
+
+func (gitHubResolver) Name() string { return "GitHub" }
+
+func (gitHubResolver) Resolve(_ context.Context, u *url.URL, _ ResolverEnv) (string, bool, error) {
+	host := strings.ToLower(u.Host)
+	if host != "github.com" && host != "www.github.com" {
+		return "", false, nil
+	}
+	segments := pathSegments(u)
+	if len(segments) == 0 || gitHubReservedOwners[strings.ToLower(segments[0])] || strings.HasSuffix(u.Path, ".atom") {
+		return "", false, nil
+	}
+
+	const base = "https://github.com/"
+	if len(segments) == 1 {
+		return base + segments[0] + ".atom", true, nil
+	}
+	repo := base + segments[0] + "/" + strings.TrimSuffix(segments[1], ".git")
+	if len(segments) == 2 {
+		return repo + "/releases.atom", true, nil
+	}
+	switch segments[2] {
+	case "releases":
+		return repo + "/releases.atom", true, nil
+	case "tags":
+		return repo + "/tags.atom", true, nil
+	case "commits":
+		if len(segments) > 3 {
+			return repo + "/commits/" + strings.Join(segments[3:], "/") + ".atom", true, nil
+		}
+		return repo + "/commits.atom", true, nil
+	}
+	return "", false, nil
+}
+
+// bilibiliResolver resolves Bilibili user spaces and live rooms to RSSHub routes,
+// since Bilibili has no feeds of its own.
+type bilibiliResolver struct{}
+
+var bilibiliIDPattern = regexp.MustCompile(`^\d+$`)
+
+func (bilibiliResolver) Name() string { return "Bilibili" }
+
+func (bilibiliResolver) Resolve(_ context.Context, u *url.URL, env ResolverEnv) (string, bool, error) {
+	host := strings.ToLower(u.Host)
+	segments := pathSegments(u)
+	if len(segments) == 0 || !bilibiliIDPattern.MatchString(segments[0]) {
+		return "", false, nil
+	}
+	switch {
+	case host == "space.bilibili.com":
+		if len(segments) > 1 && segments[1] == "dynamic" {
+			return env.RSSHub + "/bilibili/user/dynamic/" + segments[0], true, nil
+		}
+		return env.RSSHub + "/bilibili/user/video/" + segments[0], true, nil
+	case host == "live.bilibili.com":
+		return env.RSSHub + "/bilibili/live/room/" + segments[0], true, nil
+	}
+	return "", false, nil
+}
+
+// mastodonResolver resolves profile URLs (/@user, /@user@instance) on Mastodon and compatible
+// servers to the profile's RSS feed. Instances can be on any host, so it only looks at the path.
+type mastodonResolver struct{}
+
+var mastodonProfilePattern = regexp.MustCompile(`^@([A-Za-z0-9_.-]+)(?:@([A-Za-z0-9.-]+\.[A-Za-z]{2,}))?$`)
+
+func (mastodonResolver) Name() string { return "Mastodon" }
+
+func (mastodonResolver) Resolve(_ context.Context, u *url.URL, _ ResolverEnv) (string, bool, error) {
+	segments := pathSegments(u)
+	// Medium and YouTube use the same profile URL shape
+	if len(segments) != 1 || hostIs(u.Host, "medium.com") || hostIs(u.Host, "youtube.com") || strings.HasSuffix(segments[0], ".rss") {
+		return "", false, nil
+	}
+	m := mastodonProfilePattern.FindStringSubmatch(segments[0])
+	if m == nil {
+		return "", false, nil
+	}
+	host := u.Host
+	if m[2] != "" {
+		// A remote account viewed through another instance
+		host = m[2]
+	}
+	return "https://" + host + "/@" + m[1] + ".rss", true, nil
+}
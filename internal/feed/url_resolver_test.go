@@ -0,0 +1,140 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestURLResolvers(t *testing.T) {
+	env := ResolverEnv{RSSHub: "https://rsshub.example"}
+	tests := []struct {
+		in   string
+		want string // empty when no resolver should match
+	}{
+		{"https://www.youtube.com/channel/UCabcdefghijklmnopqrstuv", "https://www.youtube.com/feeds/videos.xml?channel_id=UCabcdefghijklmnopqrstuv"},
+		{"https://youtube.com/channel/UCabcdefghijklmnopqrstuv/videos", "https://www.youtube.com/feeds/videos.xml?channel_id=UCabcdefghijklmnopqrstuv"},
+		{"https://www.youtube.com/user/golang", "https://www.youtube.com/feeds/videos.xml?user=golang"},
+		{"https://www.youtube.com/playlist?list=PL123", "https://www.youtube.com/feeds/videos.xml?playlist_id=PL123"},
+		{"https://www.youtube.com/feeds/videos.xml?channel_id=UC1", ""},
+		{"https://www.reddit.com/r/golang", "https://www.reddit.com/r/golang/.rss"},
+		{"https://old.reddit.com/r/golang/top/?t=week", "https://www.reddit.com/r/golang/top/.rss?t=week"},
+		{"https://reddit.com/u/spez", "https://www.reddit.com/user/spez/.rss"},
+		{"https://www.reddit.com/r/golang/.rss", ""},
+		{"https://github.com/golang/go", "https://github.com/golang/go/releases.atom"},
+		{"https://github.com/golang/go.git", "https://github.com/golang/go/releases.atom"},
+		{"https://github.com/golang/go/tags", "https://github.com/golang/go/tags.atom"},
+		{"https://github.com/golang/go/commits/master", "https://github.com/golang/go/commits/master.atom"},
+		{"https://github.com/torvalds", "https://github.com/torvalds.atom"},
+		{"https://github.com/golang/go/issues", ""},
+		{"https://github.com/trending", ""},
+		{"https://space.bilibili.com/2267573", "https://rsshub.example/bilibili/user/video/2267573"},
+		{"https://space.bilibili.com/2267573/dynamic", "https://rsshub.example/bilibili/user/dynamic/2267573"},
+		{"https://live.bilibili.com/21452505", "https://rsshub.example/bilibili/live/room/21452505"},
+		{"https://www.bilibili.com/video/BV1xx", ""},
+		{"https://mastodon.social/@Gargron", "https://mastodon.social/@Gargron.rss"},
+		{"https://mastodon.social/@alice@fosstodon.org", "https://fosstodon.org/@alice.rss"},
+		{"https://mastodon.social/@Gargron/110000", ""},
+		{"https://medium.com/@someone", ""},
+		{"https://example.com/blog", ""},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.in)
+		var got string
+		for _, r := range urlResolvers {
+			feedURL, ok, err := r.Resolve(context.Background(), u, env)
+			if err != nil {
+				t.Errorf("%s: %s error: %v", tt.in, r.Name(), err)
+			}
+			if ok {
+				got = feedURL
+				break
+			}
+		}
+		if got != tt.want {
+			t.Errorf("resolve(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestYouTubeResolver_Handle(t *testing.T) {
+	var requested string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requested = r.URL.String()
+		page := `<html><head><link rel="canonical" href="https://www.youtube.com/channel/UC_x5XG1OV2P6uZZ5FSM9Ttw"></head></html>`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(page)), Header: http.Header{}}, nil
+	})}
+
+	u, _ := url.Parse("https://www.youtube.com/@GoogleDevelopers/videos")
+	got, ok, err := youTubeResolver{}.Resolve(context.Background(), u, ResolverEnv{Client: client})
+	if err != nil || !ok {
+		t.Fatalf("Resolve = %q, %v, %v", got, ok, err)
+	}
+	if requested != "https://www.youtube.com/@GoogleDevelopers" {
+		t.Errorf("fetched %s", requested)
+	}
+	if got != "https://www.youtube.com/feeds/videos.xml?channel_id=UC_x5XG1OV2P6uZZ5FSM9Ttw" {
+		t.Errorf("Resolve = %q", got)
+	}
+}
+
+// urlParser parses only the URLs it has feeds for.
+type urlParser map[string]*gofeed.Feed
+
+func (p urlParser) ParseURL(feedURL string) (*gofeed.Feed, error) {
+	if feed, ok := p[feedURL]; ok {
+		return feed, nil
+	}
+	return nil, errors.New("Failed to detect feed type")
+}
+
+func (p urlParser) ParseURLWithContext(feedURL string, _ context.Context) (*gofeed.Feed, error) {
+	return p.ParseURL(feedURL)
+}
+
+type staticFinder map[string]string
+
+func (s staticFinder) FindFeed(_ context.Context, pageURL string) (string, error) {
+	if feedURL, ok := s[pageURL]; ok {
+		return feedURL, nil
+	}
+	return "", errors.New("RSS feed not found")
+}
+
+func TestAddSubscription_ResolvesAndDiscoversFeeds(t *testing.T) {
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	f.fp = urlParser{
+		"https://github.com/golang/go/releases.atom": {Title: "Release notes from go"},
+		"https://example.com/feed.xml":               {Title: "Example"},
+	}
+	f.feedFinder = staticFinder{"https://example.com/@alice": "https://example.com/feed.xml"}
+
+	id, err := f.AddSubscription("https://github.com/golang/go", "", "")
+	if err != nil {
+		t.Fatalf("AddSubscription error: %v", err)
+	}
+	if feed, _ := db.GetFeedByID(id); feed.URL != "https://github.com/golang/go/releases.atom" || feed.Title != "Release notes from go" {
+		t.Errorf("resolved feed = %q %q", feed.URL, feed.Title)
+	}
+
+	// Looks like a Mastodon profile, but the guessed feed does not exist
+	id, err = f.AddSubscription("https://example.com/@alice", "", "")
+	if err != nil {
+		t.Fatalf("AddSubscription error: %v", err)
+	}
+	if feed, _ := db.GetFeedByID(id); feed.URL != "https://example.com/feed.xml" {
+		t.Errorf("discovered feed URL = %q", feed.URL)
+	}
+}
@@ -0,0 +1,336 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+const (
+	// Lease requested from hubs; hubs may grant a different one
+	WebSubLeaseSeconds = 10 * 24 * 60 * 60
+	// Leases are renewed when they expire within this window
+	WebSubRenewBefore = 24 * time.Hour
+	// Feeds with an active lease are still polled, but at most this often
+	WebSubPollInterval = 12 * time.Hour
+	// A pending or denied subscription is not requested again before this has passed
+	webSubRetryAfter = time.Hour
+	// WebSubCallbackPath is the path prefix of the callback endpoint, followed by the feed ID
+	// and the subscription's callback token
+	WebSubCallbackPath = "/api/websub/callback/"
+)
+
+// discoverWebSubLinks returns the hub and self URLs advertised by a feed, either in
+// HTTP Link headers (preferred, as the WebSub spec says) or as <link rel="hub"> / <atom:link rel="hub">
+// elements at feed level.
+func discoverWebSubLinks(header http.Header, body []byte) (hub, self string) {
+	for _, value := range header.Values("Link") {
+		for _, part := range strings.Split(value, ",") {
+			start, end := strings.Index(part, "<"), strings.Index(part, ">")
+			if start < 0 || end < start {
+				continue
+			}
+			href := strings.TrimSpace(part[start+1 : end])
+			for _, param := range strings.Split(part[end+1:], ";") {
+				name, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					if strings.EqualFold(rel, "hub") && hub == "" {
+						hub = href
+					} else if strings.EqualFold(rel, "self") && self == "" {
+						self = href
+					}
+				}
+			}
+		}
+	}
+	if hub != "" {
+		return hub, self
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	// Only ASCII attributes are of interest, so any declared charset can be read as is
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		// Links inside items describe the items, not the feed
+		if start.Name.Local == "item" || start.Name.Local == "entry" {
+			break
+		}
+		if start.Name.Local != "link" {
+			continue
+		}
+		var rel, href string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "rel":
+				rel = attr.Value
+			case "href":
+				href = strings.TrimSpace(attr.Value)
+			}
+		}
+		for _, r := range strings.Fields(rel) {
+			if r == "hub" && hub == "" {
+				hub = href
+			} else if r == "self" && self == "" {
+				self = href
+			}
+		}
+	}
+	if hub == "" {
+		return "", ""
+	}
+	return hub, self
+}
+
+// VerifyWebSubSignature checks the X-Hub-Signature header ("method=hexdigest") of pushed content.
+func VerifyWebSubSignature(secret, signature string, body []byte) bool {
+	method, digest, ok := strings.Cut(signature, "=")
+	if !ok {
+		return false
+	}
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// WebSubCallbackURL returns the callback URL hubs deliver updates of a feed to.
+func WebSubCallbackURL(publicURL string, feedID int64, token string) string {
+	return strings.TrimRight(publicURL, "/") + WebSubCallbackPath + strconv.FormatInt(feedID, 10) + "/" + token
+}
+
+// ParseWebSubCallbackPath returns the feed ID and callback token of a callback URL path.
+func ParseWebSubCallbackPath(path string) (feedID int64, token string, ok bool) {
+	id, token, found := strings.Cut(strings.TrimPrefix(path, WebSubCallbackPath), "/")
+	if !found || token == "" {
+		return 0, "", false
+	}
+	feedID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return feedID, token, true
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// webSubPublicURL returns the public base URL of this server if WebSub is enabled, "" otherwise.
+// Hubs must be able to reach the callback, so WebSub is only used in server mode.
+func (f *Fetcher) webSubPublicURL() string {
+	if !utils.IsServerMode() {
+		return ""
+	}
+	if enabled, _ := f.db.GetSetting("websub_enabled"); enabled != "true" {
+		return ""
+	}
+	publicURL, _ := f.db.GetSetting("websub_public_url")
+	return strings.TrimSpace(publicURL)
+}
+
+// ensureWebSubSubscription subscribes to the hub a feed advertises unless a
+// subscription is already active, pending or was recently refused.
+func (f *Fetcher) ensureWebSubSubscription(ctx context.Context, feed models.Feed, info *fetchInfo) {
+	if info == nil || info.Hub == "" {
+		return
+	}
+	publicURL := f.webSubPublicURL()
+	if publicURL == "" {
+		return
+	}
+	topic := info.Self
+	if topic == "" {
+		topic = feed.URL
+	}
+
+	sub, err := f.db.GetWebSubSubscription(feed.ID)
+	if err != nil {
+		log.Printf("Error getting WebSub subscription of feed %s: %v", feed.Title, err)
+		return
+	}
+	// Subscriptions from before callback tokens are requested again with one
+	if sub != nil && sub.HubURL == info.Hub && sub.TopicURL == topic && sub.CallbackToken != "" {
+		switch sub.State {
+		case models.WebSubStateActive:
+			if time.Until(sub.LeaseExpiresAt) > WebSubRenewBefore {
+				return
+			}
+		case models.WebSubStatePending, models.WebSubStateDenied:
+			if time.Since(sub.UpdatedAt) < webSubRetryAfter {
+				return
+			}
+		}
+	}
+
+	if err := f.subscribeWebSub(ctx, feed, sub, info.Hub, topic, publicURL); err != nil {
+		log.Printf("Error subscribing feed %s at WebSub hub %s: %v", feed.Title, info.Hub, err)
+	}
+}
+
+// subscribeWebSub sends a subscription request to a hub. The hub confirms it
+// asynchronously by calling the callback endpoint.
+func (f *Fetcher) subscribeWebSub(ctx context.Context, feed models.Feed, existing *models.WebSubSubscription, hub, topic, publicURL string) error {
+	// Keep the secret and callback when renewing so content pushed meanwhile still verifies
+	secret, token := "", ""
+	if existing != nil && existing.HubURL == hub {
+		secret, token = existing.Secret, existing.CallbackToken
+	}
+	var err error
+	if secret == "" {
+		if secret, err = randomHex(32); err != nil {
+			return err
+		}
+	}
+	if token == "" {
+		if token, err = randomHex(16); err != nil {
+			return err
+		}
+	}
+
+	// Store the request first: the hub may verify it before answering us
+	if err := f.db.SaveWebSubSubscriptionRequest(feed.ID, hub, topic, secret, token); err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {topic},
+		"hub.callback":      {WebSubCallbackURL(publicURL, feed.ID, token)},
+		"hub.secret":        {secret},
+		"hub.lease_seconds": {strconv.Itoa(WebSubLeaseSeconds)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client, err := f.getHTTPClient(feed)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		f.db.DenyWebSubSubscription(feed.ID, err.Error())
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		reason := fmt.Sprintf("hub answered %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		f.db.DenyWebSubSubscription(feed.ID, reason)
+		return fmt.Errorf("%s", reason)
+	}
+	utils.DebugLog("Requested WebSub subscription for feed %s at %s", feed.Title, hub)
+	return nil
+}
+
+// RenewWebSubLeases re-subscribes active subscriptions whose lease is about to expire.
+func (f *Fetcher) RenewWebSubLeases(ctx context.Context) {
+	publicURL := f.webSubPublicURL()
+	if publicURL == "" {
+		return
+	}
+	subs, err := f.db.GetWebSubSubscriptions()
+	if err != nil {
+		log.Printf("Error getting WebSub subscriptions: %v", err)
+		return
+	}
+	for _, sub := range subs {
+		if sub.State != models.WebSubStateActive || time.Until(sub.LeaseExpiresAt) > WebSubRenewBefore {
+			continue
+		}
+		feed, err := f.db.GetFeedByID(sub.FeedID)
+		if err != nil {
+			continue
+		}
+		if err := f.subscribeWebSub(ctx, *feed, &sub, sub.HubURL, sub.TopicURL, publicURL); err != nil {
+			log.Printf("Error renewing WebSub lease of feed %s: %v", feed.Title, err)
+		}
+	}
+}
+
+// StartWebSubRenewal renews expiring WebSub leases every hour until ctx is cancelled.
+func (f *Fetcher) StartWebSubRenewal(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		f.RenewWebSubLeases(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IngestPushedFeed stores the items of feed content pushed by a WebSub hub,
+// the same way as items fetched by polling. Returns the number of items processed.
+func (f *Fetcher) IngestPushedFeed(ctx context.Context, feed models.Feed, body []byte) (int, error) {
+	parsedFeed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	articles := f.processArticles(feed, parsedFeed.Items)
+	if !f.saveFeedArticles(ctx, feed, articles) {
+		return 0, fmt.Errorf("saving pushed articles failed")
+	}
+	if err := f.db.MarkWebSubPush(feed.ID); err != nil {
+		log.Printf("Error recording WebSub push for feed %s: %v", feed.Title, err)
+	}
+	return len(articles), nil
+}
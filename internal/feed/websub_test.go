@@ -0,0 +1,77 @@
+package feed
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+)
+
+func TestDiscoverWebSubLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		body     string
+		wantHub  string
+		wantSelf string
+	}{
+		{
+			name:     "link header",
+			header:   http.Header{"Link": {`<https://hub.example.com/>; rel="hub", <https://example.com/feed>; rel="self"`}},
+			body:     `<rss><channel><title>t</title></channel></rss>`,
+			wantHub:  "https://hub.example.com/",
+			wantSelf: "https://example.com/feed",
+		},
+		{
+			name:     "atom link in rss",
+			body:     `<?xml version="1.0" encoding="ISO-8859-1"?><rss xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>t</title><atom:link rel="self" href="https://example.com/rss"/><atom:link rel="hub" href="https://pubsubhubbub.appspot.com/"/></channel></rss>`,
+			wantHub:  "https://pubsubhubbub.appspot.com/",
+			wantSelf: "https://example.com/rss",
+		},
+		{
+			name:    "atom feed",
+			body:    `<feed xmlns="http://www.w3.org/2005/Atom"><title>t</title><link rel="alternate" href="https://example.com/"/><link rel="hub" href="https://hub.example.com/"/></feed>`,
+			wantHub: "https://hub.example.com/",
+		},
+		{
+			name: "hub link inside entry is ignored",
+			body: `<feed xmlns="http://www.w3.org/2005/Atom"><title>t</title><entry><link rel="hub" href="https://hub.example.com/"/></entry></feed>`,
+		},
+		{
+			name: "self without hub",
+			body: `<feed xmlns="http://www.w3.org/2005/Atom"><link rel="self" href="https://example.com/feed"/></feed>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, self := discoverWebSubLinks(tt.header, []byte(tt.body))
+			if hub != tt.wantHub || self != tt.wantSelf {
+				t.Errorf("discoverWebSubLinks() = (%q, %q), want (%q, %q)", hub, self, tt.wantHub, tt.wantSelf)
+			}
+		})
+	}
+}
+
+func TestVerifyWebSubSignature(t *testing.T) {
+	body := []byte("<feed/>")
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	valid := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !VerifyWebSubSignature("secret", valid, body) {
+		t.Error("expected valid signature to verify")
+	}
+	if VerifyWebSubSignature("other", valid, body) {
+		t.Error("expected signature with wrong secret to fail")
+	}
+	if VerifyWebSubSignature("secret", valid, []byte("<feed>changed</feed>")) {
+		t.Error("expected signature over different body to fail")
+	}
+	for _, sig := range []string{"", "sha256", "md5=abcd", "sha256=zz"} {
+		if VerifyWebSubSignature("secret", sig, body) {
+			t.Errorf("expected malformed signature %q to fail", sig)
+		}
+	}
+}
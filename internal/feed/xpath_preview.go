@@ -0,0 +1,157 @@
+package feed
+
+import (
+	"MrRSS/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+)
+
+// Items returned by a preview; the count of all extracted items is reported separately
+const maxXPathPreviewItems = 20
+
+// xpathWarnFunc receives warnings about a misconfigured field of an extracted item. A nil func ignores them.
+type xpathWarnFunc func(field, message string)
+
+func (w xpathWarnFunc) add(field, message string) {
+	if w != nil {
+		w(field, message)
+	}
+}
+
+// XPathPreview is the result of a dry run of an XPath feed configuration.
+type XPathPreview struct {
+	TotalItems int                `json:"total_items"`
+	Items      []XPathPreviewItem `json:"items"`
+	Warnings   []XPathWarning     `json:"warnings"`
+}
+
+// XPathPreviewItem holds the fields extracted for one item, named like the XPath fields of models.Feed.
+type XPathPreviewItem struct {
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	URI        string     `json:"uri"`
+	Author     string     `json:"author"`
+	Timestamp  *time.Time `json:"timestamp"`
+	Thumbnail  string     `json:"thumbnail"`
+	Categories []string   `json:"categories"`
+	UID        string     `json:"uid"`
+}
+
+// XPathWarning reports a field that looks misconfigured. Item is the index of the item, or -1 for the whole configuration.
+type XPathWarning struct {
+	Item    int    `json:"item"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateXPathFeed checks the feed type and that all configured XPath expressions compile.
+func ValidateXPathFeed(feed models.Feed) error {
+	if feed.Type != "HTML+XPath" && feed.Type != "XML+XPath" {
+		return fmt.Errorf("unsupported feed type: %s", feed.Type)
+	}
+	if feed.XPathItem == "" {
+		return fmt.Errorf("XPath item expression is required for XPath-based feeds")
+	}
+
+	htmlDoc, _ := htmlquery.Parse(strings.NewReader(""))
+	xmlDoc, _ := xmlquery.Parse(strings.NewReader("<root/>"))
+	for _, field := range xpathFields(feed) {
+		if field.expr == "" {
+			continue
+		}
+		var err error
+		if feed.Type == "HTML+XPath" {
+			_, err = htmlquery.QueryAll(htmlDoc, field.expr)
+		} else {
+			_, err = xmlquery.QueryAll(xmlDoc, field.expr)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid XPath for %s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+type xpathField struct {
+	name string
+	expr string
+}
+
+// xpathFields lists the XPath expressions of a feed with their JSON field names.
+func xpathFields(feed models.Feed) []xpathField {
+	return []xpathField{
+		{"xpath_item", feed.XPathItem},
+		{"xpath_item_title", feed.XPathItemTitle},
+		{"xpath_item_content", feed.XPathItemContent},
+		{"xpath_item_uri", feed.XPathItemUri},
+		{"xpath_item_author", feed.XPathItemAuthor},
+		{"xpath_item_timestamp", feed.XPathItemTimestamp},
+		{"xpath_item_thumbnail", feed.XPathItemThumbnail},
+		{"xpath_item_categories", feed.XPathItemCategories},
+		{"xpath_item_uid", feed.XPathItemUid},
+	}
+}
+
+// PreviewXPathFeed fetches the page of an XPath feed configuration and extracts its items
+// the same way a refresh would, without saving anything.
+func (f *Fetcher) PreviewXPathFeed(ctx context.Context, feed models.Feed, opts models.FeedRequestOptions) (*XPathPreview, error) {
+	if err := ValidateXPathFeed(feed); err != nil {
+		return nil, err
+	}
+
+	// The configuration is not stored, so its request options are given directly
+	feed.ID = 0
+	client, err := f.getHTTPClient(feed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	if client, err = withRequestOptions(client, feed.URL, opts); err != nil {
+		return nil, err
+	}
+	body, err := fetchScrapedDocument(ctx, client, feed.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &XPathPreview{Items: []XPathPreviewItem{}, Warnings: []XPathWarning{}}
+	parsedFeed, err := f.parseXPathDocument(body, &feed, func(item int, field, message string) {
+		if item < maxXPathPreviewItems {
+			preview.Warnings = append(preview.Warnings, XPathWarning{Item: item, Field: field, Message: message})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	preview.TotalItems = len(parsedFeed.Items)
+	if preview.TotalItems == 0 {
+		preview.Warnings = append(preview.Warnings, XPathWarning{Item: -1, Field: "xpath_item", Message: "expression matched no items"})
+	}
+
+	for _, item := range parsedFeed.Items[:min(len(parsedFeed.Items), maxXPathPreviewItems)] {
+		previewItem := XPathPreviewItem{
+			Title:      item.Title,
+			Content:    item.Content,
+			URI:        item.Link,
+			Timestamp:  item.PublishedParsed,
+			Categories: item.Categories,
+			UID:        item.GUID,
+		}
+		if item.Author != nil {
+			previewItem.Author = item.Author.Name
+		}
+		if item.Image != nil {
+			previewItem.Thumbnail = item.Image.URL
+		}
+		if previewItem.Categories == nil {
+			previewItem.Categories = []string{}
+		}
+		preview.Items = append(preview.Items, previewItem)
+	}
+	return preview, nil
+}
@@ -2,12 +2,17 @@ package feed
 
 import (
 	"MrRSS/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/antchfx/htmlquery"
 	"github.com/antchfx/xmlquery"
+	"github.com/mmcdole/gofeed"
 )
 
 func TestParseFeedWithXPath_HTML(t *testing.T) {
@@ -60,7 +65,7 @@ func TestParseFeedWithXPath_HTML(t *testing.T) {
 	}
 
 	// Extract item
-	item := fetcher.extractItemFromHTMLNode(articleNode, feed)
+	item := fetcher.extractItemFromHTMLNode(articleNode, feed, nil)
 
 	// Verify extraction
 	if item.Title != "Test Article" {
@@ -150,7 +155,7 @@ func TestParseFeedWithXPath_XML(t *testing.T) {
 	}
 
 	// Extract item
-	item := fetcher.extractItemFromXMLNode(itemNode, feed)
+	item := fetcher.extractItemFromXMLNode(itemNode, feed, nil)
 
 	// Verify extraction
 	if item.Title != "Test Article" {
@@ -190,3 +195,112 @@ func TestParseFeedWithXPath_XML(t *testing.T) {
 		t.Errorf("Expected GUID '12345', got '%s'", item.GUID)
 	}
 }
+
+func TestPreviewXPathFeed_Warnings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><body>
+			<div class="post"><h2>First</h2><a href="/posts/1">more</a><time>2024-03-01</time></div>
+			<div class="post"><h2></h2><a href="https://example.com/2">more</a><time>yesterday</time></div>
+		</body></html>`))
+	}))
+	defer srv.Close()
+
+	db := setupDBForFeedTests(t)
+	f := NewFetcher(db, nil)
+	feed := models.Feed{
+		URL:                 srv.URL + "/blog",
+		Type:                "HTML+XPath",
+		XPathItem:           "//div[@class='post']",
+		XPathItemTitle:      ".//h2",
+		XPathItemUri:        ".//a/@href",
+		XPathItemTimestamp:  ".//time",
+		XPathItemTimeFormat: "2006-01-02",
+	}
+
+	preview, err := f.PreviewXPathFeed(context.Background(), feed, models.FeedRequestOptions{})
+	if err != nil {
+		t.Fatalf("PreviewXPathFeed error: %v", err)
+	}
+	if preview.TotalItems != 2 || len(preview.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d (%d returned)", preview.TotalItems, len(preview.Items))
+	}
+	if preview.Items[0].URI != srv.URL+"/posts/1" || preview.Items[0].Timestamp == nil {
+		t.Errorf("unexpected first item: %+v", preview.Items[0])
+	}
+
+	want := map[XPathWarning]bool{
+		{Item: 0, Field: "xpath_item_uri", Message: `relative URL "/posts/1" resolved against the feed URL`}:        true,
+		{Item: 1, Field: "xpath_item_title", Message: "title is empty"}:                                             true,
+		{Item: 1, Field: "xpath_item_timestamp", Message: `cannot parse "yesterday" with time format "2006-01-02"`}: true,
+	}
+	for _, w := range preview.Warnings {
+		if !want[w] {
+			t.Errorf("unexpected warning: %+v", w)
+		}
+		delete(want, w)
+	}
+	for w := range want {
+		t.Errorf("missing warning: %+v", w)
+	}
+
+	// Nothing is stored by a preview
+	feeds, _ := db.GetFeeds()
+	if len(feeds) != 0 {
+		t.Errorf("preview stored %d feeds", len(feeds))
+	}
+}
+
+func TestFinalizeScrapedItem_PlaceholderLinks(t *testing.T) {
+	feed := &models.Feed{URL: "https://example.com/news"}
+	baseURL, _ := url.Parse(feed.URL)
+
+	// Titles of the same length must not share a placeholder link
+	first := &gofeed.Item{Title: "Outage A"}
+	second := &gofeed.Item{Title: "Outage B"}
+	var warnings []string
+	warn := xpathWarnFunc(func(field, message string) { warnings = append(warnings, field+": "+message) })
+	finalizeScrapedItem(first, feed, baseURL, warn, "xpath")
+	finalizeScrapedItem(second, feed, baseURL, nil, "xpath")
+	if first.Link == second.Link {
+		t.Errorf("expected distinct placeholder links, both got %s", first.Link)
+	}
+	if !strings.HasPrefix(first.Link, feed.URL+"#xpath-") || first.GUID != first.Link {
+		t.Errorf("unexpected placeholder link %q / GUID %q", first.Link, first.GUID)
+	}
+	if len(warnings) != 1 || warnings[0] != "xpath_item_uri: no link found, a placeholder link is used" {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	// The same item gets the same link on every fetch
+	again := &gofeed.Item{Title: "Outage A"}
+	finalizeScrapedItem(again, feed, baseURL, nil, "xpath")
+	if again.Link != first.Link {
+		t.Errorf("expected a stable placeholder link, got %s and %s", first.Link, again.Link)
+	}
+
+	relative := &gofeed.Item{Title: "Relative", Link: "/posts/1", GUID: "post-1"}
+	finalizeScrapedItem(relative, feed, baseURL, nil, "css")
+	if relative.Link != "https://example.com/posts/1" || relative.GUID != "post-1" {
+		t.Errorf("unexpected resolved item: link %q GUID %q", relative.Link, relative.GUID)
+	}
+}
+
+func TestValidateXPathFeed(t *testing.T) {
+	valid := models.Feed{Type: "XML+XPath", XPathItem: "//item", XPathItemTitle: "title"}
+	if err := ValidateXPathFeed(valid); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+
+	invalid := valid
+	invalid.XPathItemTitle = "title[("
+	if err := ValidateXPathFeed(invalid); err == nil || !strings.Contains(err.Error(), "xpath_item_title") {
+		t.Errorf("expected error naming xpath_item_title, got %v", err)
+	}
+
+	wrongType := valid
+	wrongType.Type = "RSS"
+	if err := ValidateXPathFeed(wrongType); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
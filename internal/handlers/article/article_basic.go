@@ -43,6 +43,15 @@ func HandleArticles(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := h.DB.AttachEnclosures(articles); err != nil {
+		log.Printf("Error loading article enclosures: %v", err)
+	}
+	if err := h.DB.AttachPodcastEpisodes(articles); err != nil {
+		log.Printf("Error loading podcast episodes: %v", err)
+	}
+	if err := h.DB.AttachFullTextStatus(articles); err != nil {
+		log.Printf("Error loading full-text status: %v", err)
+	}
 	json.NewEncoder(w).Encode(articles)
 }
 
@@ -155,5 +164,14 @@ func HandleImageGalleryArticles(h *core.Handler, w http.ResponseWriter, r *http.
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := h.DB.AttachEnclosures(articles); err != nil {
+		log.Printf("Error loading article enclosures: %v", err)
+	}
+	if err := h.DB.AttachPodcastEpisodes(articles); err != nil {
+		log.Printf("Error loading podcast episodes: %v", err)
+	}
+	if err := h.DB.AttachFullTextStatus(articles); err != nil {
+		log.Printf("Error loading full-text status: %v", err)
+	}
 	json.NewEncoder(w).Encode(articles)
 }
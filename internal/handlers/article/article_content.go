@@ -9,7 +9,7 @@ import (
 	"MrRSS/internal/handlers/core"
 )
 
-// HandleGetArticleContent fetches the article content from RSS feed dynamically.
+// HandleGetArticleContent returns the article content, preferring the copy stored at fetch time.
 func HandleGetArticleContent(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -36,7 +36,7 @@ func HandleGetArticleContent(h *core.Handler, w http.ResponseWriter, r *http.Req
 	})
 }
 
-// HandleFetchFullArticle fetches the full article content from the original URL using readability.
+// HandleFetchFullArticle fetches the full article content from the original URL, using the site's extraction rule if it has one.
 func HandleFetchFullArticle(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -71,7 +71,7 @@ func HandleFetchFullArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 	}
 
 	// Fetch full content
-	fullContent, err := h.FetchFullArticleContent(article.URL)
+	fullContent, err := h.FetchFullArticleContent(article.FeedID, article.URL)
 	if err != nil {
 		log.Printf("Error fetching full article content: %v", err)
 		http.Error(w, "Failed to fetch full article content", http.StatusInternalServerError)
@@ -0,0 +1,36 @@
+package article
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"MrRSS/internal/handlers/core"
+)
+
+// HandleArticleCopies lists the feeds that carried the same story as an article,
+// with the canonical article first.
+func HandleArticleCopies(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	articleID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+
+	copies, err := h.DB.GetArticleCopies(articleID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Article not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(copies)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"MrRSS/internal/models"
+	"MrRSS/internal/rules"
 )
 
 // FilterCondition represents a single filter condition from the frontend
@@ -13,7 +14,7 @@ type FilterCondition struct {
 	ID       int64    `json:"id"`
 	Logic    string   `json:"logic"`    // "and", "or" (null for first condition)
 	Negate   bool     `json:"negate"`   // NOT modifier for this condition
-	Field    string   `json:"field"`    // "feed_name", "feed_category", "article_title", "published_after", "published_before"
+	Field    string   `json:"field"`    // "feed_name", "feed_category", "article_title", "article_author", "article_category", "published_after", "published_before"
 	Operator string   `json:"operator"` // "contains", "exact" (null for date fields and multi-select)
 	Value    string   `json:"value"`    // Single value for text/date fields
 	Values   []string `json:"values"`   // Multiple values for feed_name and feed_category
@@ -99,6 +100,13 @@ func evaluateSingleCondition(article models.Article, condition FilterCondition,
 			}
 		}
 
+	case "article_author":
+		result = rules.MatchText(article.Author, condition.Operator, condition.Value)
+
+	case "article_category":
+		// Matches when any of the article's own categories matches
+		result = rules.MatchCategories(article.Categories, condition.Operator, condition.Values, condition.Value)
+
 	case "published_after":
 		if condition.Value == "" {
 			result = true
@@ -2,6 +2,7 @@ package article
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 
 	"MrRSS/internal/handlers/core"
@@ -93,6 +94,16 @@ func HandleFilteredArticles(h *core.Handler, w http.ResponseWriter, r *http.Requ
 
 	hasMore := end < total
 
+	if err := h.DB.AttachEnclosures(paginatedArticles); err != nil {
+		log.Printf("Error loading article enclosures: %v", err)
+	}
+	if err := h.DB.AttachPodcastEpisodes(paginatedArticles); err != nil {
+		log.Printf("Error loading podcast episodes: %v", err)
+	}
+	if err := h.DB.AttachFullTextStatus(paginatedArticles); err != nil {
+		log.Printf("Error loading full-text status: %v", err)
+	}
+
 	response := FilterResponse{
 		Articles: paginatedArticles,
 		Total:    total,
@@ -198,3 +198,88 @@ func TestHandleExportToObsidian(t *testing.T) {
 		t.Fatalf("Export not successful: %v", response)
 	}
 }
+
+func TestHandleArticleCopies(t *testing.T) {
+	h := setupHandler(t)
+
+	blogID, _ := h.DB.AddFeed(&models.Feed{Title: "Blog", URL: "http://blog"})
+	aggID, _ := h.DB.AddFeed(&models.Feed{Title: "Aggregator", URL: "http://agg"})
+	original := &models.Article{FeedID: blogID, Title: "Story", URL: "http://blog/story", PublishedAt: time.Now()}
+	dup := &models.Article{FeedID: aggID, Title: "Story", URL: "http://blog/story?ref=agg", PublishedAt: time.Now()}
+	if err := h.DB.SaveArticles(context.Background(), []*models.Article{original, dup}); err != nil {
+		t.Fatalf("SaveArticles: %v", err)
+	}
+	if err := h.DB.SetArticleCanonical(dup.ID, original.ID); err != nil {
+		t.Fatalf("SetArticleCanonical: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	article.HandleArticleCopies(h, rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/articles/copies?id=%d", dup.ID), nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+	var copies []models.ArticleCopy
+	if err := json.NewDecoder(rr.Body).Decode(&copies); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(copies) != 2 || copies[0].ArticleID != original.ID || !copies[0].IsCanonical || copies[1].FeedTitle != "Aggregator" {
+		t.Errorf("copies = %+v", copies)
+	}
+
+	rr = httptest.NewRecorder()
+	article.HandleArticleCopies(h, rr, httptest.NewRequest(http.MethodGet, "/api/articles/copies?id=999", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown article: status %d, want 404", rr.Code)
+	}
+}
+
+func TestHandleArticleRevisionDiff(t *testing.T) {
+	h := setupHandler(t)
+
+	feedID, _ := h.DB.AddFeed(&models.Feed{Title: "Blog", URL: "http://blog"})
+	published := time.Now().Add(-time.Hour)
+	first := &models.Article{FeedID: feedID, GUID: "p1", Title: "Post", URL: "http://blog/p1", Content: "<p>Intro</p><p>Old claim</p>", PublishedAt: published}
+	if err := h.DB.SaveArticle(first); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+	revised := &models.Article{FeedID: feedID, GUID: "p1", Title: "Post", URL: "http://blog/p1", Content: "<p>Intro</p><p>Corrected claim</p>", PublishedAt: published}
+	if err := h.DB.SaveArticle(revised); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	article.HandleArticleRevisions(h, rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/articles/revisions?id=%d", first.ID), nil))
+	var revisions []models.ArticleRevision
+	if err := json.NewDecoder(rr.Body).Decode(&revisions); err != nil {
+		t.Fatalf("decode revisions: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("got %d revisions, want 2", len(revisions))
+	}
+
+	rr = httptest.NewRecorder()
+	article.HandleArticleRevisionDiff(h, rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/articles/revisions/diff?id=%d&format=text", first.ID), nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		From, To     int
+		TitleChanged bool `json:"title_changed"`
+		Diff         string
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode diff: %v", err)
+	}
+	if resp.From != 1 || resp.To != 2 || resp.TitleChanged {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Diff != "  Post\n  Intro\n- Old claim\n+ Corrected claim\n" {
+		t.Errorf("diff = %q", resp.Diff)
+	}
+
+	rr = httptest.NewRecorder()
+	article.HandleArticleRevisionDiff(h, rr, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/articles/revisions/diff?id=%d&from=1&to=7", first.ID), nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown revision: status %d, want 404", rr.Code)
+	}
+}
@@ -0,0 +1,130 @@
+package article
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/utils"
+)
+
+// HandleArticleRevisions lists the revisions of an article, oldest first.
+// Articles whose feed item never changed have none.
+func HandleArticleRevisions(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	articleID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.DB.GetArticleRevisions(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// HandleArticleRevisionDiff returns the difference between two revisions of an article
+// (?id=...&from=1&to=2&format=html|text). Without from and to, the latest revision
+// is compared with the one before it.
+func HandleArticleRevisionDiff(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	articleID, err := strconv.ParseInt(query.Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" && format != "text" {
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.DB.GetArticleRevisions(articleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(revisions) < 2 {
+		http.Error(w, "Article has no revisions", http.StatusNotFound)
+		return
+	}
+	to := revisions[len(revisions)-1].Revision
+	if v := query.Get("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid revision", http.StatusBadRequest)
+			return
+		}
+	}
+	from := to - 1
+	if v := query.Get("from"); v != "" {
+		if from, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid revision", http.StatusBadRequest)
+			return
+		}
+	}
+
+	oldRev, err := h.DB.GetArticleRevision(articleID, from)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	newRev, err := h.DB.GetArticleRevision(articleID, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if oldRev == nil || newRev == nil {
+		http.Error(w, "Revision not found", http.StatusNotFound)
+		return
+	}
+
+	// The title is compared as the first line of the text
+	oldLines := append([]string{oldRev.Title}, splitLines(utils.HTMLToText(oldRev.Content))...)
+	newLines := append([]string{newRev.Title}, splitLines(utils.HTMLToText(newRev.Content))...)
+	diff := utils.DiffLines(oldLines, newLines)
+
+	response := struct {
+		ArticleID    int64  `json:"article_id"`
+		From         int    `json:"from"`
+		To           int    `json:"to"`
+		Format       string `json:"format"`
+		TitleChanged bool   `json:"title_changed"`
+		Diff         string `json:"diff"`
+	}{
+		ArticleID:    articleID,
+		From:         from,
+		To:           to,
+		Format:       format,
+		TitleChanged: oldRev.Title != newRev.Title,
+	}
+	if format == "text" {
+		response.Diff = utils.FormatTextDiff(diff)
+	} else {
+		response.Diff = utils.FormatHTMLDiff(diff)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
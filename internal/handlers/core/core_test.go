@@ -1,10 +1,13 @@
 package core
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"MrRSS/internal/database"
 	"MrRSS/internal/feed"
+	"MrRSS/internal/models"
 )
 
 func TestNewHandler_ConstructsHandler(t *testing.T) {
@@ -29,3 +32,70 @@ func TestNewHandler_ConstructsHandler(t *testing.T) {
 		t.Fatal("DiscoveryService should be initialized")
 	}
 }
+
+func TestGetArticleContent_ServesStoredContent(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init failed: %v", err)
+	}
+
+	// The feed URL is unreachable, so any network fallback would fail
+	feedID, err := db.AddFeed(&models.Feed{Title: "Offline", URL: "http://127.0.0.1:0/feed.xml"})
+	if err != nil {
+		t.Fatalf("AddFeed failed: %v", err)
+	}
+	article := &models.Article{FeedID: feedID, Title: "Stored", URL: "http://127.0.0.1:0/a", Content: "<p>offline body</p>", PublishedAt: time.Now()}
+	if err := db.SaveArticles(context.Background(), []*models.Article{article}); err != nil {
+		t.Fatalf("SaveArticles failed: %v", err)
+	}
+	var articleID int64
+	if err := db.QueryRow(`SELECT id FROM articles WHERE url = ?`, article.URL).Scan(&articleID); err != nil {
+		t.Fatalf("lookup article id: %v", err)
+	}
+
+	h := NewHandler(db, feed.NewFetcher(db, nil), nil)
+	content, err := h.GetArticleContent(articleID)
+	if err != nil {
+		t.Fatalf("GetArticleContent failed: %v", err)
+	}
+	if content != "<p>offline body</p>" {
+		t.Fatalf("expected stored content, got %q", content)
+	}
+
+	// Later changes to the stored content, such as a fetched full text, are served right away
+	if err := db.SetFullTextContent(articleID, "<p>full text</p>"); err != nil {
+		t.Fatalf("SetFullTextContent failed: %v", err)
+	}
+	if content, _ := h.GetArticleContent(articleID); content != "<p>full text</p>" {
+		t.Fatalf("expected updated content, got %q", content)
+	}
+}
+
+func TestNextDueTime(t *testing.T) {
+	last := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	interval := 30 * time.Minute
+	hostUntil := last.Add(3 * time.Hour)
+	hosts := map[string]models.HostDeferral{"busy.example.com": {Host: "busy.example.com", Until: hostUntil}}
+
+	tests := []struct {
+		name string
+		feed models.Feed
+		want time.Time
+	}{
+		{"interval only", models.Feed{URL: "https://ok.example.com/feed", LastUpdated: last}, last.Add(interval)},
+		{"backoff", models.Feed{URL: "https://ok.example.com/feed", LastUpdated: last, NextRetryAt: last.Add(time.Hour)}, last.Add(time.Hour)},
+		{"server deferral", models.Feed{URL: "https://ok.example.com/feed", LastUpdated: last, DeferredUntil: last.Add(2 * time.Hour)}, last.Add(2 * time.Hour)},
+		{"expired deferral", models.Feed{URL: "https://ok.example.com/feed", LastUpdated: last, DeferredUntil: last.Add(time.Minute)}, last.Add(interval)},
+		{"host deferral", models.Feed{URL: "https://BUSY.example.com:8443/feed", LastUpdated: last}, hostUntil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDueTime(tt.feed, interval, hosts); !got.Equal(tt.want) {
+				t.Errorf("nextDueTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
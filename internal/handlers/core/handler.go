@@ -3,9 +3,10 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"log"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -16,11 +17,10 @@ import (
 	"MrRSS/internal/discovery"
 	"MrRSS/internal/feed"
 	"MrRSS/internal/models"
+	"MrRSS/internal/podcast"
 	"MrRSS/internal/translation"
 	"MrRSS/internal/utils"
 
-	"codeberg.org/readeck/go-readability/v2"
-
 	"github.com/mmcdole/gofeed"
 )
 
@@ -49,7 +49,8 @@ type Handler struct {
 	AITracker        *aiusage.Tracker
 	DiscoveryService *discovery.Service
 	App              interface{}         // Wails app instance for browser integration (interface{} to avoid import in server mode)
-	ContentCache     *cache.ContentCache // Cache for article content
+	ContentCache     *cache.ContentCache // Cache for parsed feeds used to recover article content
+	Episodes         *podcast.Downloader // Podcast episode download queue
 
 	// Discovery state tracking for polling-based progress
 	DiscoveryMu          sync.RWMutex
@@ -59,14 +60,16 @@ type Handler struct {
 
 // NewHandler creates a new Handler with the given dependencies.
 func NewHandler(db *database.DB, fetcher *feed.Fetcher, translator translation.Translator) *Handler {
-	return &Handler{
+	h := &Handler{
 		DB:               db,
 		Fetcher:          fetcher,
 		Translator:       translator,
 		AITracker:        aiusage.NewTracker(db),
 		DiscoveryService: discovery.NewService(),
-		ContentCache:     cache.NewContentCache(100, 30*time.Minute), // Cache up to 100 articles for 30 minutes
+		ContentCache:     cache.NewContentCache(100, 30*time.Minute), // Cache up to 100 feeds for 30 minutes
 	}
+	h.Episodes = podcast.NewDownloader(db, "", h.articleHTTPClient)
+	return h
 }
 
 // SetApp sets the Wails application instance for browser integration.
@@ -75,14 +78,20 @@ func (h *Handler) SetApp(app interface{}) {
 	h.App = app
 }
 
-// GetArticleContent fetches article content with caching
+// GetArticleContent returns the content stored with an article, or recovers it from the live
+// feed. Content recovered from the live feed is written back to the database so later reads
+// work offline. Stored content is not cached, as full texts and revisions replace it.
 func (h *Handler) GetArticleContent(articleID int64) (string, error) {
-	// Check cache first
-	if content, found := h.ContentCache.Get(articleID); found {
-		return content, nil
+	// Serve content persisted when the article was fetched
+	storedContent, err := h.DB.GetArticleContent(articleID)
+	if err != nil {
+		return "", err
+	}
+	if storedContent != "" {
+		return storedContent, nil
 	}
 
-	// Get the article from database
+	// Nothing stored (e.g. articles saved before content persistence), fall back to the network
 	article, err := h.DB.GetArticleByID(articleID)
 	if err != nil {
 		return "", err
@@ -130,8 +139,12 @@ func (h *Handler) GetArticleContent(articleID int64) (string, error) {
 		content := feed.ExtractContent(matchingItem)
 		cleanContent := utils.CleanHTML(content)
 
-		// Cache the content
-		h.ContentCache.Set(articleID, cleanContent)
+		// Persist the content so it survives the item dropping out of the feed
+		if cleanContent != "" {
+			if err := h.DB.UpdateArticleContent(articleID, cleanContent); err != nil {
+				log.Printf("Failed to store content for article %d: %v", articleID, err)
+			}
+		}
 
 		return cleanContent, nil
 	}
@@ -139,22 +152,31 @@ func (h *Handler) GetArticleContent(articleID int64) (string, error) {
 	return "", nil
 }
 
-// FetchFullArticleContent fetches the full article content from the original URL using readability.
-func (h *Handler) FetchFullArticleContent(url string) (string, error) {
-	// Use FromURL which handles the HTTP request internally
-	article, err := readability.FromURL(url, 30*time.Second)
+// FetchFullArticleContent fetches the full article content from the original URL. The extraction rule
+// of the article's site is used if there is one, readability otherwise.
+// The page is requested with the HTTP client of the article's feed, so its proxy and request options apply.
+func (h *Handler) FetchFullArticleContent(feedID int64, articleURL string) (string, error) {
+	client, err := h.articleHTTPClient(feedID)
 	if err != nil {
-		return "", fmt.Errorf("readability parse: %w", err)
+		return "", fmt.Errorf("create HTTP client: %w", err)
 	}
-
-	// Render the article content as HTML
-	var buf bytes.Buffer
-	err = article.RenderHTML(&buf)
+	rule, err := h.DB.FindExtractionRule(utils.URLHost(articleURL))
 	if err != nil {
-		return "", fmt.Errorf("render HTML: %w", err)
+		return "", fmt.Errorf("load extraction rule: %w", err)
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return feed.ExtractFullText(ctx, client, articleURL, rule)
+}
 
-	return buf.String(), nil
+// articleHTTPClient returns the HTTP client of a feed, or a plain client if the feed is unknown.
+func (h *Handler) articleHTTPClient(feedID int64) (*http.Client, error) {
+	if h.Fetcher != nil && feedID != 0 {
+		if f, err := h.DB.GetFeedByID(feedID); err == nil {
+			return h.Fetcher.GetHTTPClient(*f)
+		}
+	}
+	return feed.CreateHTTPClient("")
 }
 
 // findMatchingFeedItem finds the best matching feed item for an article using multiple criteria
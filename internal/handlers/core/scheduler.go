@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"MrRSS/internal/cache"
+	ff "MrRSS/internal/feed"
+	"MrRSS/internal/refreshpolicy"
 	"MrRSS/internal/utils"
 )
 
@@ -34,6 +36,12 @@ func (h *Handler) StartBackgroundScheduler(ctx context.Context) {
 		}
 	}()
 
+	// Download queued podcast episodes and keep offline episodes up to date
+	go h.Episodes.Run(ctx)
+
+	// Fetch the full text of new articles of feeds that ask for it
+	go h.Fetcher.RunFullTextWorkers(ctx)
+
 	// Check refresh mode
 	refreshMode, _ := h.DB.GetSetting("refresh_mode")
 
@@ -83,6 +91,9 @@ func (h *Handler) refreshFeedsWithFixedInterval(ctx context.Context, globalInter
 		log.Printf("Error getting feeds for fixed refresh: %v", err)
 		return
 	}
+	hostDeferrals := h.getHostDeferrals()
+	pushedFeeds := h.getWebSubFeeds()
+	policies := h.getRefreshPolicies()
 
 	for i, feed := range feeds {
 		// Check if context is cancelled
@@ -95,6 +106,11 @@ func (h *Handler) refreshFeedsWithFixedInterval(ctx context.Context, globalInter
 		// Create local copy to avoid loop variable capture issues
 		currentFeed := feed
 
+		// Skip paused feeds
+		if currentFeed.IsPaused {
+			continue
+		}
+
 		// Determine refresh interval for this feed
 		// Feed-level settings override global settings:
 		// - RefreshInterval > 0: Use custom fixed interval (in minutes)
@@ -114,9 +130,20 @@ func (h *Handler) refreshFeedsWithFixedInterval(ctx context.Context, globalInter
 			refreshInterval = globalInterval
 		}
 
-		// Check if feed needs refresh based on last_updated time
-		timeSinceUpdate := time.Since(currentFeed.LastUpdated)
-		if timeSinceUpdate >= refreshInterval {
+		// Feeds whose hub pushes updates only need an occasional safety poll
+		if pushedFeeds[currentFeed.ID] {
+			refreshInterval = max(refreshInterval, ff.WebSubPollInterval)
+		}
+
+		// Quiet hours and an exhausted data budget pause or slow down background refreshing
+		decision := policies.Decide(currentFeed.Category)
+		if decision.Paused {
+			continue
+		}
+		refreshInterval *= time.Duration(decision.IntervalFactor)
+
+		// Check if feed needs refresh based on last_updated time and any backoff or server deferral
+		if !time.Now().Before(nextDueTime(currentFeed, refreshInterval, hostDeferrals)) {
 			// Apply staggered delay to avoid thundering herd
 			staggerDelay := h.Fetcher.GetStaggeredDelay(currentFeed.ID, len(feeds))
 
@@ -175,6 +202,9 @@ func (h *Handler) refreshFeedsIntelligently(ctx context.Context) {
 		log.Printf("Error getting feeds for intelligent refresh: %v", err)
 		return
 	}
+	hostDeferrals := h.getHostDeferrals()
+	pushedFeeds := h.getWebSubFeeds()
+	policies := h.getRefreshPolicies()
 
 	// Use intelligent refresh calculator
 	calculator := h.Fetcher.GetIntelligentRefreshCalculator()
@@ -190,6 +220,11 @@ func (h *Handler) refreshFeedsIntelligently(ctx context.Context) {
 		// Create local copy to avoid loop variable capture issues
 		currentFeed := feed
 
+		// Skip paused feeds
+		if currentFeed.IsPaused {
+			continue
+		}
+
 		// Determine refresh interval for this feed
 		// Feed-level settings override global settings:
 		// - RefreshInterval > 0: Use custom fixed interval (in minutes)
@@ -208,9 +243,20 @@ func (h *Handler) refreshFeedsIntelligently(ctx context.Context) {
 			refreshInterval = calculator.CalculateInterval(currentFeed)
 		}
 
-		// Check if feed needs refresh based on last_updated time
-		timeSinceUpdate := time.Since(currentFeed.LastUpdated)
-		if timeSinceUpdate >= refreshInterval {
+		// Feeds whose hub pushes updates only need an occasional safety poll
+		if pushedFeeds[currentFeed.ID] {
+			refreshInterval = max(refreshInterval, ff.WebSubPollInterval)
+		}
+
+		// Quiet hours and an exhausted data budget pause or slow down background refreshing
+		decision := policies.Decide(currentFeed.Category)
+		if decision.Paused {
+			continue
+		}
+		refreshInterval *= time.Duration(decision.IntervalFactor)
+
+		// Check if feed needs refresh based on last_updated time and any backoff or server deferral
+		if !time.Now().Before(nextDueTime(currentFeed, refreshInterval, hostDeferrals)) {
 			// Apply staggered delay to avoid thundering herd
 			staggerDelay := h.Fetcher.GetStaggeredDelay(currentFeed.ID, len(feeds))
 
@@ -237,8 +283,54 @@ func (h *Handler) refreshFeedsIntelligently(ctx context.Context) {
 	h.runCleanup()
 }
 
+// nextDueTime returns when a feed should be refreshed next: one interval after its last update,
+// but not before a failure backoff or a deferral requested by the feed's server or host has passed.
+func nextDueTime(feed models.Feed, interval time.Duration, hostDeferrals map[string]models.HostDeferral) time.Time {
+	due := feed.LastUpdated.Add(interval)
+	for _, t := range []time.Time{feed.NextRetryAt, feed.DeferredUntil, hostDeferrals[utils.URLHost(feed.URL)].Until} {
+		if t.After(due) {
+			due = t
+		}
+	}
+	return due
+}
+
+// getHostDeferrals returns the active per-host deferrals, or none if they cannot be loaded.
+func (h *Handler) getHostDeferrals() map[string]models.HostDeferral {
+	deferrals, err := h.DB.GetHostDeferrals(time.Now())
+	if err != nil {
+		log.Printf("Error getting host deferrals: %v", err)
+		return nil
+	}
+	return deferrals
+}
+
+// getWebSubFeeds returns the feeds with an active WebSub lease, or none if they cannot be loaded.
+func (h *Handler) getWebSubFeeds() map[int64]bool {
+	ids, err := h.DB.GetActiveWebSubFeedIDs(time.Now())
+	if err != nil {
+		log.Printf("Error getting WebSub subscriptions: %v", err)
+		return nil
+	}
+	return ids
+}
+
+// getRefreshPolicies returns the refresh policies and today's data usage, or no restrictions if they cannot be loaded.
+func (h *Handler) getRefreshPolicies() *refreshpolicy.Snapshot {
+	snap, err := h.Fetcher.GetRefreshPolicies().Snapshot(time.Now())
+	if err != nil {
+		log.Printf("Error getting refresh policies: %v", err)
+	}
+	return snap
+}
+
 // runCleanup runs the cleanup routine if enabled
 func (h *Handler) runCleanup() {
+	// Only today's data usage counts towards the daily budget
+	if err := h.DB.DeleteDataUsageBefore(refreshpolicy.Day(time.Now())); err != nil {
+		log.Printf("Error removing old data usage: %v", err)
+	}
+
 	autoCleanup, _ := h.DB.GetSetting("auto_cleanup_enabled")
 	if autoCleanup == "true" {
 		count, err := h.DB.CleanupOldArticles()
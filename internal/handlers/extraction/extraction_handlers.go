@@ -0,0 +1,148 @@
+package extraction
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"MrRSS/internal/feed"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// Upper bound for an uploaded site config file
+const maxSiteConfigSize = 1 << 20
+
+// importResult reports the outcome of importing one site config file.
+type importResult struct {
+	Domain   string   `json:"domain"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// HandleRules lists the extraction rules (GET) or creates or replaces the rule of a domain (POST).
+func HandleRules(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := h.DB.GetExtractionRules()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if rules == nil {
+			rules = []models.ExtractionRule{}
+		}
+		json.NewEncoder(w).Encode(rules)
+	case http.MethodPost:
+		var rule models.ExtractionRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := feed.ValidateExtractionRule(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.DB.SaveExtractionRule(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(rule)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleDeleteRule deletes the rule of a domain (?domain=).
+func HandleDeleteRule(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	err := h.DB.DeleteExtractionRule(r.URL.Query().Get("domain"))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Extraction rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleImportRules imports site config files in the ftr-site-config format. Files are uploaded as
+// multipart form files named "file", each named after its domain like in ftr-site-config
+// (example.com.txt), or as the raw request body with the domain given as ?domain=.
+// Lazy-image attributes of existing rules are kept, since the format has no equivalent.
+func HandleImportRules(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var results []importResult
+	if strings.Contains(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		files := r.MultipartForm.File["file"]
+		if len(files) == 0 {
+			http.Error(w, "No file uploaded", http.StatusBadRequest)
+			return
+		}
+		for _, header := range files {
+			domain := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+			f, err := header.Open()
+			if err != nil {
+				results = append(results, importResult{Domain: domain, Error: err.Error()})
+				continue
+			}
+			results = append(results, importSiteConfig(h, f, domain))
+			f.Close()
+		}
+	} else {
+		domain := r.URL.Query().Get("domain")
+		if domain == "" {
+			http.Error(w, "Missing domain", http.StatusBadRequest)
+			return
+		}
+		results = append(results, importSiteConfig(h, r.Body, domain))
+	}
+
+	json.NewEncoder(w).Encode(results)
+}
+
+// importSiteConfig parses one site config file and saves it as the rule of domain.
+func importSiteConfig(h *core.Handler, r io.Reader, domain string) importResult {
+	result := importResult{Domain: domain}
+	data, err := io.ReadAll(io.LimitReader(r, maxSiteConfigSize+1))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(data) > maxSiteConfigSize {
+		result.Error = fmt.Sprintf("file is larger than %d bytes", maxSiteConfigSize)
+		return result
+	}
+
+	rule, warnings, err := feed.ParseSiteConfig(strings.NewReader(string(data)), domain)
+	result.Warnings = warnings
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Domain = rule.Domain
+	if existing, err := h.DB.GetExtractionRule(rule.Domain); err == nil {
+		rule.LazyImageAttrs = existing.LazyImageAttrs
+	}
+	if err := h.DB.SaveExtractionRule(rule); err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	ff "MrRSS/internal/feed"
+	"MrRSS/internal/handlers/core"
+)
+
+// HandleBackfillFeed starts importing the older items of a feed (?id=) from its archive.
+// The optional body sets how far back to go: {"until": "2024-01-01", "max_items": 200}.
+// Progress is reported by HandleBackfillProgress.
+func HandleBackfillFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Until    string `json:"until"` // RFC 3339 time or YYYY-MM-DD
+		MaxItems int    `json:"max_items"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	opts := ff.BackfillOptions{MaxItems: req.MaxItems}
+	if req.Until != "" {
+		if opts.Until, err = time.Parse(time.RFC3339, req.Until); err != nil {
+			if opts.Until, err = time.ParseInLocation("2006-01-02", req.Until, time.Local); err != nil {
+				http.Error(w, "Invalid until date", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	feed, err := h.DB.GetFeedByID(id)
+	if err != nil {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	err = h.Fetcher.StartBackfill(*feed, opts)
+	switch {
+	case errors.Is(err, ff.ErrBackfillRunning):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, ff.ErrBackfillUnsupported):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandleBackfillProgress returns the progress of the running or last backfill.
+func HandleBackfillProgress(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(w).Encode(h.Fetcher.GetBackfillProgress())
+}
+
+// HandleCancelBackfill stops the running backfill, keeping the items imported so far.
+func HandleCancelBackfill(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.Fetcher.CancelBackfill()
+	w.WriteHeader(http.StatusOK)
+}
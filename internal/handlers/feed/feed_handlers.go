@@ -5,10 +5,75 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
+	ff "MrRSS/internal/feed"
 	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
 )
 
+// requestOptionsInput holds the request options accepted when adding or updating a feed.
+// Missing fields keep their current value; an empty string clears one.
+type requestOptionsInput struct {
+	AuthType      *string `json:"auth_type"`
+	AuthUsername  *string `json:"auth_username"`
+	AuthSecret    *string `json:"auth_secret"`
+	CustomHeaders *string `json:"custom_headers"`
+	Cookies       *string `json:"cookies"`
+	UserAgent     *string `json:"user_agent"`
+}
+
+// isSet reports whether any request option was given.
+func (in requestOptionsInput) isSet() bool {
+	return in.AuthType != nil || in.AuthUsername != nil || in.AuthSecret != nil || in.CustomHeaders != nil || in.Cookies != nil || in.UserAgent != nil
+}
+
+// apply overrides the given options with the ones present in the request.
+func (in requestOptionsInput) apply(opts *models.FeedRequestOptions) {
+	for _, field := range []struct {
+		value *string
+		dst   *string
+	}{
+		{in.AuthType, &opts.AuthType},
+		{in.AuthUsername, &opts.AuthUsername},
+		{in.AuthSecret, &opts.AuthSecret},
+		{in.CustomHeaders, &opts.CustomHeaders},
+		{in.Cookies, &opts.Cookies},
+		{in.UserAgent, &opts.UserAgent},
+	} {
+		if field.value != nil {
+			*field.dst = *field.value
+		}
+	}
+	opts.AuthType = strings.ToLower(strings.TrimSpace(opts.AuthType))
+}
+
+// scriptOptionsInput holds the script feed options accepted when adding or updating a feed.
+// Missing fields leave the stored option unchanged.
+type scriptOptionsInput struct {
+	ScriptArgs    *[]string          `json:"script_args"`
+	ScriptEnv     *map[string]string `json:"script_env"`
+	ScriptTimeout *int               `json:"script_timeout"`
+}
+
+// isSet reports whether any script option was given.
+func (in scriptOptionsInput) isSet() bool {
+	return in.ScriptArgs != nil || in.ScriptEnv != nil || in.ScriptTimeout != nil
+}
+
+// apply overrides the given options with the ones present in the request.
+func (in scriptOptionsInput) apply(opts *models.FeedScriptOptions) {
+	if in.ScriptArgs != nil {
+		opts.Args = *in.ScriptArgs
+	}
+	if in.ScriptEnv != nil {
+		opts.Env = *in.ScriptEnv
+	}
+	if in.ScriptTimeout != nil {
+		opts.Timeout = *in.ScriptTimeout
+	}
+}
+
 // HandleFeeds returns all feeds.
 func HandleFeeds(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	feeds, err := h.DB.GetFeeds()
@@ -44,29 +109,54 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		XPathItemCategories string `json:"xpath_item_categories"`
 		XPathItemUid        string `json:"xpath_item_uid"`
 		ArticleViewMode     string `json:"article_view_mode"`
+		// Credentials, headers, cookies and User-Agent for private feeds
+		requestOptionsInput
+		// Arguments, environment and timeout for script feeds
+		scriptOptionsInput
+		FetchFullText bool `json:"fetch_full_text"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	var opts models.FeedRequestOptions
+	req.requestOptionsInput.apply(&opts)
+	if err := ff.ValidateRequestOptions(opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var scriptOpts models.FeedScriptOptions
+	req.scriptOptionsInput.apply(&scriptOpts)
+	if err := ff.ValidateScriptOptions(scriptOpts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var feedID int64
 	var err error
 	if req.ScriptPath != "" {
 		// Add feed using custom script
-		feedID, err = h.Fetcher.AddScriptSubscription(req.ScriptPath, req.Category, req.Title)
+		feedID, err = h.Fetcher.AddScriptSubscriptionWithOptions(req.ScriptPath, req.Category, req.Title, scriptOpts)
 	} else if req.XPathItem != "" {
 		// Add feed using XPath
 		feedID, err = h.Fetcher.AddXPathSubscription(req.URL, req.Category, req.Title, req.Type, req.XPathItem, req.XPathItemTitle, req.XPathItemContent, req.XPathItemUri, req.XPathItemAuthor, req.XPathItemTimestamp, req.XPathItemTimeFormat, req.XPathItemThumbnail, req.XPathItemCategories, req.XPathItemUid)
 	} else {
 		// Add feed using URL
-		feedID, err = h.Fetcher.AddSubscription(req.URL, req.Category, req.Title)
+		feedID, err = h.Fetcher.AddSubscriptionWithOptions(req.URL, req.Category, req.Title, opts)
 	}
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if (req.ScriptPath != "" || req.XPathItem != "") && !opts.IsZero() {
+		if err := h.DB.UpdateFeedRequestOptions(feedID, opts); err != nil {
+			http.Error(w, "feed created but failed to store request options: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
 	// Update all feed settings
 	feed, err := h.DB.GetFeedByID(feedID)
@@ -80,6 +170,12 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "feed created but failed to update settings: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if req.FetchFullText {
+		if err := h.DB.SetFeedFetchFullText(feedID, true); err != nil {
+			http.Error(w, "feed created but failed to update settings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
 	// Immediately fetch articles for the newly added feed in background
 	go func() {
@@ -130,16 +226,89 @@ func HandleUpdateFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		XPathItemCategories string `json:"xpath_item_categories"`
 		XPathItemUid        string `json:"xpath_item_uid"`
 		ArticleViewMode     string `json:"article_view_mode"`
+		// Optional, left unchanged when missing
+		MarkUnreadOnUpdate *bool `json:"mark_unread_on_update"`
+		KeepEpisodes       *int  `json:"keep_episodes"`
+		FetchFullText      *bool `json:"fetch_full_text"`
+		requestOptionsInput
+		scriptOptionsInput
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.KeepEpisodes != nil && *req.KeepEpisodes < 0 {
+		http.Error(w, "keep_episodes must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	var opts models.FeedRequestOptions
+	if req.requestOptionsInput.isSet() {
+		var err error
+		if opts, err = h.DB.GetFeedRequestOptions(req.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		req.requestOptionsInput.apply(&opts)
+		if err := ff.ValidateRequestOptions(opts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var scriptOpts models.FeedScriptOptions
+	if req.scriptOptionsInput.isSet() {
+		var err error
+		if scriptOpts, err = h.DB.GetFeedScriptOptions(req.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		req.scriptOptionsInput.apply(&scriptOpts)
+		if err := ff.ValidateScriptOptions(scriptOpts); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// The placeholder URL of a script feed includes its arguments
+		if req.ScriptPath != "" {
+			req.URL = ff.ScriptFeedURL(req.ScriptPath, scriptOpts.Args)
+		}
+	}
 
 	if err := h.DB.UpdateFeed(req.ID, req.Title, req.URL, req.Category, req.ScriptPath, req.HideFromTimeline, req.ProxyURL, req.ProxyEnabled, req.RefreshInterval, req.IsImageMode, req.Type, req.XPathItem, req.XPathItemTitle, req.XPathItemContent, req.XPathItemUri, req.XPathItemAuthor, req.XPathItemTimestamp, req.XPathItemTimeFormat, req.XPathItemThumbnail, req.XPathItemCategories, req.XPathItemUid, req.ArticleViewMode); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if req.MarkUnreadOnUpdate != nil {
+		if err := h.DB.SetFeedMarkUnreadOnUpdate(req.ID, *req.MarkUnreadOnUpdate); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.KeepEpisodes != nil {
+		if err := h.DB.SetFeedKeepEpisodes(req.ID, *req.KeepEpisodes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.Episodes.Notify()
+	}
+	if req.FetchFullText != nil {
+		if err := h.DB.SetFeedFetchFullText(req.ID, *req.FetchFullText); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.requestOptionsInput.isSet() {
+		if err := h.DB.UpdateFeedRequestOptions(req.ID, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.scriptOptionsInput.isSet() {
+		if err := h.DB.UpdateFeedScriptOptions(req.ID, scriptOpts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
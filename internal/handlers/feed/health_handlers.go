@@ -0,0 +1,219 @@
+package feed
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+// defaultStaleDays is the number of days without new articles after which a working feed counts as stale.
+const defaultStaleDays = 30
+
+// FeedHealth describes a feed that needs attention.
+type FeedHealth struct {
+	FeedID              int64      `json:"feed_id"`
+	Title               string     `json:"title"`
+	URL                 string     `json:"url"`
+	Category            string     `json:"category"`
+	Status              string     `json:"status"` // "failing", "deferred", "stale" or "dead"
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastHTTPStatus      int        `json:"last_http_status,omitempty"`
+	LastUpdated         time.Time  `json:"last_updated"`
+	NextRetryAt         *time.Time `json:"next_retry_at,omitempty"`
+	PausedReason        string     `json:"paused_reason,omitempty"`
+	LastArticleAt       *time.Time `json:"last_article_at,omitempty"`
+	DeferredUntil       *time.Time `json:"deferred_until,omitempty"` // Server asked not to be fetched before this time
+	DeferReason         string     `json:"defer_reason,omitempty"`
+}
+
+// FeedHealthResponse groups unhealthy feeds by status.
+type FeedHealthResponse struct {
+	Failing  []FeedHealth `json:"failing"`  // Recent fetches failed, retrying with backoff
+	Deferred []FeedHealth `json:"deferred"` // Working, but the server asked to be fetched less often (Retry-After, Cache-Control)
+	Stale    []FeedHealth `json:"stale"`    // Fetches succeed but nothing new was published for a long time
+	Dead     []FeedHealth `json:"dead"`     // Paused after too many failures or HTTP 410
+}
+
+// HandleFeedHealth lists failing, deferred, stale and dead feeds.
+// The optional stale_days query parameter overrides the staleness threshold.
+func HandleFeedHealth(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	staleDays := defaultStaleDays
+	if d, err := strconv.Atoi(r.URL.Query().Get("stale_days")); err == nil && d > 0 {
+		staleDays = d
+	}
+	staleBefore := time.Now().AddDate(0, 0, -staleDays)
+
+	feeds, err := h.DB.GetFeeds()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	latestArticles, err := h.DB.GetLatestArticleTimes()
+	if err != nil {
+		log.Printf("Error getting latest article times: %v", err)
+	}
+
+	hostDeferrals, err := h.DB.GetHostDeferrals(time.Now())
+	if err != nil {
+		log.Printf("Error getting host deferrals: %v", err)
+	}
+
+	response := FeedHealthResponse{
+		Failing:  []FeedHealth{},
+		Deferred: []FeedHealth{},
+		Stale:    []FeedHealth{},
+		Dead:     []FeedHealth{},
+	}
+	for _, feed := range feeds {
+		health := FeedHealth{
+			FeedID:              feed.ID,
+			Title:               feed.Title,
+			URL:                 feed.URL,
+			Category:            feed.Category,
+			ConsecutiveFailures: feed.ConsecutiveFailures,
+			LastError:           feed.LastError,
+			LastUpdated:         feed.LastUpdated,
+			PausedReason:        feed.PausedReason,
+		}
+		if !feed.NextRetryAt.IsZero() {
+			nextRetry := feed.NextRetryAt
+			health.NextRetryAt = &nextRetry
+		}
+		setFeedDeferral(&health, feed, hostDeferrals)
+		if latest, ok := latestArticles[feed.ID]; ok {
+			health.LastArticleAt = &latest
+		}
+
+		switch {
+		case feed.IsPaused:
+			health.Status = "dead"
+		case feed.ConsecutiveFailures > 0:
+			health.Status = "failing"
+		case health.DeferredUntil != nil:
+			health.Status = "deferred"
+		case health.LastArticleAt != nil && health.LastArticleAt.Before(staleBefore):
+			health.Status = "stale"
+		default:
+			continue
+		}
+
+		if health.Status != "stale" {
+			if history, err := h.DB.GetFeedFetchHistory(feed.ID, 1); err == nil && len(history) > 0 {
+				health.LastHTTPStatus = history[0].HTTPStatus
+			}
+		}
+
+		switch health.Status {
+		case "dead":
+			response.Dead = append(response.Dead, health)
+		case "failing":
+			response.Failing = append(response.Failing, health)
+		case "deferred":
+			response.Deferred = append(response.Deferred, health)
+		case "stale":
+			response.Stale = append(response.Stale, health)
+		}
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// setFeedDeferral fills in the active server deferral of a feed,
+// taking whichever of the feed's own and its host's deferral lasts longer.
+func setFeedDeferral(health *FeedHealth, feed models.Feed, hostDeferrals map[string]models.HostDeferral) {
+	now := time.Now()
+	until, reason := feed.DeferredUntil, feed.DeferReason
+	if d, ok := hostDeferrals[utils.URLHost(feed.URL)]; ok && d.Until.After(until) {
+		until, reason = d.Until, d.Reason+" (host "+d.Host+")"
+	}
+	if until.After(now) {
+		health.DeferredUntil = &until
+		health.DeferReason = reason
+	}
+}
+
+// HandleFeedFetchHistory returns the recent fetch history of a feed.
+func HandleFeedFetchHistory(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	history, err := h.DB.GetFeedFetchHistory(id, limit)
+	if err != nil {
+		log.Printf("Error getting fetch history: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(history)
+}
+
+// HandleResumeFeed resumes scheduled refreshes of a paused feed.
+func HandleResumeFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.ResumeFeed(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleFeedURLChanges returns the recorded URL changes caused by permanent redirects.
+// The optional id parameter limits the result to changes involving that feed.
+func HandleFeedURLChanges(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var id int64
+	if idStr := r.URL.Query().Get("id"); idStr != "" {
+		var err error
+		id, err = strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	changes, err := h.DB.GetFeedURLChanges(id)
+	if err != nil {
+		log.Printf("Error getting feed URL changes: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if changes == nil {
+		changes = []models.FeedURLChange{}
+	}
+	json.NewEncoder(w).Encode(changes)
+}
@@ -0,0 +1,70 @@
+package feed_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	fh "MrRSS/internal/handlers/feed"
+	"MrRSS/internal/models"
+)
+
+func TestHandleFeedHealth(t *testing.T) {
+	h := setupHandler(t)
+
+	failingID, _ := h.DB.AddFeed(&models.Feed{Title: "Failing", URL: "https://failing.example.com/feed"})
+	deadID, _ := h.DB.AddFeed(&models.Feed{Title: "Dead", URL: "https://dead.example.com/feed"})
+	staleID, _ := h.DB.AddFeed(&models.Feed{Title: "Stale", URL: "https://stale.example.com/feed"})
+	h.DB.AddFeed(&models.Feed{Title: "Healthy", URL: "https://ok.example.com/feed"})
+	deferredID, _ := h.DB.AddFeed(&models.Feed{Title: "Deferred", URL: "https://busy.example.com/feed"})
+	h.DB.SetHostDeferral("busy.example.com", time.Now().Add(time.Hour), "HTTP 429 with Retry-After 1h0m0s")
+
+	h.DB.MarkFeedFetchFailed(failingID, "timeout", 2, time.Now().Add(time.Hour))
+	h.DB.MarkFeedFetchFailed(deadID, "gone", 1, time.Now().Add(time.Hour))
+	h.DB.PauseFeed(deadID, "feed is gone (HTTP 410)")
+	h.DB.RecordFeedFetch(models.FetchHistoryEntry{FeedID: deadID, HTTPStatus: 410, Error: "gone"})
+	h.DB.SaveArticle(&models.Article{FeedID: staleID, Title: "old", URL: "https://stale.example.com/1", PublishedAt: time.Now().AddDate(0, 0, -90)})
+	newest := time.Now().AddDate(0, 0, -45)
+	h.DB.SaveArticle(&models.Article{FeedID: staleID, Title: "less old", URL: "https://stale.example.com/2", PublishedAt: newest})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/feeds/health", nil)
+	rr := httptest.NewRecorder()
+	fh.HandleFeedHealth(h, rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+
+	var resp fh.FeedHealthResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Failing) != 1 || resp.Failing[0].FeedID != failingID || resp.Failing[0].ConsecutiveFailures != 2 {
+		t.Errorf("unexpected failing feeds: %+v", resp.Failing)
+	}
+	if len(resp.Dead) != 1 || resp.Dead[0].FeedID != deadID || resp.Dead[0].LastHTTPStatus != 410 {
+		t.Errorf("unexpected dead feeds: %+v", resp.Dead)
+	}
+	if len(resp.Deferred) != 1 || resp.Deferred[0].FeedID != deferredID || resp.Deferred[0].DeferReason != "HTTP 429 with Retry-After 1h0m0s (host busy.example.com)" {
+		t.Errorf("unexpected deferred feeds: %+v", resp.Deferred)
+	}
+	if len(resp.Stale) != 1 || resp.Stale[0].FeedID != staleID {
+		t.Errorf("unexpected stale feeds: %+v", resp.Stale)
+	} else if at := resp.Stale[0].LastArticleAt; at == nil || !at.Equal(newest) {
+		t.Errorf("expected last article at %v, got %v", newest, at)
+	}
+
+	// Resuming brings the dead feed back
+	req = httptest.NewRequest(http.MethodPost, "/api/feeds/resume?id="+strconv.FormatInt(deadID, 10), nil)
+	rr = httptest.NewRecorder()
+	fh.HandleResumeFeed(h, rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+	feed, _ := h.DB.GetFeedByID(deadID)
+	if feed.IsPaused || feed.ConsecutiveFailures != 0 {
+		t.Errorf("expected resumed feed, got paused=%v failures=%d", feed.IsPaused, feed.ConsecutiveFailures)
+	}
+}
@@ -0,0 +1,84 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"MrRSS/internal/handlers/core"
+)
+
+// HandleMailGroups lists the mailing lists and senders of a Maildir or mbox in the mail
+// directory, each of which can be subscribed to as a feed.
+func HandleMailGroups(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	path := strings.TrimSpace(r.URL.Query().Get("path"))
+	if path == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+	groups, err := h.Fetcher.ListMailGroups(ctx, path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// HandleAddMailFeeds subscribes to groups of a Maildir or mbox in the mail directory, one
+// feed per List-Id or sender, and fetches their articles in the background.
+func HandleAddMailFeeds(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Path     string   `json:"path"`
+		Category string   `json:"category"`
+		Groups   []string `json:"groups"` // Keys returned by /api/feeds/mail-groups, empty for all
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Path) == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+	feedIDs, err := h.Fetcher.AddMailSubscriptions(ctx, strings.TrimSpace(req.Path), req.Category, req.Groups)
+	if err != nil && len(feedIDs) == 0 {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch the articles of the new feeds in background
+	go func() {
+		for _, id := range feedIDs {
+			feed, err := h.DB.GetFeedByID(id)
+			if err != nil {
+				continue
+			}
+			h.Fetcher.FetchSingleFeed(context.Background(), *feed)
+		}
+	}()
+
+	resp := map[string]interface{}{"feed_ids": feedIDs}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,33 @@
+package feed
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/handlers/core"
+)
+
+// HandleRefreshPrediction returns the publishing model of a feed (?id=) and when intelligent
+// refresh expects its next article and refreshes it next.
+func HandleRefreshPrediction(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid feed ID", http.StatusBadRequest)
+		return
+	}
+	feed, err := h.DB.GetFeedByID(id)
+	if err != nil {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return
+	}
+
+	prediction := h.Fetcher.GetIntelligentRefreshCalculator().Predict(*feed, time.Now())
+	json.NewEncoder(w).Encode(prediction)
+}
@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	ff "MrRSS/internal/feed"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// HandlePreviewXPathFeed runs an XPath feed configuration against its page without saving anything
+// and returns the extracted items with per-field warnings.
+func HandlePreviewXPathFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		// Existing feed whose stored request options are used, 0 for a new configuration
+		FeedID              int64  `json:"feed_id"`
+		URL                 string `json:"url"`
+		Type                string `json:"type"`
+		XPathItem           string `json:"xpath_item"`
+		XPathItemTitle      string `json:"xpath_item_title"`
+		XPathItemContent    string `json:"xpath_item_content"`
+		XPathItemUri        string `json:"xpath_item_uri"`
+		XPathItemAuthor     string `json:"xpath_item_author"`
+		XPathItemTimestamp  string `json:"xpath_item_timestamp"`
+		XPathItemTimeFormat string `json:"xpath_item_time_format"`
+		XPathItemThumbnail  string `json:"xpath_item_thumbnail"`
+		XPathItemCategories string `json:"xpath_item_categories"`
+		XPathItemUid        string `json:"xpath_item_uid"`
+		ProxyURL            string `json:"proxy_url"`
+		ProxyEnabled        bool   `json:"proxy_enabled"`
+		requestOptionsInput
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+
+	feed := models.Feed{
+		URL:                 req.URL,
+		Type:                req.Type,
+		XPathItem:           req.XPathItem,
+		XPathItemTitle:      req.XPathItemTitle,
+		XPathItemContent:    req.XPathItemContent,
+		XPathItemUri:        req.XPathItemUri,
+		XPathItemAuthor:     req.XPathItemAuthor,
+		XPathItemTimestamp:  req.XPathItemTimestamp,
+		XPathItemTimeFormat: req.XPathItemTimeFormat,
+		XPathItemThumbnail:  req.XPathItemThumbnail,
+		XPathItemCategories: req.XPathItemCategories,
+		XPathItemUid:        req.XPathItemUid,
+		ProxyURL:            req.ProxyURL,
+		ProxyEnabled:        req.ProxyEnabled,
+	}
+	if err := ff.ValidateXPathFeed(feed); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var opts models.FeedRequestOptions
+	if req.FeedID != 0 {
+		var err error
+		if opts, err = h.DB.GetFeedRequestOptions(req.FeedID); err != nil {
+			http.Error(w, "Feed not found", http.StatusNotFound)
+			return
+		}
+	}
+	req.requestOptionsInput.apply(&opts)
+	if err := ff.ValidateRequestOptions(opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	preview, err := h.Fetcher.PreviewXPathFeed(ctx, feed, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
@@ -0,0 +1,63 @@
+package feed_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ff "MrRSS/internal/feed"
+	fh "MrRSS/internal/handlers/feed"
+)
+
+func TestHandlePreviewXPathFeed(t *testing.T) {
+	h := setupHandler(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`<rss><channel><item><title>One</title><link>https://example.com/1</link></item></channel></rss>`))
+	}))
+	defer srv.Close()
+
+	post := func(payload map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		w := httptest.NewRecorder()
+		fh.HandlePreviewXPathFeed(h, w, httptest.NewRequest(http.MethodPost, "/api/feeds/preview-xpath", bytes.NewReader(body)))
+		return w
+	}
+
+	config := map[string]interface{}{
+		"url":              srv.URL,
+		"type":             "XML+XPath",
+		"xpath_item":       "//item",
+		"xpath_item_title": "title",
+		"xpath_item_uri":   "link",
+		"auth_type":        "bearer",
+		"auth_secret":      "t0k",
+	}
+	w := post(config)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var preview ff.XPathPreview
+	if err := json.NewDecoder(w.Body).Decode(&preview); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(preview.Items) != 1 || preview.Items[0].Title != "One" || len(preview.Warnings) != 0 {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+
+	config["xpath_item_title"] = "title[("
+	if w := post(config); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid XPath, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	fh.HandlePreviewXPathFeed(h, w, httptest.NewRequest(http.MethodGet, "/api/feeds/preview-xpath", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", w.Code)
+	}
+}
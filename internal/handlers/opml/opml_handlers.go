@@ -59,8 +59,8 @@ func HandleOPMLImport(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		var feedID int64
 		var err error
 
-		// Check if feed has XPath configuration
-		if f.Type == "HTML+XPath" || f.Type == "XML+XPath" {
+		// Check if feed has XPath, CSS selector or JSONPath configuration
+		if f.Type == "HTML+XPath" || f.Type == "XML+XPath" || f.Type == "HTML+CSS" || f.Type == "JSON+JSONPath" {
 			feedID, err = h.Fetcher.AddXPathSubscription(
 				f.URL, f.Category, f.Title, f.Type,
 				f.XPathItem, f.XPathItemTitle, f.XPathItemContent, f.XPathItemUri,
@@ -194,8 +194,8 @@ func HandleOPMLImportDialog(h *core.Handler, w http.ResponseWriter, r *http.Requ
 		var feedID int64
 		var err error
 
-		// Check if feed has XPath configuration
-		if f.Type == "HTML+XPath" || f.Type == "XML+XPath" {
+		// Check if feed has XPath, CSS selector or JSONPath configuration
+		if f.Type == "HTML+XPath" || f.Type == "XML+XPath" || f.Type == "HTML+CSS" || f.Type == "JSON+JSONPath" {
 			feedID, err = h.Fetcher.AddXPathSubscription(
 				f.URL, f.Category, f.Title, f.Type,
 				f.XPathItem, f.XPathItemTitle, f.XPathItemContent, f.XPathItemUri,
@@ -0,0 +1,344 @@
+package output
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+	"MrRSS/internal/output"
+	"MrRSS/internal/rules"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// Articles per output feed when no limit is given
+	defaultOutputLimit = 50
+	// Upper bound for the articles per output feed
+	maxOutputLimit = 500
+	// Newest articles examined when selecting by filter or rule conditions
+	conditionWindow = 5000
+	// FeedPath serves the rendered output feeds
+	FeedPath = "/api/outputs/feed"
+)
+
+// outputFeedResponse is an output feed together with the URLs it is served at.
+type outputFeedResponse struct {
+	models.OutputFeed
+	URLs map[string]string `json:"urls"`
+}
+
+func newOutputFeedResponse(of models.OutputFeed) outputFeedResponse {
+	urls := make(map[string]string)
+	for _, format := range []string{output.FormatRSS, output.FormatAtom, output.FormatJSON} {
+		query := url.Values{"id": {strconv.FormatInt(of.ID, 10)}, "token": {of.Token}, "format": {format}}
+		urls[format] = FeedPath + "?" + query.Encode()
+	}
+	return outputFeedResponse{OutputFeed: of, URLs: urls}
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// validateOutputFeed checks the article selection of an output feed and applies the default limit.
+func validateOutputFeed(of *models.OutputFeed) error {
+	of.Name = strings.TrimSpace(of.Name)
+	if of.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch of.Source {
+	case models.OutputSourceAll, models.OutputSourceUnread, models.OutputSourceFavorites, models.OutputSourceReadLater:
+	case models.OutputSourceFeed, models.OutputSourceRule:
+		if _, err := strconv.ParseInt(of.SourceValue, 10, 64); err != nil {
+			return fmt.Errorf("source_value must be an ID for source %s", of.Source)
+		}
+	case models.OutputSourceCategory:
+		if of.SourceValue == "" {
+			return fmt.Errorf("source_value must name a category")
+		}
+	case models.OutputSourceFilter:
+		var conditions []rules.Condition
+		if err := json.Unmarshal([]byte(of.Conditions), &conditions); err != nil {
+			return fmt.Errorf("invalid conditions: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown source: %s", of.Source)
+	}
+	if of.Limit <= 0 {
+		of.Limit = defaultOutputLimit
+	}
+	of.Limit = min(of.Limit, maxOutputLimit)
+	return nil
+}
+
+// HandleOutputFeeds lists output feeds (GET) or creates one (POST).
+func HandleOutputFeeds(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		outputs, err := h.DB.GetOutputFeeds()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response := []outputFeedResponse{}
+		for _, of := range outputs {
+			response = append(response, newOutputFeedResponse(of))
+		}
+		json.NewEncoder(w).Encode(response)
+	case http.MethodPost:
+		var of models.OutputFeed
+		if err := json.NewDecoder(r.Body).Decode(&of); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := validateOutputFeed(&of); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		token, err := generateToken()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		of.Token = token
+		id, err := h.DB.CreateOutputFeed(&of)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		created, err := h.DB.GetOutputFeedByID(id)
+		if err != nil || created == nil {
+			http.Error(w, "Failed to load created output feed", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(newOutputFeedResponse(*created))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleUpdateOutputFeed changes the name and article selection of an output feed.
+func HandleUpdateOutputFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var of models.OutputFeed
+	if err := json.NewDecoder(r.Body).Decode(&of); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validateOutputFeed(&of); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.DB.UpdateOutputFeed(&of); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleDeleteOutputFeed deletes an output feed.
+func HandleDeleteOutputFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err := h.DB.DeleteOutputFeed(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleRegenerateToken gives an output feed a new token, so readers using the old one lose access.
+func HandleRegenerateToken(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	of, err := h.DB.GetOutputFeedByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if of == nil {
+		http.Error(w, "Output feed not found", http.StatusNotFound)
+		return
+	}
+	token, err := generateToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.DB.SetOutputFeedToken(of.ID, token); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	of.Token = token
+	json.NewEncoder(w).Encode(newOutputFeedResponse(*of))
+}
+
+// HandleFeed renders an output feed as RSS, Atom or JSON Feed (?format=rss|atom|json).
+// In server mode the feed's token is required; the desktop app only listens locally,
+// so there the ID alone is enough.
+func HandleFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	token := query.Get("token")
+	var of *models.OutputFeed
+	var err error
+	if idStr := query.Get("id"); idStr != "" {
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+		of, err = h.DB.GetOutputFeedByID(id)
+	} else {
+		of, err = h.DB.GetOutputFeedByToken(token)
+	}
+	if err != nil {
+		log.Printf("Error getting output feed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if of == nil || (utils.IsServerMode() && subtle.ConstantTimeCompare([]byte(token), []byte(of.Token)) != 1) {
+		// Same answer for unknown feeds and wrong tokens
+		http.NotFound(w, r)
+		return
+	}
+
+	format := query.Get("format")
+	if !output.ValidFormat(format) {
+		http.Error(w, "unsupported output format: "+format, http.StatusBadRequest)
+		return
+	}
+
+	articles, err := selectArticles(h, *of)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ch := output.Channel{
+		Title:       of.Name,
+		Description: "Articles republished by MrRSS",
+		SelfURL:     requestURL(r),
+	}
+	data, contentType, err := output.Render(format, ch, articles)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// selectArticles returns the newest articles of an output feed's selection,
+// with their stored content and enclosures loaded.
+func selectArticles(h *core.Handler, of models.OutputFeed) ([]models.Article, error) {
+	limit := of.Limit
+	if limit <= 0 {
+		limit = defaultOutputLimit
+	}
+
+	var articles []models.Article
+	var err error
+	switch of.Source {
+	case models.OutputSourceAll, models.OutputSourceUnread, models.OutputSourceFavorites, models.OutputSourceReadLater:
+		articles, err = h.DB.GetArticles(of.Source, 0, "", false, limit, 0)
+	case models.OutputSourceFeed:
+		feedID, _ := strconv.ParseInt(of.SourceValue, 10, 64)
+		articles, err = h.DB.GetArticles("", feedID, "", false, limit, 0)
+	case models.OutputSourceCategory:
+		articles, err = h.DB.GetArticles("", 0, of.SourceValue, false, limit, 0)
+	case models.OutputSourceFilter, models.OutputSourceRule:
+		articles, err = selectByConditions(h, of)
+		if len(articles) > limit {
+			articles = articles[:limit]
+		}
+	default:
+		return nil, fmt.Errorf("unknown source: %s", of.Source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if articles == nil {
+		articles = []models.Article{}
+	}
+
+	ids := make([]int64, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+	contents, err := h.DB.GetArticleContents(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range articles {
+		articles[i].Content = contents[articles[i].ID]
+	}
+	if err := h.DB.AttachEnclosures(articles); err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+// selectByConditions returns the recent articles matching a saved filter or a rule's conditions.
+func selectByConditions(h *core.Handler, of models.OutputFeed) ([]models.Article, error) {
+	engine := rules.NewEngine(h.DB)
+
+	var conditions []rules.Condition
+	if of.Source == models.OutputSourceRule {
+		ruleID, _ := strconv.ParseInt(of.SourceValue, 10, 64)
+		rule, err := engine.GetRule(ruleID)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("rule %d not found", ruleID)
+		}
+		conditions = rule.Conditions
+	} else if err := json.Unmarshal([]byte(of.Conditions), &conditions); err != nil {
+		return nil, err
+	}
+
+	candidates, err := h.DB.GetArticles("", 0, "", false, conditionWindow, 0)
+	if err != nil {
+		return nil, err
+	}
+	return engine.FilterArticles(candidates, conditions)
+}
+
+// requestURL reconstructs the absolute URL a request was made to, honouring reverse proxy headers.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+	host := r.Host
+	if fwd := r.Header.Get("X-Forwarded-Host"); fwd != "" {
+		host = strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+	return scheme + "://" + host + r.URL.RequestURI()
+}
@@ -0,0 +1,193 @@
+package output_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	ff "MrRSS/internal/feed"
+	"MrRSS/internal/handlers/core"
+	outputhandlers "MrRSS/internal/handlers/output"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func setupHandler(t *testing.T) *core.Handler {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB error: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init error: %v", err)
+	}
+	return core.NewHandler(db, ff.NewFetcher(db, nil), nil)
+}
+
+type createdOutput struct {
+	models.OutputFeed
+	URLs map[string]string `json:"urls"`
+}
+
+func createOutput(t *testing.T, h *core.Handler, body string) createdOutput {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	outputhandlers.HandleOutputFeeds(h, rr, httptest.NewRequest(http.MethodPost, "/api/outputs", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("create status %d: %s", rr.Code, rr.Body.String())
+	}
+	var created createdOutput
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return created
+}
+
+func getFeed(h *core.Handler, target string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	outputhandlers.HandleFeed(h, rr, httptest.NewRequest(http.MethodGet, target, nil))
+	return rr
+}
+
+func seedArticles(t *testing.T, h *core.Handler) {
+	t.Helper()
+	feedID, err := h.DB.AddFeed(&models.Feed{Title: "Tech", URL: "https://tech.example/feed", Category: "News"})
+	if err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+	now := time.Now()
+	for _, a := range []models.Article{
+		{FeedID: feedID, Title: "Go 2 released", URL: "https://tech.example/go2", PublishedAt: now, IsFavorite: true, TranslatedTitle: "Go 2 veröffentlicht", Summary: "Big news", Content: "<p>Stored body</p>"},
+		{FeedID: feedID, Title: "Rust news", URL: "https://tech.example/rust", PublishedAt: now.Add(-time.Hour)},
+	} {
+		if err := h.DB.SaveArticle(&a); err != nil {
+			t.Fatalf("SaveArticle: %v", err)
+		}
+	}
+}
+
+func TestHandleOutputFeeds_CreateAndList(t *testing.T) {
+	h := setupHandler(t)
+
+	created := createOutput(t, h, `{"name":"Favorites","source":"favorites"}`)
+	if created.Token == "" || created.Limit != 50 {
+		t.Errorf("created = %+v, want a token and the default limit", created.OutputFeed)
+	}
+	if !strings.Contains(created.URLs["atom"], "format=atom") || !strings.Contains(created.URLs["atom"], created.Token) {
+		t.Errorf("atom URL = %q", created.URLs["atom"])
+	}
+
+	for _, body := range []string{`{"name":"","source":"favorites"}`, `{"name":"x","source":"bogus"}`, `{"name":"x","source":"feed","source_value":"abc"}`, `{"name":"x","source":"filter","conditions":"not json"}`} {
+		rr := httptest.NewRecorder()
+		outputhandlers.HandleOutputFeeds(h, rr, httptest.NewRequest(http.MethodPost, "/api/outputs", strings.NewReader(body)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	outputhandlers.HandleOutputFeeds(h, rr, httptest.NewRequest(http.MethodGet, "/api/outputs", nil))
+	var list []createdOutput
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "Favorites" {
+		t.Errorf("list = %+v", list)
+	}
+}
+
+func TestHandleFeed_RendersSelection(t *testing.T) {
+	h := setupHandler(t)
+	seedArticles(t, h)
+
+	favorites := createOutput(t, h, `{"name":"Favorites","source":"favorites"}`)
+	rr := getFeed(h, favorites.URLs["rss"])
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/rss+xml") {
+		t.Errorf("content type = %q", ct)
+	}
+	parsed, err := gofeed.NewParser().Parse(bytes.NewReader(rr.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(parsed.Items) != 1 {
+		t.Fatalf("got %d items, want only the favorite", len(parsed.Items))
+	}
+	item := parsed.Items[0]
+	if item.Title != "Go 2 veröffentlicht" || item.Content != "<p>Stored body</p>" || item.Description != "Big news" {
+		t.Errorf("item = title %q, content %q, description %q", item.Title, item.Content, item.Description)
+	}
+
+	conditions, _ := json.Marshal(`[{"field":"article_title","operator":"contains","value":"Rust"}]`)
+	filtered := createOutput(t, h, `{"name":"Rust","source":"filter","conditions":`+string(conditions)+`}`)
+	rr = getFeed(h, filtered.URLs["json"])
+	var doc struct {
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode JSON Feed: %v", err)
+	}
+	if len(doc.Items) != 1 || doc.Items[0].Title != "Rust news" {
+		t.Errorf("filtered items = %+v", doc.Items)
+	}
+
+	category := createOutput(t, h, `{"name":"News","source":"category","source_value":"News"}`)
+	rr = getFeed(h, category.URLs["atom"])
+	parsed, err = gofeed.NewParser().Parse(bytes.NewReader(rr.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("parse atom: %v", err)
+	}
+	if parsed.FeedType != "atom" || len(parsed.Items) != 2 {
+		t.Errorf("category feed: type %s with %d items", parsed.FeedType, len(parsed.Items))
+	}
+
+	if rr := getFeed(h, strings.Replace(category.URLs["atom"], "format=atom", "format=csv", 1)); rr.Code != http.StatusBadRequest {
+		t.Errorf("unsupported format: status %d, want 400", rr.Code)
+	}
+}
+
+func TestHandleFeed_TokenRequiredInServerMode(t *testing.T) {
+	h := setupHandler(t)
+	created := createOutput(t, h, `{"name":"All","source":"all"}`)
+	byID := "/api/outputs/feed?id=" + strconv.FormatInt(created.ID, 10)
+
+	// The desktop app only listens locally
+	if rr := getFeed(h, byID); rr.Code != http.StatusOK {
+		t.Errorf("desktop mode without token: status %d", rr.Code)
+	}
+
+	utils.SetServerMode(true)
+	t.Cleanup(func() { utils.SetServerMode(false) })
+
+	if rr := getFeed(h, byID); rr.Code != http.StatusNotFound {
+		t.Errorf("server mode without token: status %d, want 404", rr.Code)
+	}
+	if rr := getFeed(h, byID+"&token=wrong"); rr.Code != http.StatusNotFound {
+		t.Errorf("server mode with wrong token: status %d, want 404", rr.Code)
+	}
+	if rr := getFeed(h, created.URLs["rss"]); rr.Code != http.StatusOK {
+		t.Errorf("server mode with token: status %d", rr.Code)
+	}
+
+	// A new token revokes the old one
+	rr := httptest.NewRecorder()
+	outputhandlers.HandleRegenerateToken(h, rr, httptest.NewRequest(http.MethodPost, "/api/outputs/regenerate-token?id="+strconv.FormatInt(created.ID, 10), nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("regenerate status %d", rr.Code)
+	}
+	if rr := getFeed(h, created.URLs["rss"]); rr.Code != http.StatusNotFound {
+		t.Errorf("old token after regeneration: status %d, want 404", rr.Code)
+	}
+}
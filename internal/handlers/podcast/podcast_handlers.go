@@ -0,0 +1,168 @@
+package podcast
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/handlers/core"
+)
+
+// HandleEpisode returns the episode details, playback progress and download of an article (?id=).
+func HandleEpisode(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	articleID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+
+	episode, err := h.DB.GetPodcastEpisode(articleID)
+	if err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(episode)
+}
+
+// HandleProgress stores the playback position (in seconds) and/or played flag of an episode.
+func HandleProgress(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ArticleID int64    `json:"article_id"`
+		Position  *float64 `json:"position"`
+		Played    *bool    `json:"played"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Position != nil && *req.Position < 0 {
+		http.Error(w, "position must not be negative", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.DB.GetPodcastEpisode(req.ArticleID); err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+
+	if err := h.DB.SetEpisodeProgress(req.ArticleID, req.Position, req.Played); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleDownloads lists the episode download queue and finished downloads.
+func HandleDownloads(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	downloads, err := h.DB.GetEpisodeDownloads()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(downloads)
+}
+
+// HandleQueueDownload queues an episode for offline download.
+func HandleQueueDownload(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ArticleID int64 `json:"article_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Episodes.Enqueue(req.ArticleID); err != nil {
+		writeEpisodeError(w, err)
+		return
+	}
+	download, err := h.DB.GetEpisodeDownload(req.ArticleID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(download)
+}
+
+// HandleRemoveDownload cancels or deletes the download of an episode.
+func HandleRemoveDownload(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ArticleID int64 `json:"article_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Episodes.Remove(req.ArticleID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleDownloadFile serves the downloaded audio of an episode (?id=) for offline playback.
+func HandleDownloadFile(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	articleID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid article ID", http.StatusBadRequest)
+		return
+	}
+
+	filePath, err := h.Episodes.FilePath(articleID)
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "Episode not downloaded", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// ServeFile handles range requests, which players use to seek
+	http.ServeFile(w, r, filePath)
+}
+
+// writeEpisodeError maps errors about a missing article or episode to HTTP responses.
+func writeEpisodeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Article not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrNoEpisodeAudio):
+		http.Error(w, "Article is not a podcast episode", http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,96 @@
+package policy
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+	"MrRSS/internal/refreshpolicy"
+)
+
+// policiesResponse lists the refresh policies together with today's data usage and what the
+// global policy allows right now, so the frontend can skip image prefetching in low data mode.
+type policiesResponse struct {
+	Policies []models.RefreshPolicy `json:"policies"`
+	Usage    usageResponse          `json:"usage"`
+	Status   refreshpolicy.Decision `json:"status"`
+}
+
+type usageResponse struct {
+	Day        string           `json:"day"`
+	TotalBytes int64            `json:"total_bytes"`
+	Categories map[string]int64 `json:"categories"`
+}
+
+// HandlePolicies lists the refresh policies (GET) or creates or replaces the policy of a
+// category, or the global policy for an empty category (POST).
+func HandlePolicies(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		policies, err := h.DB.GetRefreshPolicies()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if policies == nil {
+			policies = []models.RefreshPolicy{}
+		}
+		now := time.Now()
+		snap, err := refreshpolicy.New(h.DB).Snapshot(now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(policiesResponse{
+			Policies: policies,
+			Usage:    usageResponse{Day: refreshpolicy.Day(now), TotalBytes: snap.TotalUsage(), Categories: snap.Usage},
+			Status:   snap.Decide(""),
+		})
+	case http.MethodPost:
+		var p models.RefreshPolicy
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		p.Category = strings.TrimSpace(p.Category)
+		if err := refreshpolicy.Validate(&p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.DB.SaveRefreshPolicy(p); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleDeletePolicy deletes the policy of a category (?category=), whose feeds then follow the
+// global policy. The global policy itself is cleared by saving it empty.
+func HandleDeletePolicy(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	category := r.URL.Query().Get("category")
+	if category == "" {
+		http.Error(w, "category is required", http.StatusBadRequest)
+		return
+	}
+	err := h.DB.DeleteRefreshPolicy(category)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Refresh policy not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
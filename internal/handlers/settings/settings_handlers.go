@@ -74,131 +74,167 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		freshRSSAPIPassword, _ := h.DB.GetEncryptedSetting("freshrss_api_password")
 		fullTextFetchEnabled, _ := h.DB.GetSetting("full_text_fetch_enabled")
 		autoShowAllContent, _ := h.DB.GetSetting("auto_show_all_content")
+		feedPauseAfterFailures, _ := h.DB.GetSetting("feed_pause_after_failures")
+		feedRedirectConfirmations, _ := h.DB.GetSetting("feed_redirect_confirmations")
+		maxConcurrentPerHost, _ := h.DB.GetSetting("max_concurrent_per_host")
+		webSubEnabled, _ := h.DB.GetSetting("websub_enabled")
+		webSubPublicURL, _ := h.DB.GetSetting("websub_public_url")
+		duplicateHandling, _ := h.DB.GetSetting("duplicate_handling")
+		scriptTimeoutSeconds, _ := h.DB.GetSetting("script_timeout_seconds")
+		scriptMaxOutputKB, _ := h.DB.GetSetting("script_max_output_kb")
+		podcastDownloadMaxSizeMB, _ := h.DB.GetSetting("podcast_download_max_size_mb")
+		rSSHubInstance, _ := h.DB.GetSetting("rsshub_instance")
+		fullTextWorkers, _ := h.DB.GetSetting("full_text_workers")
+		fullTextHostIntervalMs, _ := h.DB.GetSetting("full_text_host_interval_ms")
 		json.NewEncoder(w).Encode(map[string]string{
-			"update_interval":             interval,
-			"refresh_mode":                refreshMode,
-			"translation_enabled":         translationEnabled,
-			"target_language":             targetLang,
-			"translation_provider":        provider,
-			"deepl_api_key":               apiKey,
-			"deepl_endpoint":              deeplEndpoint,
-			"baidu_app_id":                baiduAppID,
-			"baidu_secret_key":            baiduSecretKey,
-			"ai_api_key":                  aiAPIKey,
-			"ai_endpoint":                 aiEndpoint,
-			"ai_model":                    aiModel,
-			"ai_translation_prompt":       aiTranslationPrompt,
-			"ai_summary_prompt":           aiSummaryPrompt,
-			"ai_custom_headers":           aiCustomHeaders,
-			"ai_usage_tokens":             aiUsageTokens,
-			"ai_usage_limit":              aiUsageLimit,
-			"ai_chat_enabled":             aiChatEnabled,
-			"auto_cleanup_enabled":        autoCleanup,
-			"max_cache_size_mb":           maxCacheSize,
-			"max_article_age_days":        maxArticleAge,
-			"language":                    language,
-			"theme":                       theme,
-			"last_article_update":         lastUpdate,
-			"show_hidden_articles":        showHidden,
-			"hover_mark_as_read":          hoverMarkAsRead,
-			"startup_on_boot":             startupOnBoot,
-			"close_to_tray":               closeToTray,
-			"shortcuts":                   shortcuts,
-			"rules":                       rules,
-			"default_view_mode":           defaultViewMode,
-			"media_cache_enabled":         mediaCacheEnabled,
-			"media_cache_max_size_mb":     mediaCacheMaxSizeMB,
-			"media_cache_max_age_days":    mediaCacheMaxAgeDays,
-			"summary_enabled":             summaryEnabled,
-			"summary_length":              summaryLength,
-			"summary_provider":            summaryProvider,
-			"summary_trigger_mode":        summaryTriggerMode,
-			"proxy_enabled":               proxyEnabled,
-			"proxy_type":                  proxyType,
-			"proxy_host":                  proxyHost,
-			"proxy_port":                  proxyPort,
-			"proxy_username":              proxyUsername,
-			"proxy_password":              proxyPassword,
-			"google_translate_endpoint":   googleTranslateEndpoint,
-			"show_article_preview_images": showArticlePreviewImages,
-			"obsidian_enabled":            obsidianEnabled,
-			"obsidian_vault":              obsidianVault,
-			"obsidian_vault_path":         obsidianVaultPath,
-			"network_speed":               networkSpeed,
-			"network_bandwidth_mbps":      networkBandwidth,
-			"network_latency_ms":          networkLatency,
-			"max_concurrent_refreshes":    maxConcurrentRefreshes,
-			"last_network_test":           lastNetworkTest,
-			"image_gallery_enabled":       imageGalleryEnabled,
-			"freshrss_enabled":            freshRSSSyncEnabled,
-			"freshrss_server_url":         freshRSSServerURL,
-			"freshrss_username":           freshRSSUsername,
-			"freshrss_api_password":       freshRSSAPIPassword,
-			"full_text_fetch_enabled":     fullTextFetchEnabled,
-			"auto_show_all_content":       autoShowAllContent,
+			"update_interval":              interval,
+			"refresh_mode":                 refreshMode,
+			"translation_enabled":          translationEnabled,
+			"target_language":              targetLang,
+			"translation_provider":         provider,
+			"deepl_api_key":                apiKey,
+			"deepl_endpoint":               deeplEndpoint,
+			"baidu_app_id":                 baiduAppID,
+			"baidu_secret_key":             baiduSecretKey,
+			"ai_api_key":                   aiAPIKey,
+			"ai_endpoint":                  aiEndpoint,
+			"ai_model":                     aiModel,
+			"ai_translation_prompt":        aiTranslationPrompt,
+			"ai_summary_prompt":            aiSummaryPrompt,
+			"ai_custom_headers":            aiCustomHeaders,
+			"ai_usage_tokens":              aiUsageTokens,
+			"ai_usage_limit":               aiUsageLimit,
+			"ai_chat_enabled":              aiChatEnabled,
+			"auto_cleanup_enabled":         autoCleanup,
+			"max_cache_size_mb":            maxCacheSize,
+			"max_article_age_days":         maxArticleAge,
+			"language":                     language,
+			"theme":                        theme,
+			"last_article_update":          lastUpdate,
+			"show_hidden_articles":         showHidden,
+			"hover_mark_as_read":           hoverMarkAsRead,
+			"startup_on_boot":              startupOnBoot,
+			"close_to_tray":                closeToTray,
+			"shortcuts":                    shortcuts,
+			"rules":                        rules,
+			"default_view_mode":            defaultViewMode,
+			"media_cache_enabled":          mediaCacheEnabled,
+			"media_cache_max_size_mb":      mediaCacheMaxSizeMB,
+			"media_cache_max_age_days":     mediaCacheMaxAgeDays,
+			"summary_enabled":              summaryEnabled,
+			"summary_length":               summaryLength,
+			"summary_provider":             summaryProvider,
+			"summary_trigger_mode":         summaryTriggerMode,
+			"proxy_enabled":                proxyEnabled,
+			"proxy_type":                   proxyType,
+			"proxy_host":                   proxyHost,
+			"proxy_port":                   proxyPort,
+			"proxy_username":               proxyUsername,
+			"proxy_password":               proxyPassword,
+			"google_translate_endpoint":    googleTranslateEndpoint,
+			"show_article_preview_images":  showArticlePreviewImages,
+			"obsidian_enabled":             obsidianEnabled,
+			"obsidian_vault":               obsidianVault,
+			"obsidian_vault_path":          obsidianVaultPath,
+			"network_speed":                networkSpeed,
+			"network_bandwidth_mbps":       networkBandwidth,
+			"network_latency_ms":           networkLatency,
+			"max_concurrent_refreshes":     maxConcurrentRefreshes,
+			"last_network_test":            lastNetworkTest,
+			"image_gallery_enabled":        imageGalleryEnabled,
+			"freshrss_enabled":             freshRSSSyncEnabled,
+			"freshrss_server_url":          freshRSSServerURL,
+			"freshrss_username":            freshRSSUsername,
+			"freshrss_api_password":        freshRSSAPIPassword,
+			"full_text_fetch_enabled":      fullTextFetchEnabled,
+			"auto_show_all_content":        autoShowAllContent,
+			"feed_pause_after_failures":    feedPauseAfterFailures,
+			"feed_redirect_confirmations":  feedRedirectConfirmations,
+			"max_concurrent_per_host":      maxConcurrentPerHost,
+			"websub_enabled":               webSubEnabled,
+			"websub_public_url":            webSubPublicURL,
+			"duplicate_handling":           duplicateHandling,
+			"script_timeout_seconds":       scriptTimeoutSeconds,
+			"script_max_output_kb":         scriptMaxOutputKB,
+			"podcast_download_max_size_mb": podcastDownloadMaxSizeMB,
+			"rsshub_instance":              rSSHubInstance,
+			"full_text_workers":            fullTextWorkers,
+			"full_text_host_interval_ms":   fullTextHostIntervalMs,
 		})
 	case http.MethodPost:
 		var req struct {
-			UpdateInterval           string `json:"update_interval"`
-			RefreshMode              string `json:"refresh_mode"`
-			TranslationEnabled       string `json:"translation_enabled"`
-			TargetLanguage           string `json:"target_language"`
-			TranslationProvider      string `json:"translation_provider"`
-			DeepLAPIKey              string `json:"deepl_api_key"`
-			DeepLEndpoint            string `json:"deepl_endpoint"`
-			BaiduAppID               string `json:"baidu_app_id"`
-			BaiduSecretKey           string `json:"baidu_secret_key"`
-			AIAPIKey                 string `json:"ai_api_key"`
-			AIEndpoint               string `json:"ai_endpoint"`
-			AIModel                  string `json:"ai_model"`
-			AITranslationPrompt      string `json:"ai_translation_prompt"`
-			AISummaryPrompt          string `json:"ai_summary_prompt"`
-			AICustomHeaders          string `json:"ai_custom_headers"`
-			AIUsageTokens            string `json:"ai_usage_tokens"`
-			AIUsageLimit             string `json:"ai_usage_limit"`
-			AIChatEnabled            string `json:"ai_chat_enabled"`
-			AutoCleanupEnabled       string `json:"auto_cleanup_enabled"`
-			MaxCacheSizeMB           string `json:"max_cache_size_mb"`
-			MaxArticleAgeDays        string `json:"max_article_age_days"`
-			Language                 string `json:"language"`
-			Theme                    string `json:"theme"`
-			ShowHiddenArticles       string `json:"show_hidden_articles"`
-			HoverMarkAsRead          string `json:"hover_mark_as_read"`
-			StartupOnBoot            string `json:"startup_on_boot"`
-			CloseToTray              string `json:"close_to_tray"`
-			Shortcuts                string `json:"shortcuts"`
-			Rules                    string `json:"rules"`
-			DefaultViewMode          string `json:"default_view_mode"`
-			MediaCacheEnabled        string `json:"media_cache_enabled"`
-			MediaCacheMaxSizeMB      string `json:"media_cache_max_size_mb"`
-			MediaCacheMaxAgeDays     string `json:"media_cache_max_age_days"`
-			SummaryEnabled           string `json:"summary_enabled"`
-			SummaryLength            string `json:"summary_length"`
-			SummaryProvider          string `json:"summary_provider"`
-			SummaryTriggerMode       string `json:"summary_trigger_mode"`
-			ProxyEnabled             string `json:"proxy_enabled"`
-			ProxyType                string `json:"proxy_type"`
-			ProxyHost                string `json:"proxy_host"`
-			ProxyPort                string `json:"proxy_port"`
-			ProxyUsername            string `json:"proxy_username"`
-			ProxyPassword            string `json:"proxy_password"`
-			GoogleTranslateEndpoint  string `json:"google_translate_endpoint"`
-			ShowArticlePreviewImages string `json:"show_article_preview_images"`
-			ObsidianEnabled          string `json:"obsidian_enabled"`
-			ObsidianVault            string `json:"obsidian_vault"`
-			ObsidianVaultPath        string `json:"obsidian_vault_path"`
-			NetworkSpeed             string `json:"network_speed"`
-			NetworkBandwidth         string `json:"network_bandwidth_mbps"`
-			NetworkLatency           string `json:"network_latency_ms"`
-			MaxConcurrentRefreshes   string `json:"max_concurrent_refreshes"`
-			LastNetworkTest          string `json:"last_network_test"`
-			ImageGalleryEnabled      string `json:"image_gallery_enabled"`
-			FreshRSSSyncEnabled      string `json:"freshrss_enabled"`
-			FreshRSSServerURL        string `json:"freshrss_server_url"`
-			FreshRSSUsername         string `json:"freshrss_username"`
-			FreshRSSAPIPassword      string `json:"freshrss_api_password"`
-			FullTextFetchEnabled     string `json:"full_text_fetch_enabled"`
-			AutoShowAllContent       string `json:"auto_show_all_content"`
+			UpdateInterval            string `json:"update_interval"`
+			RefreshMode               string `json:"refresh_mode"`
+			TranslationEnabled        string `json:"translation_enabled"`
+			TargetLanguage            string `json:"target_language"`
+			TranslationProvider       string `json:"translation_provider"`
+			DeepLAPIKey               string `json:"deepl_api_key"`
+			DeepLEndpoint             string `json:"deepl_endpoint"`
+			BaiduAppID                string `json:"baidu_app_id"`
+			BaiduSecretKey            string `json:"baidu_secret_key"`
+			AIAPIKey                  string `json:"ai_api_key"`
+			AIEndpoint                string `json:"ai_endpoint"`
+			AIModel                   string `json:"ai_model"`
+			AITranslationPrompt       string `json:"ai_translation_prompt"`
+			AISummaryPrompt           string `json:"ai_summary_prompt"`
+			AICustomHeaders           string `json:"ai_custom_headers"`
+			AIUsageTokens             string `json:"ai_usage_tokens"`
+			AIUsageLimit              string `json:"ai_usage_limit"`
+			AIChatEnabled             string `json:"ai_chat_enabled"`
+			AutoCleanupEnabled        string `json:"auto_cleanup_enabled"`
+			MaxCacheSizeMB            string `json:"max_cache_size_mb"`
+			MaxArticleAgeDays         string `json:"max_article_age_days"`
+			Language                  string `json:"language"`
+			Theme                     string `json:"theme"`
+			ShowHiddenArticles        string `json:"show_hidden_articles"`
+			HoverMarkAsRead           string `json:"hover_mark_as_read"`
+			StartupOnBoot             string `json:"startup_on_boot"`
+			CloseToTray               string `json:"close_to_tray"`
+			Shortcuts                 string `json:"shortcuts"`
+			Rules                     string `json:"rules"`
+			DefaultViewMode           string `json:"default_view_mode"`
+			MediaCacheEnabled         string `json:"media_cache_enabled"`
+			MediaCacheMaxSizeMB       string `json:"media_cache_max_size_mb"`
+			MediaCacheMaxAgeDays      string `json:"media_cache_max_age_days"`
+			SummaryEnabled            string `json:"summary_enabled"`
+			SummaryLength             string `json:"summary_length"`
+			SummaryProvider           string `json:"summary_provider"`
+			SummaryTriggerMode        string `json:"summary_trigger_mode"`
+			ProxyEnabled              string `json:"proxy_enabled"`
+			ProxyType                 string `json:"proxy_type"`
+			ProxyHost                 string `json:"proxy_host"`
+			ProxyPort                 string `json:"proxy_port"`
+			ProxyUsername             string `json:"proxy_username"`
+			ProxyPassword             string `json:"proxy_password"`
+			GoogleTranslateEndpoint   string `json:"google_translate_endpoint"`
+			ShowArticlePreviewImages  string `json:"show_article_preview_images"`
+			ObsidianEnabled           string `json:"obsidian_enabled"`
+			ObsidianVault             string `json:"obsidian_vault"`
+			ObsidianVaultPath         string `json:"obsidian_vault_path"`
+			NetworkSpeed              string `json:"network_speed"`
+			NetworkBandwidth          string `json:"network_bandwidth_mbps"`
+			NetworkLatency            string `json:"network_latency_ms"`
+			MaxConcurrentRefreshes    string `json:"max_concurrent_refreshes"`
+			LastNetworkTest           string `json:"last_network_test"`
+			ImageGalleryEnabled       string `json:"image_gallery_enabled"`
+			FreshRSSSyncEnabled       string `json:"freshrss_enabled"`
+			FreshRSSServerURL         string `json:"freshrss_server_url"`
+			FreshRSSUsername          string `json:"freshrss_username"`
+			FreshRSSAPIPassword       string `json:"freshrss_api_password"`
+			FullTextFetchEnabled      string `json:"full_text_fetch_enabled"`
+			AutoShowAllContent        string `json:"auto_show_all_content"`
+			FeedPauseAfterFailures    string `json:"feed_pause_after_failures"`
+			FeedRedirectConfirmations string `json:"feed_redirect_confirmations"`
+			MaxConcurrentPerHost      string `json:"max_concurrent_per_host"`
+			WebSubEnabled             string `json:"websub_enabled"`
+			WebSubPublicURL           string `json:"websub_public_url"`
+			DuplicateHandling         string `json:"duplicate_handling"`
+			ScriptTimeoutSeconds      string `json:"script_timeout_seconds"`
+			ScriptMaxOutputKB         string `json:"script_max_output_kb"`
+			PodcastDownloadMaxSizeMB  string `json:"podcast_download_max_size_mb"`
+			RSSHubInstance            string `json:"rsshub_instance"`
+			FullTextWorkers           string `json:"full_text_workers"`
+			FullTextHostIntervalMs    string `json:"full_text_host_interval_ms"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -433,6 +469,54 @@ func HandleSettings(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			h.DB.SetSetting("auto_show_all_content", req.AutoShowAllContent)
 		}
 
+		if req.FeedPauseAfterFailures != "" {
+			h.DB.SetSetting("feed_pause_after_failures", req.FeedPauseAfterFailures)
+		}
+
+		if req.FeedRedirectConfirmations != "" {
+			h.DB.SetSetting("feed_redirect_confirmations", req.FeedRedirectConfirmations)
+		}
+
+		if req.MaxConcurrentPerHost != "" {
+			h.DB.SetSetting("max_concurrent_per_host", req.MaxConcurrentPerHost)
+		}
+
+		if req.WebSubEnabled != "" {
+			h.DB.SetSetting("websub_enabled", req.WebSubEnabled)
+		}
+
+		if req.WebSubPublicURL != "" {
+			h.DB.SetSetting("websub_public_url", req.WebSubPublicURL)
+		}
+
+		if req.DuplicateHandling != "" {
+			h.DB.SetSetting("duplicate_handling", req.DuplicateHandling)
+		}
+
+		if req.ScriptTimeoutSeconds != "" {
+			h.DB.SetSetting("script_timeout_seconds", req.ScriptTimeoutSeconds)
+		}
+
+		if req.ScriptMaxOutputKB != "" {
+			h.DB.SetSetting("script_max_output_kb", req.ScriptMaxOutputKB)
+		}
+
+		if req.PodcastDownloadMaxSizeMB != "" {
+			h.DB.SetSetting("podcast_download_max_size_mb", req.PodcastDownloadMaxSizeMB)
+		}
+
+		if req.RSSHubInstance != "" {
+			h.DB.SetSetting("rsshub_instance", req.RSSHubInstance)
+		}
+
+		if req.FullTextWorkers != "" {
+			h.DB.SetSetting("full_text_workers", req.FullTextWorkers)
+		}
+
+		if req.FullTextHostIntervalMs != "" {
+			h.DB.SetSetting("full_text_host_interval_ms", req.FullTextHostIntervalMs)
+		}
+
 		w.WriteHeader(http.StatusOK)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -0,0 +1,140 @@
+package websub
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"MrRSS/internal/feed"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// maxPushSize bounds the size of content a hub may push in one request.
+const maxPushSize = 10 << 20
+
+// HandleCallback is the WebSub callback endpoint (/api/websub/callback/{feedID}/{token}).
+// GET requests are intent verifications from the hub, POST requests deliver new content.
+// Requests without the subscription's callback token are treated as unknown.
+func HandleCallback(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	feedID, token, ok := feed.ParseWebSubCallbackPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	sub, err := h.DB.GetWebSubSubscription(feedID)
+	if err != nil {
+		log.Printf("Error getting WebSub subscription of feed %d: %v", feedID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if sub != nil && subtle.ConstantTimeCompare([]byte(sub.CallbackToken), []byte(token)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		handleVerification(h, w, r, sub)
+	case http.MethodPost:
+		if sub == nil {
+			// Tells the hub the subscription no longer exists
+			http.Error(w, "Subscription gone", http.StatusGone)
+			return
+		}
+		handleContent(h, w, r, sub)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleVerification answers a hub's intent verification by echoing hub.challenge
+// if it matches a subscription we requested.
+func handleVerification(h *core.Handler, w http.ResponseWriter, r *http.Request, sub *models.WebSubSubscription) {
+	query := r.URL.Query()
+	if sub == nil || query.Get("hub.topic") != sub.TopicURL {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		if sub.State != models.WebSubStatePending && sub.State != models.WebSubStateActive {
+			http.NotFound(w, r)
+			return
+		}
+		leaseSeconds, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || leaseSeconds <= 0 {
+			leaseSeconds = feed.WebSubLeaseSeconds
+		}
+		if err := h.DB.ActivateWebSubSubscription(sub.FeedID, leaseSeconds); err != nil {
+			log.Printf("Error activating WebSub subscription of feed %d: %v", sub.FeedID, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("WebSub subscription for feed %d verified (lease %ds)", sub.FeedID, leaseSeconds)
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, query.Get("hub.challenge"))
+	case "denied":
+		if err := h.DB.DenyWebSubSubscription(sub.FeedID, query.Get("hub.reason")); err != nil {
+			log.Printf("Error denying WebSub subscription of feed %d: %v", sub.FeedID, err)
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		// We never unsubscribe explicitly; subscriptions of deleted feeds end with 410 Gone
+		http.NotFound(w, r)
+	}
+}
+
+// handleContent verifies the signature of pushed content and stores its items.
+func handleContent(h *core.Handler, w http.ResponseWriter, r *http.Request, sub *models.WebSubSubscription) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPushSize))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	// Content with a missing or wrong signature is acknowledged but ignored, as the spec requires
+	if sub.Secret != "" && !feed.VerifyWebSubSignature(sub.Secret, r.Header.Get("X-Hub-Signature"), body) {
+		log.Printf("Ignoring WebSub content for feed %d with invalid signature", sub.FeedID)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	f, err := h.DB.GetFeedByID(sub.FeedID)
+	if err != nil {
+		http.Error(w, "Subscription gone", http.StatusGone)
+		return
+	}
+
+	count, err := h.Fetcher.IngestPushedFeed(r.Context(), *f, body)
+	if err != nil {
+		log.Printf("Error processing WebSub content for feed %s: %v", f.Title, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Received %d items for feed %s via WebSub", count, f.Title)
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleSubscriptions lists the WebSub subscriptions of all feeds.
+func HandleSubscriptions(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	subs, err := h.DB.GetWebSubSubscriptions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if subs == nil {
+		subs = []models.WebSubSubscription{}
+	}
+	json.NewEncoder(w).Encode(subs)
+}
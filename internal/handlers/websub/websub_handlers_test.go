@@ -0,0 +1,197 @@
+package websub_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"MrRSS/internal/database"
+	ff "MrRSS/internal/feed"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/websub"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+func setupHandler(t *testing.T) *core.Handler {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB error: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init error: %v", err)
+	}
+	return core.NewHandler(db, ff.NewFetcher(db, nil), nil)
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebSubSubscribeVerifyAndPush(t *testing.T) {
+	utils.SetServerMode(true)
+	t.Cleanup(func() { utils.SetServerMode(false) })
+	h := setupHandler(t)
+
+	callbackSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		websub.HandleCallback(h, w, r)
+	}))
+	defer callbackSrv.Close()
+
+	// Fake hub: verifies the subscriber's intent before accepting the request
+	var hubSecret, hubCallback string
+	var verified bool
+	hubSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		hubSecret = r.PostForm.Get("hub.secret")
+		hubCallback = r.PostForm.Get("hub.callback")
+		verifyURL := hubCallback + "?" + url.Values{
+			"hub.mode":          {"subscribe"},
+			"hub.topic":         {r.PostForm.Get("hub.topic")},
+			"hub.challenge":     {"challenge-123"},
+			"hub.lease_seconds": {"3600"},
+		}.Encode()
+		resp, err := http.Get(verifyURL)
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			verified = resp.StatusCode == http.StatusOK && string(body) == "challenge-123"
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hubSrv.Close()
+
+	var feedURL string
+	feedSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Pushed</title>`+
+			`<atom:link rel="hub" href="%s"/><atom:link rel="self" href="%s"/>`+
+			`<item><title>polled</title><link>http://example.com/1</link><guid>1</guid></item></channel></rss>`, hubSrv.URL, feedURL)
+	}))
+	defer feedSrv.Close()
+	feedURL = feedSrv.URL + "/feed"
+
+	h.DB.SetSetting("websub_enabled", "true")
+	h.DB.SetSetting("websub_public_url", callbackSrv.URL)
+	id, _ := h.DB.AddFeed(&models.Feed{Title: "pushed", URL: feedURL})
+	feed, _ := h.DB.GetFeedByID(id)
+	h.Fetcher.FetchFeed(context.Background(), *feed)
+
+	if !verified {
+		t.Fatal("expected the hub's intent verification to be answered with the challenge")
+	}
+	sub, err := h.DB.GetWebSubSubscription(id)
+	if err != nil || sub == nil {
+		t.Fatalf("expected subscription, got %v, %v", sub, err)
+	}
+	if sub.CallbackToken == "" || hubCallback != ff.WebSubCallbackURL(callbackSrv.URL, id, sub.CallbackToken) {
+		t.Errorf("unexpected callback URL %s", hubCallback)
+	}
+	if sub.State != models.WebSubStateActive || sub.LeaseSeconds != 3600 || sub.TopicURL != feedURL {
+		t.Errorf("unexpected subscription: %+v", sub)
+	}
+	if active, _ := h.DB.GetActiveWebSubFeedIDs(sub.UpdatedAt); !active[id] {
+		t.Errorf("expected feed %d to have an active lease", id)
+	}
+
+	push := func(body, signature string) int {
+		req, _ := http.NewRequest(http.MethodPost, hubCallback, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/rss+xml")
+		if signature != "" {
+			req.Header.Set("X-Hub-Signature", signature)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("push failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	forged := `<?xml version="1.0"?><rss><channel><title>Pushed</title><item><title>forged</title><link>http://example.com/x</link><guid>x</guid></item></channel></rss>`
+	if code := push(forged, sign("wrong", []byte(forged))); code != http.StatusAccepted {
+		t.Errorf("expected forged push to be acknowledged with 202, got %d", code)
+	}
+
+	content := `<?xml version="1.0"?><rss><channel><title>Pushed</title><item><title>pushed</title><link>http://example.com/2</link><guid>2</guid></item></channel></rss>`
+	if code := push(content, sign(hubSecret, []byte(content))); code != http.StatusOK {
+		t.Fatalf("expected signed push to be accepted, got %d", code)
+	}
+
+	articles, _ := h.DB.GetArticles("", id, "", false, 10, 0)
+	titles := map[string]bool{}
+	for _, a := range articles {
+		titles[a.Title] = true
+	}
+	if len(articles) != 2 || !titles["polled"] || !titles["pushed"] {
+		t.Errorf("expected polled and pushed articles only, got %v", titles)
+	}
+
+	// Once the feed is gone the hub is told to stop
+	h.DB.DeleteFeed(id)
+	if code := push(content, sign(hubSecret, []byte(content))); code != http.StatusGone {
+		t.Errorf("expected 410 for deleted subscription, got %d", code)
+	}
+}
+
+func TestWebSubVerificationRejectsUnknownTopic(t *testing.T) {
+	h := setupHandler(t)
+	id, _ := h.DB.AddFeed(&models.Feed{Title: "f", URL: "https://example.com/feed"})
+	h.DB.SaveWebSubSubscriptionRequest(id, "https://hub.example.com/", "https://example.com/feed", "s", "tok")
+
+	query := url.Values{"hub.mode": {"subscribe"}, "hub.topic": {"https://evil.example.com/"}, "hub.challenge": {"c"}}
+	req := httptest.NewRequest(http.MethodGet, ff.WebSubCallbackURL("", id, "tok")+"?"+query.Encode(), nil)
+	rr := httptest.NewRecorder()
+	websub.HandleCallback(h, rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown topic, got %d", rr.Code)
+	}
+
+	sub, _ := h.DB.GetWebSubSubscription(id)
+	if sub.State != models.WebSubStatePending {
+		t.Errorf("expected subscription to stay pending, got %s", sub.State)
+	}
+}
+
+func TestWebSubCallbackRequiresToken(t *testing.T) {
+	h := setupHandler(t)
+	id, _ := h.DB.AddFeed(&models.Feed{Title: "f", URL: "https://example.com/feed"})
+	h.DB.SaveWebSubSubscriptionRequest(id, "https://hub.example.com/", "https://example.com/feed", "s", "tok")
+
+	query := url.Values{"hub.mode": {"subscribe"}, "hub.topic": {"https://example.com/feed"}, "hub.challenge": {"c"}}
+	for _, path := range []string{
+		fmt.Sprintf("%s%d", ff.WebSubCallbackPath, id),
+		ff.WebSubCallbackURL("", id, ""),
+		ff.WebSubCallbackURL("", id, "guess"),
+	} {
+		req := httptest.NewRequest(http.MethodGet, path+"?"+query.Encode(), nil)
+		rr := httptest.NewRecorder()
+		websub.HandleCallback(h, rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404 without the callback token, got %d", path, rr.Code)
+		}
+	}
+
+	sub, _ := h.DB.GetWebSubSubscription(id)
+	if sub.State != models.WebSubStatePending {
+		t.Errorf("expected subscription to stay pending, got %s", sub.State)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, ff.WebSubCallbackURL("", id, "tok")+"?"+query.Encode(), nil)
+	rr := httptest.NewRecorder()
+	websub.HandleCallback(h, rr, req)
+	if rr.Code != http.StatusOK || rr.Body.String() != "c" {
+		t.Errorf("expected verification with the token to succeed, got %d %q", rr.Code, rr.Body.String())
+	}
+}
@@ -20,8 +20,8 @@ type Feed struct {
 	ProxyEnabled       bool      `json:"proxy_enabled"`         // Whether to use proxy for this feed
 	RefreshInterval    int       `json:"refresh_interval"`      // Custom refresh interval in minutes (0 = use global, -1 = intelligent, >0 = custom minutes)
 	IsImageMode        bool      `json:"is_image_mode"`         // Whether this feed is for image gallery mode
-	// XPath support for HTML/XML scraping
-	Type                string `json:"type"`                   // "HTML+XPath" or "XML+XPath"
+	// XPath support for HTML/XML scraping; HTML+CSS and JSON+JSONPath feeds store CSS selectors and JSONPath expressions in the same fields
+	Type                string `json:"type"`                   // "HTML+XPath", "XML+XPath", "HTML+CSS", "JSON+JSONPath", or "Mail" for a local Maildir/mbox
 	XPathItem           string `json:"xpath_item"`             // XPath to extract feed items
 	XPathItemTitle      string `json:"xpath_item_title"`       // XPath to extract item title
 	XPathItemContent    string `json:"xpath_item_content"`     // XPath to extract item content
@@ -33,22 +33,284 @@ type Feed struct {
 	XPathItemCategories string `json:"xpath_item_categories"`  // XPath to extract item categories
 	XPathItemUid        string `json:"xpath_item_uid"`         // XPath to extract item unique ID
 	ArticleViewMode     string `json:"article_view_mode"`      // Article view mode override ('global', 'webpage', 'rendered')
+	// HTTP cache validators from the last successful fetch, used for conditional requests
+	ETag         string `json:"etag,omitempty"`          // Sent back as If-None-Match
+	LastModified string `json:"last_modified,omitempty"` // Sent back as If-Modified-Since
+	// Fetch health tracking
+	ConsecutiveFailures int       `json:"consecutive_failures"`    // Failed fetches since the last success
+	NextRetryAt         time.Time `json:"next_retry_at"`           // Backoff: no scheduled fetch before this time (zero = no backoff)
+	IsPaused            bool      `json:"is_paused"`               // Auto-paused feeds are skipped by scheduled refreshes
+	PausedReason        string    `json:"paused_reason,omitempty"` // Why the feed was paused
+	// Deferral requested by the server (Retry-After, Cache-Control max-age)
+	DeferredUntil time.Time `json:"deferred_until"`         // No scheduled fetch before this time (zero = none)
+	DeferReason   string    `json:"defer_reason,omitempty"` // Server signal that caused the deferral
+	// Mark articles unread again when the feed publishes a new revision of them
+	MarkUnreadOnUpdate bool `json:"mark_unread_on_update"`
+	// Request options for private feeds; the secret ones are only loaded when fetching
+	AuthType          string `json:"auth_type,omitempty"`     // "", "basic" or "bearer"
+	AuthUsername      string `json:"auth_username,omitempty"` // Username for basic auth
+	UserAgent         string `json:"user_agent,omitempty"`    // Overrides the default User-Agent
+	HasRequestSecrets bool   `json:"has_request_secrets"`     // Whether a password/token, custom headers or cookies are stored
+	// Script feed options; the environment may hold API keys and is only loaded when fetching
+	ScriptArgs    []string `json:"script_args,omitempty"`    // Arguments passed to the script
+	ScriptTimeout int      `json:"script_timeout,omitempty"` // Timeout in seconds (0 = global default)
+	HasScriptEnv  bool     `json:"has_script_env"`           // Whether environment variables are stored
+	// Podcast feeds: download the newest N episodes automatically and keep only those (0 = manual downloads)
+	KeepEpisodes int `json:"keep_episodes"`
+	// Replace the content of new articles with the full text extracted from their page
+	FetchFullText bool `json:"fetch_full_text"`
+}
+
+// Authentication schemes supported for feed requests
+const (
+	FeedAuthBasic  = "basic"
+	FeedAuthBearer = "bearer"
+)
+
+// FeedRequestOptions holds the credentials, headers and cookies sent with a feed's requests.
+// Secrets are stored encrypted and never included in API responses or exports.
+type FeedRequestOptions struct {
+	AuthType      string
+	AuthUsername  string
+	AuthSecret    string // Password for basic auth, token for bearer auth
+	CustomHeaders string // JSON object of extra request headers
+	Cookies       string // Value of the Cookie header
+	UserAgent     string
+}
+
+// IsZero reports whether no request option is set.
+func (o FeedRequestOptions) IsZero() bool {
+	return o == FeedRequestOptions{}
+}
+
+// FeedScriptOptions holds the arguments, environment variables and timeout a feed's script runs with.
+// The environment is stored encrypted and never included in API responses or exports.
+type FeedScriptOptions struct {
+	Args    []string
+	Env     map[string]string
+	Timeout int // Seconds, 0 = global default
+}
+
+// FetchHistoryEntry records the outcome of a single feed fetch
+type FetchHistoryEntry struct {
+	ID         int64     `json:"id"`
+	FeedID     int64     `json:"feed_id"`
+	FetchedAt  time.Time `json:"fetched_at"`
+	HTTPStatus int       `json:"http_status"` // 0 when no HTTP response was received (network error, script feed, ...)
+	DurationMs int64     `json:"duration_ms"`
+	ItemCount  int       `json:"item_count"`
+	Error      string    `json:"error,omitempty"`
+	Stderr     string    `json:"stderr,omitempty"` // What a script feed wrote to stderr, truncated
+}
+
+// HostDeferral is a fetch deferral the server of a host asked for, e.g. with 429 and Retry-After
+type HostDeferral struct {
+	Host   string    `json:"host"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"`
+}
+
+// WebSub subscription states
+const (
+	WebSubStatePending = "pending" // Subscription requested, waiting for the hub to verify it
+	WebSubStateActive  = "active"  // Verified; the hub pushes updates until the lease expires
+	WebSubStateDenied  = "denied"  // The hub refused or the request failed
+)
+
+// WebSubSubscription is a push subscription of a feed at its WebSub hub
+type WebSubSubscription struct {
+	FeedID         int64     `json:"feed_id"`
+	HubURL         string    `json:"hub_url"`
+	TopicURL       string    `json:"topic_url"`
+	Secret         string    `json:"-"` // HMAC key for verifying pushed content
+	CallbackToken  string    `json:"-"` // Random part of the callback URL
+	State          string    `json:"state"`
+	LeaseSeconds   int       `json:"lease_seconds"`
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+	LastPushAt     time.Time `json:"last_push_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+// Article selections an output feed can republish
+const (
+	OutputSourceAll       = "all"       // All articles shown in the timeline
+	OutputSourceUnread    = "unread"    // Unread articles
+	OutputSourceFavorites = "favorites" // Favorite articles
+	OutputSourceReadLater = "readLater" // Read-later list
+	OutputSourceFeed      = "feed"      // One feed; SourceValue is the feed ID
+	OutputSourceCategory  = "category"  // A category including subcategories; SourceValue is the category
+	OutputSourceFilter    = "filter"    // Articles matching Conditions
+	OutputSourceRule      = "rule"      // Articles matching a rule's conditions; SourceValue is the rule ID
+)
+
+// OutputFeed republishes a selection of articles as RSS, Atom or JSON Feed
+type OutputFeed struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Token       string    `json:"token"` // Secret that grants read access to the output
+	Source      string    `json:"source"`
+	SourceValue string    `json:"source_value,omitempty"`
+	Conditions  string    `json:"conditions,omitempty"` // JSON filter conditions for the "filter" source
+	Limit       int       `json:"limit"`                // Maximum number of articles, newest first
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// FeedURLChange records a feed URL that was updated after a confirmed permanent redirect
+type FeedURLChange struct {
+	ID         int64     `json:"id"`
+	FeedID     int64     `json:"feed_id"`
+	OldURL     string    `json:"old_url"`
+	NewURL     string    `json:"new_url"`
+	ChangedAt  time.Time `json:"changed_at"`
+	MergedInto int64     `json:"merged_into,omitempty"` // ID of the existing subscription the feed was merged into
 }
 
 type Article struct {
-	ID              int64     `json:"id"`
-	FeedID          int64     `json:"feed_id"`
-	Title           string    `json:"title"`
-	URL             string    `json:"url"`
-	ImageURL        string    `json:"image_url"`
-	AudioURL        string    `json:"audio_url"`
-	VideoURL        string    `json:"video_url"` // YouTube video URL for embedded player
-	PublishedAt     time.Time `json:"published_at"`
-	IsRead          bool      `json:"is_read"`
-	IsFavorite      bool      `json:"is_favorite"`
-	IsHidden        bool      `json:"is_hidden"`
-	IsReadLater     bool      `json:"is_read_later"`
-	FeedTitle       string    `json:"feed_title,omitempty"` // Joined field
-	TranslatedTitle string    `json:"translated_title"`
-	Summary         string    `json:"summary"` // Cached AI-generated summary
+	ID              int64           `json:"id"`
+	FeedID          int64           `json:"feed_id"`
+	Title           string          `json:"title"`
+	URL             string          `json:"url"`
+	GUID            string          `json:"guid,omitempty"` // Item GUID, or normalized URL when the feed provides none
+	ImageURL        string          `json:"image_url"`
+	AudioURL        string          `json:"audio_url"`
+	VideoURL        string          `json:"video_url"` // YouTube video URL for embedded player
+	PublishedAt     time.Time       `json:"published_at"`
+	IsRead          bool            `json:"is_read"`
+	IsFavorite      bool            `json:"is_favorite"`
+	IsHidden        bool            `json:"is_hidden"`
+	IsReadLater     bool            `json:"is_read_later"`
+	FeedTitle       string          `json:"feed_title,omitempty"` // Joined field
+	TranslatedTitle string          `json:"translated_title"`
+	Summary         string          `json:"summary"`              // Cached AI-generated summary
+	Content         string          `json:"content,omitempty"`    // Article body stored at fetch time for offline reading
+	Author          string          `json:"author,omitempty"`     // Author names joined with ", "
+	Categories      []string        `json:"categories,omitempty"` // Item categories/tags as published by the feed
+	Enclosures      []Enclosure     `json:"enclosures,omitempty"`
+	CanonicalID     int64           `json:"canonical_id,omitempty"`    // Article this one duplicates, 0 if it is not a duplicate
+	DuplicateCount  int             `json:"duplicate_count,omitempty"` // Duplicates in other feeds linked to this article
+	ItemUpdatedAt   time.Time       `json:"-"`                         // Update time declared by the feed, used to detect revisions
+	Podcast         *PodcastEpisode `json:"podcast,omitempty"`         // Episode details of articles with audio
+	FullText        *FullTextStatus `json:"full_text,omitempty"`       // Automatic full-text fetch of articles of feeds that fetch full text
+}
+
+// ArticleRevision is one version of an article whose feed item changed after it was first saved
+type ArticleRevision struct {
+	ID            int64     `json:"id"`
+	ArticleID     int64     `json:"article_id"`
+	Revision      int       `json:"revision"` // 1 is the version first saved
+	Title         string    `json:"title"`
+	Content       string    `json:"content,omitempty"`
+	ItemUpdatedAt time.Time `json:"item_updated_at"` // Update time declared by the feed (zero = none)
+	CreatedAt     time.Time `json:"created_at"`      // When this version was saved
+}
+
+// ArticleCopy is one feed's copy of a story that arrived through several feeds
+type ArticleCopy struct {
+	ArticleID   int64     `json:"article_id"`
+	FeedID      int64     `json:"feed_id"`
+	FeedTitle   string    `json:"feed_title"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+	IsCanonical bool      `json:"is_canonical"`
+}
+
+// Chapter is a chapter mark of a podcast episode
+type Chapter struct {
+	Start float64 `json:"start"` // Seconds from the beginning
+	Title string  `json:"title"`
+	URL   string  `json:"url,omitempty"`
+	Image string  `json:"image,omitempty"`
+}
+
+// PodcastEpisode holds the podcast metadata of an article with audio and the listener's progress
+type PodcastEpisode struct {
+	ArticleID    int64            `json:"article_id"`
+	Duration     int              `json:"duration"`                // Seconds, from itunes:duration (0 = unknown)
+	Episode      int              `json:"episode,omitempty"`       // itunes:episode
+	Season       int              `json:"season,omitempty"`        // itunes:season
+	EpisodeType  string           `json:"episode_type,omitempty"`  // "full", "trailer" or "bonus"
+	ChaptersURL  string           `json:"chapters_url,omitempty"`  // Chapters file linked with podcast:chapters
+	ChaptersType string           `json:"chapters_type,omitempty"` // MIME type of the chapters file
+	Chapters     []Chapter        `json:"chapters,omitempty"`      // Chapters listed in the feed (Podlove Simple Chapters)
+	Position     float64          `json:"position"`                // Playback position in seconds
+	Played       bool             `json:"played"`
+	ProgressAt   time.Time        `json:"progress_at"` // Last change of position or played (zero = never played)
+	Download     *EpisodeDownload `json:"download,omitempty"`
+}
+
+// Episode download states
+const (
+	EpisodeDownloadQueued      = "queued"
+	EpisodeDownloadDownloading = "downloading"
+	EpisodeDownloadDone        = "done"
+	EpisodeDownloadFailed      = "failed"
+)
+
+// EpisodeDownload is an episode's audio file queued for or saved in the episode download directory
+type EpisodeDownload struct {
+	ArticleID    int64     `json:"article_id"`
+	FeedID       int64     `json:"feed_id"`       // Joined field
+	ArticleTitle string    `json:"article_title"` // Joined field
+	AudioURL     string    `json:"audio_url"`
+	Status       string    `json:"status"`
+	FilePath     string    `json:"-"`
+	Size         int64     `json:"size"`
+	Error        string    `json:"error,omitempty"`
+	QueuedAt     time.Time `json:"queued_at"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
+// Full-text fetch statuses
+const (
+	FullTextPending = "pending"
+	FullTextOK      = "ok"
+	FullTextFailed  = "failed"
+)
+
+// FullTextStatus tracks the automatic full-text fetch of an article.
+type FullTextStatus struct {
+	ArticleID int64     `json:"article_id"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExtractionRule tells full-text fetching where the article is on the pages of a site and its subdomains.
+// Selectors starting with "/" or "(" are XPath expressions, others are CSS selectors.
+type ExtractionRule struct {
+	Domain           string    `json:"domain"`
+	ContentSelectors []string  `json:"content_selectors"`  // Tried in order, the first one matching is used; readability if none matches
+	StripSelectors   []string  `json:"strip_selectors"`    // Elements removed before the content is extracted
+	NextPageSelector string    `json:"next_page_selector"` // Link to the next page of multi-page articles
+	LazyImageAttrs   []string  `json:"lazy_image_attrs"`   // Image attributes holding the real source, such as data-src
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// What refreshing does during quiet hours
+const (
+	QuietHoursPause = "pause" // Feeds are not refreshed
+	QuietHoursSlow  = "slow"  // Feeds are refreshed QuietSlowFactor times less often
+)
+
+// RefreshPolicy limits when and how much data background refreshing downloads, either globally
+// or for the feeds of one category. A category's policy replaces the global one for its feeds,
+// except that the global daily budget always caps the total.
+type RefreshPolicy struct {
+	Category        string `json:"category"`          // "" for the global policy
+	QuietStart      string `json:"quiet_start"`       // Start of the quiet hours as HH:MM local time, "" for none
+	QuietEnd        string `json:"quiet_end"`         // End of the quiet hours as HH:MM, before the start to span midnight
+	QuietMode       string `json:"quiet_mode"`        // "pause" or "slow"
+	QuietSlowFactor int    `json:"quiet_slow_factor"` // Refresh interval multiplier in slow mode
+	LowData         bool   `json:"low_data"`          // Skip full-text and episode prefetching and refresh fewer feeds at once
+	DailyBudgetMB   int    `json:"daily_budget_mb"`   // Data downloaded per day at most, 0 for no limit
+}
+
+// Enclosure is a media file attached to an article (podcast audio, images, video, ...)
+type Enclosure struct {
+	URL    string `json:"url"`
+	Type   string `json:"type"`   // MIME type as declared by the feed
+	Length int64  `json:"length"` // Size in bytes as declared by the feed, 0 if unknown
 }
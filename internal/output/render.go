@@ -0,0 +1,363 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// Output formats
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// Channel describes the republished feed itself.
+type Channel struct {
+	Title       string
+	Description string
+	Link        string // Web page of the feed, optional
+	SelfURL     string // URL the feed is served from
+}
+
+// ValidFormat reports whether articles can be rendered in format. An empty format means RSS.
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatRSS, FormatAtom, FormatJSON, "":
+		return true
+	}
+	return false
+}
+
+// Render renders articles in the given format and returns the document with its content type.
+func Render(format string, ch Channel, articles []models.Article) ([]byte, string, error) {
+	switch format {
+	case FormatRSS, "":
+		data, err := RSS(ch, articles)
+		return data, "application/rss+xml; charset=utf-8", err
+	case FormatAtom:
+		data, err := Atom(ch, articles)
+		return data, "application/atom+xml; charset=utf-8", err
+	case FormatJSON:
+		data, err := JSONFeed(ch, articles)
+		return data, "application/feed+json; charset=utf-8", err
+	default:
+		return nil, "", fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// title returns the translated title of an article if there is one.
+func title(a models.Article) string {
+	if a.TranslatedTitle != "" {
+		return a.TranslatedTitle
+	}
+	return a.Title
+}
+
+// description returns the AI summary of an article, or its content if it has none.
+func description(a models.Article) string {
+	if a.Summary != "" {
+		return a.Summary
+	}
+	return a.Content
+}
+
+// itemID returns a stable identifier for an article.
+func itemID(a models.Article) string {
+	if a.GUID != "" {
+		return a.GUID
+	}
+	if a.URL != "" {
+		return a.URL
+	}
+	return "mrrss:article:" + strconv.FormatInt(a.ID, 10)
+}
+
+// lastUpdated returns the publication time of the newest article, or now if there are none.
+func lastUpdated(articles []models.Article) time.Time {
+	var latest time.Time
+	for _, a := range articles {
+		if a.PublishedAt.After(latest) {
+			latest = a.PublishedAt
+		}
+	}
+	if latest.IsZero() {
+		return time.Now()
+	}
+	return latest
+}
+
+type rssDocument struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	DCNS      string     `xml:"xmlns:dc,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      *atomLink `xml:"atom:link,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Creator     string        `xml:"dc:creator,omitempty"`
+	Categories  []string      `xml:"category"`
+	Description string        `xml:"description,omitempty"`
+	Content     string        `xml:"content:encoded,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+// RSS renders articles as an RSS 2.0 document.
+func RSS(ch Channel, articles []models.Article) ([]byte, error) {
+	doc := rssDocument{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		DCNS:      "http://purl.org/dc/elements/1.1/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         ch.Title,
+			Link:          ch.Link,
+			Description:   ch.Description,
+			LastBuildDate: lastUpdated(articles).Format(time.RFC1123Z),
+			Generator:     "MrRSS",
+		},
+	}
+	if doc.Channel.Link == "" {
+		doc.Channel.Link = ch.SelfURL
+	}
+	if ch.SelfURL != "" {
+		doc.Channel.SelfLink = &atomLink{Href: ch.SelfURL, Rel: "self", Type: "application/rss+xml"}
+	}
+
+	for _, a := range articles {
+		item := rssItem{
+			Title:       title(a),
+			Link:        a.URL,
+			GUID:        rssGUID{IsPermaLink: false, Value: itemID(a)},
+			Creator:     a.Author,
+			Categories:  a.Categories,
+			Description: description(a),
+			Content:     a.Content,
+		}
+		if !a.PublishedAt.IsZero() {
+			item.PubDate = a.PublishedAt.Format(time.RFC1123Z)
+		}
+		// RSS 2.0 allows a single enclosure per item
+		if len(a.Enclosures) > 0 {
+			enc := a.Enclosures[0]
+			item.Enclosure = &rssEnclosure{URL: enc.URL, Type: enc.Type, Length: enc.Length}
+		} else if a.AudioURL != "" {
+			item.Enclosure = &rssEnclosure{URL: a.AudioURL, Type: "audio/mpeg"}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	return marshalXML(doc)
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	NS        string      `xml:"xmlns,attr"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	ID        string      `xml:"id"`
+	Updated   string      `xml:"updated"`
+	Generator string      `xml:"generator"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+// Atom renders articles as an Atom 1.0 document.
+func Atom(ch Channel, articles []models.Article) ([]byte, error) {
+	feed := atomFeed{
+		NS:        "http://www.w3.org/2005/Atom",
+		Title:     ch.Title,
+		Subtitle:  ch.Description,
+		ID:        ch.SelfURL,
+		Updated:   lastUpdated(articles).Format(time.RFC3339),
+		Generator: "MrRSS",
+	}
+	if ch.SelfURL != "" {
+		feed.Links = append(feed.Links, atomLink{Href: ch.SelfURL, Rel: "self", Type: "application/atom+xml"})
+	}
+	if ch.Link != "" {
+		feed.Links = append(feed.Links, atomLink{Href: ch.Link, Rel: "alternate", Type: "text/html"})
+	}
+
+	for _, a := range articles {
+		published := a.PublishedAt
+		if published.IsZero() {
+			published = time.Now()
+		}
+		entry := atomEntry{
+			Title:     title(a),
+			ID:        itemID(a),
+			Published: published.Format(time.RFC3339),
+			Updated:   published.Format(time.RFC3339),
+		}
+		if a.URL != "" {
+			entry.Links = append(entry.Links, atomLink{Href: a.URL, Rel: "alternate", Type: "text/html"})
+		}
+		for _, enc := range a.Enclosures {
+			entry.Links = append(entry.Links, atomLink{Href: enc.URL, Rel: "enclosure", Type: enc.Type, Length: enc.Length})
+		}
+		if a.Author != "" {
+			entry.Author = &atomPerson{Name: a.Author}
+		}
+		for _, c := range a.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		if a.Summary != "" {
+			entry.Summary = &atomText{Type: "html", Value: a.Summary}
+		}
+		if a.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: a.Content}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return marshalXML(feed)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title,omitempty"`
+	ContentHTML   string               `json:"content_html,omitempty"`
+	Summary       string               `json:"summary,omitempty"`
+	Image         string               `json:"image,omitempty"`
+	DatePublished string               `json:"date_published,omitempty"`
+	Authors       []jsonFeedAuthor     `json:"authors,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []jsonFeedAttachment `json:"attachments,omitempty"`
+}
+
+// JSONFeed renders articles as a JSON Feed 1.1 document.
+func JSONFeed(ch Channel, articles []models.Article) ([]byte, error) {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       ch.Title,
+		HomePageURL: ch.Link,
+		FeedURL:     ch.SelfURL,
+		Description: ch.Description,
+		Items:       []jsonFeedItem{},
+	}
+
+	for _, a := range articles {
+		item := jsonFeedItem{
+			ID:          itemID(a),
+			URL:         a.URL,
+			Title:       title(a),
+			ContentHTML: a.Content,
+			Summary:     a.Summary,
+			Image:       a.ImageURL,
+			Tags:        a.Categories,
+		}
+		// Items need content_html or content_text; fall back to the summary
+		if item.ContentHTML == "" {
+			item.ContentHTML = description(a)
+		}
+		if !a.PublishedAt.IsZero() {
+			item.DatePublished = a.PublishedAt.Format(time.RFC3339)
+		}
+		if a.Author != "" {
+			item.Authors = []jsonFeedAuthor{{Name: a.Author}}
+		}
+		for _, enc := range a.Enclosures {
+			item.Attachments = append(item.Attachments, jsonFeedAttachment{URL: enc.URL, MimeType: enc.Type, SizeInBytes: enc.Length})
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
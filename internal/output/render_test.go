@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func testArticles() []models.Article {
+	return []models.Article{
+		{
+			ID:              1,
+			Title:           "Hallo Welt",
+			TranslatedTitle: "Hello World",
+			URL:             "https://example.com/hello",
+			GUID:            "guid-1",
+			PublishedAt:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Summary:         "<p>A short summary</p>",
+			Content:         "<p>Full <b>content</b> & more</p>",
+			Author:          "Jane",
+			Categories:      []string{"news", "go"},
+			Enclosures:      []models.Enclosure{{URL: "https://example.com/ep.mp3", Type: "audio/mpeg", Length: 1234}},
+		},
+		{
+			ID:          2,
+			Title:       "No extras",
+			URL:         "https://example.com/plain",
+			PublishedAt: time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestRender_ParsesBack(t *testing.T) {
+	ch := Channel{Title: "My Favorites", Description: "Curated", SelfURL: "https://mrrss.example/api/outputs/feed?id=1"}
+
+	for _, format := range []string{FormatRSS, FormatAtom, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			data, contentType, err := Render(format, ch, testArticles())
+			if err != nil {
+				t.Fatalf("Render error: %v", err)
+			}
+			if contentType == "" {
+				t.Error("expected a content type")
+			}
+
+			parsed, err := gofeed.NewParser().Parse(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("rendered %s does not parse: %v\n%s", format, err, data)
+			}
+			if parsed.Title != "My Favorites" {
+				t.Errorf("feed title = %q", parsed.Title)
+			}
+			if len(parsed.Items) != 2 {
+				t.Fatalf("got %d items, want 2", len(parsed.Items))
+			}
+
+			item := parsed.Items[0]
+			if item.Title != "Hello World" {
+				t.Errorf("title = %q, want the translated title", item.Title)
+			}
+			if item.Link != "https://example.com/hello" {
+				t.Errorf("link = %q", item.Link)
+			}
+			if item.GUID != "guid-1" {
+				t.Errorf("guid = %q", item.GUID)
+			}
+			if item.Content != "<p>Full <b>content</b> & more</p>" {
+				t.Errorf("content = %q", item.Content)
+			}
+			if item.Description != "<p>A short summary</p>" {
+				t.Errorf("description = %q, want the summary", item.Description)
+			}
+			if item.PublishedParsed == nil || !item.PublishedParsed.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("published = %v", item.PublishedParsed)
+			}
+			if len(item.Authors) == 0 || item.Authors[0].Name != "Jane" {
+				t.Errorf("authors = %+v", item.Authors)
+			}
+			if len(item.Categories) != 2 {
+				t.Errorf("categories = %v", item.Categories)
+			}
+			if len(item.Enclosures) != 1 || item.Enclosures[0].URL != "https://example.com/ep.mp3" {
+				t.Errorf("enclosures = %+v", item.Enclosures)
+			}
+		})
+	}
+}
+
+func TestJSONFeed_EmptyItems(t *testing.T) {
+	data, err := JSONFeed(Channel{Title: "Empty"}, nil)
+	if err != nil {
+		t.Fatalf("JSONFeed error: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if items, ok := doc["items"].([]interface{}); !ok || len(items) != 0 {
+		t.Errorf("items = %v, want an empty array", doc["items"])
+	}
+	if doc["version"] != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("version = %v", doc["version"])
+	}
+}
+
+func TestRender_UnknownFormat(t *testing.T) {
+	if _, _, err := Render("yaml", Channel{}, nil); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
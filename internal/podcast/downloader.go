@@ -0,0 +1,377 @@
+// Package podcast downloads podcast episodes for offline listening.
+package podcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/refreshpolicy"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// EpisodesDirName is the subdirectory of the media cache directory that holds downloaded episodes.
+	EpisodesDirName = "episodes"
+	// checkInterval is how often feeds with a keep-episodes policy are checked for new episodes.
+	checkInterval = 15 * time.Minute
+	// downloadTimeout bounds a single episode download.
+	downloadTimeout = 2 * time.Hour
+)
+
+// ErrSizeLimit is returned when an episode does not fit in the download size limit.
+var ErrSizeLimit = errors.New("episode is larger than the download size limit")
+
+// ClientFunc returns the HTTP client used to download an episode of the given feed.
+type ClientFunc func(feedID int64) (*http.Client, error)
+
+// Downloader works through the episode download queue one episode at a time. It also queues
+// the newest episodes of feeds that keep episodes offline and removes downloads that are no
+// longer kept or that exceed the podcast_download_max_size_mb setting.
+type Downloader struct {
+	db       *database.DB
+	client   ClientFunc
+	wake     chan struct{}
+	policies *refreshpolicy.Service
+
+	mu       sync.Mutex
+	dir      string
+	activeID int64
+	cancel   context.CancelFunc
+}
+
+// NewDownloader creates a downloader saving episodes to dir. An empty dir means the
+// episodes subdirectory of the media cache directory.
+func NewDownloader(db *database.DB, dir string, client ClientFunc) *Downloader {
+	return &Downloader{
+		db:       db,
+		dir:      dir,
+		client:   client,
+		wake:     make(chan struct{}, 1),
+		policies: refreshpolicy.New(db),
+	}
+}
+
+// Run processes the download queue until ctx is cancelled.
+func (d *Downloader) Run(ctx context.Context) {
+	if err := d.db.ResetRunningEpisodeDownloads(); err != nil {
+		log.Printf("Error resetting episode downloads: %v", err)
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		d.QueueKeptEpisodes()
+		d.ProcessQueue(ctx)
+		d.Enforce()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Notify wakes the download loop, e.g. after a feed's keep-episodes policy changed.
+func (d *Downloader) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue queues an episode for download.
+func (d *Downloader) Enqueue(articleID int64) error {
+	if err := d.db.QueueEpisodeDownload(articleID); err != nil {
+		return err
+	}
+	d.Notify()
+	return nil
+}
+
+// QueueKeptEpisodes queues the newest episodes of feeds with a keep-episodes policy. Episodes
+// held back by a refresh policy are queued on a later check.
+func (d *Downloader) QueueKeptEpisodes() {
+	ids, err := d.db.GetEpisodesToAutoDownload()
+	if err != nil {
+		log.Printf("Error listing episodes to download: %v", err)
+		return
+	}
+	snap, err := d.policies.Snapshot(time.Now())
+	if err != nil {
+		log.Printf("Error reading refresh policies: %v", err)
+	}
+	for _, id := range ids {
+		// Low data mode, quiet hours and an exhausted budget hold back automatic downloads
+		if category, err := d.db.GetArticleCategory(id); err == nil {
+			if decision := snap.Decide(category); decision.LowData || decision.Paused {
+				continue
+			}
+		}
+		if err := d.db.QueueEpisodeDownload(id); err != nil {
+			log.Printf("Error queueing episode %d: %v", id, err)
+		}
+	}
+}
+
+// ProcessQueue downloads queued episodes until the queue is empty or ctx is cancelled.
+func (d *Downloader) ProcessQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		next, err := d.db.NextQueuedEpisodeDownload()
+		if err != nil {
+			log.Printf("Error reading episode download queue: %v", err)
+			return
+		}
+		if next == nil {
+			return
+		}
+		d.download(ctx, next)
+	}
+}
+
+// download downloads one episode and records the outcome.
+func (d *Downloader) download(ctx context.Context, dl *models.EpisodeDownload) {
+	if err := d.db.SetEpisodeDownloadState(dl.ArticleID, models.EpisodeDownloadDownloading, "", 0, ""); err != nil {
+		log.Printf("Error updating episode download %d: %v", dl.ArticleID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	d.mu.Lock()
+	d.activeID, d.cancel = dl.ArticleID, cancel
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		d.activeID, d.cancel = 0, nil
+		d.mu.Unlock()
+		cancel()
+	}()
+
+	filePath, size, err := d.fetch(ctx, dl)
+	if err != nil {
+		log.Printf("Error downloading episode %d: %v", dl.ArticleID, err)
+		_ = d.db.SetEpisodeDownloadState(dl.ArticleID, models.EpisodeDownloadFailed, "", 0, err.Error())
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// The download may have been removed while it was running
+	if current, err := d.db.GetEpisodeDownload(dl.ArticleID); err != nil || current == nil {
+		_ = os.Remove(filePath)
+		return
+	}
+	if err := d.db.SetEpisodeDownloadState(dl.ArticleID, models.EpisodeDownloadDone, filePath, size, ""); err != nil {
+		log.Printf("Error updating episode download %d: %v", dl.ArticleID, err)
+		_ = os.Remove(filePath)
+	}
+}
+
+// fetch saves the audio of an episode to the episodes directory and returns its path and size.
+func (d *Downloader) fetch(ctx context.Context, dl *models.EpisodeDownload) (string, int64, error) {
+	dir, err := d.episodesDir()
+	if err != nil {
+		return "", 0, err
+	}
+	limit := d.maxBytes()
+
+	client, err := d.client(dl.FeedID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	// The client's timeout is meant for feeds; the context bounds the whole download instead
+	c := *client
+	c.Timeout = 0
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dl.AudioURL, nil)
+	if err != nil {
+		return "", 0, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if limit > 0 && resp.ContentLength > limit {
+		return "", 0, ErrSizeLimit
+	}
+
+	tmp, err := os.CreateTemp(dir, "download-*.part")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	body := io.Reader(resp.Body)
+	if limit > 0 {
+		body = io.LimitReader(resp.Body, limit+1)
+	}
+	size, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if limit > 0 && size > limit {
+		return "", 0, ErrSizeLimit
+	}
+
+	filePath := filepath.Join(dir, strconv.FormatInt(dl.ArticleID, 10)+episodeExtension(dl.AudioURL, resp.Header.Get("Content-Type")))
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return "", 0, err
+	}
+	return filePath, size, nil
+}
+
+// Enforce deletes downloads that their feed no longer keeps or whose article was deleted, then
+// removes the oldest finished downloads until they fit in the download size limit. Episodes
+// removed for space are marked failed so they are not downloaded again automatically.
+func (d *Downloader) Enforce() {
+	expired, err := d.db.GetExpiredEpisodeDownloads()
+	if err != nil {
+		log.Printf("Error listing expired episode downloads: %v", err)
+		return
+	}
+	for _, dl := range expired {
+		if err := d.Remove(dl.ArticleID); err != nil {
+			log.Printf("Error removing episode download %d: %v", dl.ArticleID, err)
+		}
+	}
+
+	limit := d.maxBytes()
+	if limit <= 0 {
+		return
+	}
+	downloads, err := d.db.GetEpisodeDownloads()
+	if err != nil {
+		log.Printf("Error listing episode downloads: %v", err)
+		return
+	}
+	var done []models.EpisodeDownload
+	var total int64
+	for _, dl := range downloads {
+		if dl.Status == models.EpisodeDownloadDone {
+			done = append(done, dl)
+			total += dl.Size
+		}
+	}
+	sort.Slice(done, func(i, j int) bool {
+		if !done[i].CompletedAt.Equal(done[j].CompletedAt) {
+			return done[i].CompletedAt.Before(done[j].CompletedAt)
+		}
+		return done[i].ArticleID < done[j].ArticleID
+	})
+	for _, dl := range done {
+		if total <= limit {
+			break
+		}
+		d.mu.Lock()
+		err := d.db.SetEpisodeDownloadState(dl.ArticleID, models.EpisodeDownloadFailed, "", 0, "removed to stay within the download size limit")
+		if err == nil {
+			_ = os.Remove(dl.FilePath)
+			total -= dl.Size
+		}
+		d.mu.Unlock()
+	}
+}
+
+// Remove deletes the download of an episode and its file, cancelling it if it is running.
+func (d *Downloader) Remove(articleID int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.activeID == articleID && d.cancel != nil {
+		d.cancel()
+	}
+	dl, err := d.db.GetEpisodeDownload(articleID)
+	if err != nil || dl == nil {
+		return err
+	}
+	if dl.FilePath != "" {
+		if err := os.Remove(dl.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return d.db.DeleteEpisodeDownload(articleID)
+}
+
+// FilePath returns the path of a finished episode download.
+func (d *Downloader) FilePath(articleID int64) (string, error) {
+	dl, err := d.db.GetEpisodeDownload(articleID)
+	if err != nil {
+		return "", err
+	}
+	if dl == nil || dl.Status != models.EpisodeDownloadDone || dl.FilePath == "" {
+		return "", os.ErrNotExist
+	}
+	if _, err := os.Stat(dl.FilePath); err != nil {
+		return "", err
+	}
+	return dl.FilePath, nil
+}
+
+// episodesDir returns the directory episodes are saved to, creating it if needed.
+func (d *Downloader) episodesDir() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dir == "" {
+		cacheDir, err := utils.GetMediaCacheDir()
+		if err != nil {
+			return "", err
+		}
+		d.dir = filepath.Join(cacheDir, EpisodesDirName)
+	}
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create episodes directory: %w", err)
+	}
+	return d.dir, nil
+}
+
+// maxBytes returns the podcast_download_max_size_mb setting in bytes; 0 means no limit.
+func (d *Downloader) maxBytes() int64 {
+	s, err := d.db.GetSetting("podcast_download_max_size_mb")
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n << 20
+}
+
+// episodeExtension returns the file extension for an episode, from its URL or else its Content-Type.
+func episodeExtension(audioURL, contentType string) string {
+	if u, err := url.Parse(audioURL); err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		if len(ext) > 1 && len(ext) <= 5 && strings.Trim(ext[1:], "abcdefghijklmnopqrstuvwxyz0123456789") == "" {
+			return ext
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ""
+}
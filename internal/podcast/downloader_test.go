@@ -0,0 +1,136 @@
+package podcast
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func setupDownloader(t *testing.T, handler http.HandlerFunc) (*Downloader, *database.DB, *httptest.Server) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB error: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init error: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	d := NewDownloader(db, t.TempDir(), func(int64) (*http.Client, error) { return server.Client(), nil })
+	return d, db, server
+}
+
+func addEpisodes(t *testing.T, db *database.DB, serverURL string, n int) (int64, []int64) {
+	t.Helper()
+	feedID, err := db.AddFeed(&models.Feed{Title: "Show", URL: serverURL + "/feed.xml"})
+	if err != nil {
+		t.Fatalf("AddFeed error: %v", err)
+	}
+	base := time.Now().Add(-time.Duration(n) * time.Hour)
+	var ids []int64
+	for i := 0; i < n; i++ {
+		a := &models.Article{
+			FeedID: feedID, Title: fmt.Sprintf("Episode %d", i), URL: fmt.Sprintf("%s/ep%d", serverURL, i),
+			AudioURL: fmt.Sprintf("%s/audio/ep%d.mp3", serverURL, i), PublishedAt: base.Add(time.Duration(i) * time.Hour),
+		}
+		if err := db.SaveArticle(a); err != nil {
+			t.Fatalf("SaveArticle error: %v", err)
+		}
+		ids = append(ids, a.ID)
+	}
+	return feedID, ids
+}
+
+func TestDownloader_KeepsNewestEpisodes(t *testing.T) {
+	d, db, server := setupDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		fmt.Fprint(w, "audio of "+r.URL.Path)
+	})
+	feedID, ids := addEpisodes(t, db, server.URL, 3)
+
+	// A manual download of the oldest episode, then a policy keeping the two newest
+	if err := d.Enqueue(ids[0]); err != nil {
+		t.Fatalf("Enqueue error: %v", err)
+	}
+	d.ProcessQueue(context.Background())
+	oldPath, err := d.FilePath(ids[0])
+	if err != nil {
+		t.Fatalf("FilePath error: %v", err)
+	}
+	if data, _ := os.ReadFile(oldPath); string(data) != "audio of /audio/ep0.mp3" || filepath.Ext(oldPath) != ".mp3" {
+		t.Errorf("downloaded %s = %q", oldPath, data)
+	}
+
+	if err := db.SetFeedKeepEpisodes(feedID, 2); err != nil {
+		t.Fatalf("SetFeedKeepEpisodes error: %v", err)
+	}
+	d.QueueKeptEpisodes()
+	d.ProcessQueue(context.Background())
+	d.Enforce()
+
+	for _, id := range ids[1:] {
+		if _, err := d.FilePath(id); err != nil {
+			t.Errorf("episode %d not downloaded: %v", id, err)
+		}
+	}
+	if dl, _ := db.GetEpisodeDownload(ids[0]); dl != nil {
+		t.Errorf("old episode still downloaded: %+v", dl)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("old episode file not removed: %v", err)
+	}
+}
+
+func TestDownloader_SizeLimit(t *testing.T) {
+	big := strings.Repeat("x", 600<<10)
+	d, db, server := setupDownloader(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "ep2.mp3") {
+			// No Content-Length, so the limit is enforced while copying
+			w.(http.Flusher).Flush()
+			fmt.Fprint(w, big+big)
+			return
+		}
+		fmt.Fprint(w, big)
+	})
+	_, ids := addEpisodes(t, db, server.URL, 3)
+	if err := db.SetSetting("podcast_download_max_size_mb", "1"); err != nil {
+		t.Fatalf("SetSetting error: %v", err)
+	}
+
+	for _, id := range ids {
+		if err := d.Enqueue(id); err != nil {
+			t.Fatalf("Enqueue error: %v", err)
+		}
+		d.ProcessQueue(context.Background())
+	}
+
+	dl, _ := db.GetEpisodeDownload(ids[2])
+	if dl == nil || dl.Status != models.EpisodeDownloadFailed || dl.Error != ErrSizeLimit.Error() {
+		t.Errorf("oversized episode = %+v", dl)
+	}
+
+	// Two 600 KB episodes exceed 1 MB, so the first one finished is removed
+	d.Enforce()
+	first, _ := db.GetEpisodeDownload(ids[0])
+	if first == nil || first.Status != models.EpisodeDownloadFailed || first.FilePath != "" {
+		t.Errorf("first episode = %+v", first)
+	}
+	if _, err := d.FilePath(ids[1]); err != nil {
+		t.Errorf("second episode removed: %v", err)
+	}
+	entries, _ := os.ReadDir(d.dir)
+	if len(entries) != 1 {
+		t.Errorf("episodes directory has %d files, want 1", len(entries))
+	}
+}
@@ -0,0 +1,196 @@
+// Package refreshpolicy decides when background refreshing may download data: quiet hours that
+// pause or slow refreshing, a low data mode and a daily data budget, set globally or per category.
+package refreshpolicy
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+const (
+	// DefaultSlowFactor is the refresh interval multiplier of quiet hours in slow mode.
+	DefaultSlowFactor = 4
+	// maxSlowFactor caps the slow mode multiplier.
+	maxSlowFactor = 24
+	dayLayout     = "2006-01-02"
+	clockLayout   = "15:04"
+)
+
+// Service reads the refresh policies and records data usage.
+type Service struct {
+	db *database.DB
+}
+
+// New creates a policy service.
+func New(db *database.DB) *Service {
+	return &Service{db: db}
+}
+
+// Day returns the usage day of t, in local time.
+func Day(t time.Time) string {
+	return t.Local().Format(dayLayout)
+}
+
+// Record adds n downloaded bytes to the usage of category today.
+func (s *Service) Record(category string, n int64) {
+	if s == nil || n <= 0 {
+		return
+	}
+	if err := s.db.AddDataUsage(Day(time.Now()), category, n); err != nil {
+		log.Printf("Error recording data usage: %v", err)
+	}
+}
+
+// Snapshot reads the policies and the usage of the day of now. A nil service has no policies.
+func (s *Service) Snapshot(now time.Time) (*Snapshot, error) {
+	snap := &Snapshot{Now: now, Usage: map[string]int64{}, categories: map[string]models.RefreshPolicy{}}
+	if s == nil {
+		return snap, nil
+	}
+	policies, err := s.db.GetRefreshPolicies()
+	if err != nil {
+		return snap, err
+	}
+	for _, p := range policies {
+		if p.Category == "" {
+			snap.global = p
+		} else {
+			snap.categories[p.Category] = p
+		}
+	}
+	usage, err := s.db.GetDataUsage(Day(now))
+	if err != nil {
+		return snap, err
+	}
+	snap.Usage = usage
+	return snap, nil
+}
+
+// Snapshot holds the policies and the day's data usage at one point in time.
+type Snapshot struct {
+	Now   time.Time
+	Usage map[string]int64 // Bytes downloaded today by category
+
+	global     models.RefreshPolicy
+	categories map[string]models.RefreshPolicy
+}
+
+// Decision is what the policies allow for the feeds of a category.
+type Decision struct {
+	Paused         bool   `json:"paused"`          // Background refreshing is paused
+	Reason         string `json:"reason"`          // "quiet_hours" or "budget_exhausted" when paused or slowed
+	IntervalFactor int    `json:"interval_factor"` // Multiplier of the refresh interval, at least 1
+	LowData        bool   `json:"low_data"`        // Prefetching is skipped
+	Quiet          bool   `json:"quiet"`           // In quiet hours
+}
+
+// TotalUsage returns the bytes downloaded today across all categories.
+func (s *Snapshot) TotalUsage() int64 {
+	var total int64
+	for _, n := range s.Usage {
+		total += n
+	}
+	return total
+}
+
+// Policy returns the policy that applies to the feeds of category.
+func (s *Snapshot) Policy(category string) models.RefreshPolicy {
+	if p, ok := s.categories[category]; ok && category != "" {
+		return p
+	}
+	return s.global
+}
+
+// Decide returns what the policies allow for the feeds of category.
+func (s *Snapshot) Decide(category string) Decision {
+	d := Decision{IntervalFactor: 1}
+	p := s.Policy(category)
+	d.LowData = p.LowData
+
+	if InQuietHours(p, s.Now) {
+		d.Quiet = true
+		d.Reason = "quiet_hours"
+		if p.QuietMode == models.QuietHoursSlow {
+			d.IntervalFactor = slowFactor(p)
+		} else {
+			d.Paused = true
+		}
+	}
+
+	// The global budget caps all feeds, a category's budget only its own
+	exhausted := overBudget(s.global.DailyBudgetMB, s.TotalUsage())
+	if category != "" {
+		if cp, ok := s.categories[category]; ok {
+			exhausted = exhausted || overBudget(cp.DailyBudgetMB, s.Usage[category])
+		}
+	}
+	if exhausted {
+		d.Paused = true
+		d.LowData = true
+		d.Reason = "budget_exhausted"
+	}
+	return d
+}
+
+func overBudget(budgetMB int, used int64) bool {
+	return budgetMB > 0 && used >= int64(budgetMB)<<20
+}
+
+func slowFactor(p models.RefreshPolicy) int {
+	if p.QuietSlowFactor < 1 {
+		return DefaultSlowFactor
+	}
+	return min(p.QuietSlowFactor, maxSlowFactor)
+}
+
+// InQuietHours reports whether now is within the quiet hours of p. Quiet hours whose end is
+// before their start span midnight.
+func InQuietHours(p models.RefreshPolicy, now time.Time) bool {
+	start, err1 := time.Parse(clockLayout, p.QuietStart)
+	end, err2 := time.Parse(clockLayout, p.QuietEnd)
+	if err1 != nil || err2 != nil || start.Equal(end) {
+		return false
+	}
+	now = now.Local()
+	minute := now.Hour()*60 + now.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
+
+// Validate checks a policy before it is saved and fills in the default slow factor.
+func Validate(p *models.RefreshPolicy) error {
+	if (p.QuietStart == "") != (p.QuietEnd == "") {
+		return fmt.Errorf("quiet hours need both a start and an end")
+	}
+	for _, clock := range []string{p.QuietStart, p.QuietEnd} {
+		if clock == "" {
+			continue
+		}
+		if _, err := time.Parse(clockLayout, clock); err != nil {
+			return fmt.Errorf("invalid time %q, expected HH:MM", clock)
+		}
+	}
+	switch p.QuietMode {
+	case "":
+		p.QuietMode = models.QuietHoursPause
+	case models.QuietHoursPause, models.QuietHoursSlow:
+	default:
+		return fmt.Errorf("invalid quiet hours mode %q", p.QuietMode)
+	}
+	if p.QuietSlowFactor < 0 || p.DailyBudgetMB < 0 {
+		return fmt.Errorf("slow factor and daily budget must not be negative")
+	}
+	if p.QuietMode == models.QuietHoursSlow && p.QuietSlowFactor == 0 {
+		p.QuietSlowFactor = DefaultSlowFactor
+	}
+	p.QuietSlowFactor = min(p.QuietSlowFactor, maxSlowFactor)
+	return nil
+}
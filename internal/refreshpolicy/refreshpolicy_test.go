@@ -0,0 +1,125 @@
+package refreshpolicy
+
+import (
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func setupDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB error: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init error: %v", err)
+	}
+	return db
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, 5, 1, hour, minute, 0, 0, time.Local)
+}
+
+func TestInQuietHours(t *testing.T) {
+	overnight := models.RefreshPolicy{QuietStart: "22:00", QuietEnd: "07:30"}
+	daytime := models.RefreshPolicy{QuietStart: "09:00", QuietEnd: "17:00"}
+
+	tests := []struct {
+		policy models.RefreshPolicy
+		now    time.Time
+		want   bool
+	}{
+		{overnight, at(23, 0), true},
+		{overnight, at(3, 0), true},
+		{overnight, at(7, 29), true},
+		{overnight, at(7, 30), false},
+		{overnight, at(12, 0), false},
+		{daytime, at(9, 0), true},
+		{daytime, at(17, 0), false},
+		{daytime, at(8, 59), false},
+		{models.RefreshPolicy{}, at(12, 0), false},
+	}
+	for _, tt := range tests {
+		if got := InQuietHours(tt.policy, tt.now); got != tt.want {
+			t.Errorf("InQuietHours(%s-%s, %s) = %v, want %v", tt.policy.QuietStart, tt.policy.QuietEnd, tt.now.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestDecide_CategoryPolicyAndBudgets(t *testing.T) {
+	db := setupDB(t)
+	s := New(db)
+
+	policies := []models.RefreshPolicy{
+		{QuietStart: "22:00", QuietEnd: "06:00", QuietMode: models.QuietHoursPause, DailyBudgetMB: 10},
+		{Category: "Podcasts", LowData: true, DailyBudgetMB: 1},
+		{Category: "News", QuietStart: "22:00", QuietEnd: "06:00", QuietMode: models.QuietHoursSlow},
+	}
+	for _, p := range policies {
+		if err := Validate(&p); err != nil {
+			t.Fatalf("Validate(%+v) error: %v", p, err)
+		}
+		if err := db.SaveRefreshPolicy(p); err != nil {
+			t.Fatalf("SaveRefreshPolicy error: %v", err)
+		}
+	}
+
+	// Usage is recorded for today, so the snapshots are taken today
+	today := time.Now()
+	night := time.Date(today.Year(), today.Month(), today.Day(), 23, 0, 0, 0, time.Local)
+	snap, err := s.Snapshot(night)
+	if err != nil {
+		t.Fatalf("Snapshot error: %v", err)
+	}
+	if d := snap.Decide("Tech"); !d.Paused || d.Reason != "quiet_hours" {
+		t.Errorf("global quiet hours: %+v", d)
+	}
+	if d := snap.Decide("News"); d.Paused || d.IntervalFactor != DefaultSlowFactor {
+		t.Errorf("slowed category: %+v", d)
+	}
+	// A category's policy replaces the global one, quiet hours included
+	if d := snap.Decide("Podcasts"); d.Paused || !d.LowData {
+		t.Errorf("low data category: %+v", d)
+	}
+
+	noon := time.Date(today.Year(), today.Month(), today.Day(), 12, 0, 0, 0, time.Local)
+	s.Record("Podcasts", 2<<20)
+	snap, _ = s.Snapshot(noon)
+	if d := snap.Decide("Podcasts"); !d.Paused || d.Reason != "budget_exhausted" {
+		t.Errorf("category over its budget: %+v", d)
+	}
+	if d := snap.Decide("Tech"); d.Paused {
+		t.Errorf("other category paused by a category budget: %+v", d)
+	}
+
+	// The global budget covers every category
+	s.Record("", 9<<20)
+	snap, _ = s.Snapshot(noon)
+	if d := snap.Decide("News"); !d.Paused || d.Reason != "budget_exhausted" {
+		t.Errorf("global budget exhausted: %+v", d)
+	}
+	if snap.TotalUsage() != 11<<20 {
+		t.Errorf("TotalUsage = %d", snap.TotalUsage())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	p := models.RefreshPolicy{QuietStart: "23:00", QuietEnd: "06:00", QuietMode: models.QuietHoursSlow}
+	if err := Validate(&p); err != nil || p.QuietSlowFactor != DefaultSlowFactor {
+		t.Errorf("Validate = %v, slow factor %d", err, p.QuietSlowFactor)
+	}
+	for _, bad := range []models.RefreshPolicy{
+		{QuietStart: "23:00"},
+		{QuietStart: "25:00", QuietEnd: "06:00"},
+		{QuietStart: "23:00", QuietEnd: "06:00", QuietMode: "sleep"},
+		{DailyBudgetMB: -1},
+	} {
+		if err := Validate(&bad); err == nil {
+			t.Errorf("Validate(%+v) accepted an invalid policy", bad)
+		}
+	}
+}
@@ -15,7 +15,7 @@ type Condition struct {
 	ID       int64    `json:"id"`
 	Logic    string   `json:"logic"`    // "and", "or" (null for first condition)
 	Negate   bool     `json:"negate"`   // NOT modifier for this condition
-	Field    string   `json:"field"`    // "feed_name", "feed_category", "article_title", etc.
+	Field    string   `json:"field"`    // "feed_name", "feed_category", "article_title", "article_author", "article_category", etc.
 	Operator string   `json:"operator"` // "contains", "exact"
 	Value    string   `json:"value"`    // Single value for text/date fields
 	Values   []string `json:"values"`   // Multiple values for feed_name and feed_category
@@ -135,6 +135,48 @@ func (e *Engine) ApplyRule(rule Rule) (int, error) {
 	return affected, nil
 }
 
+// GetRule returns the rule with the given ID, or nil if there is none.
+func (e *Engine) GetRule(id int64) (*Rule, error) {
+	rulesJSON, _ := e.db.GetSetting("rules")
+	if rulesJSON == "" {
+		return nil, nil
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal([]byte(rulesJSON), &rules); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if rule.ID == id {
+			return &rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// FilterArticles returns the articles matching the conditions, without applying any action.
+func (e *Engine) FilterArticles(articles []models.Article, conditions []Condition) ([]models.Article, error) {
+	feeds, err := e.db.GetFeeds()
+	if err != nil {
+		return nil, err
+	}
+
+	feedCategories := make(map[int64]string)
+	feedTitles := make(map[int64]string)
+	for _, feed := range feeds {
+		feedCategories[feed.ID] = feed.Category
+		feedTitles[feed.ID] = feed.Title
+	}
+
+	matched := []models.Article{}
+	for _, article := range articles {
+		if matchesConditions(article, conditions, feedCategories, feedTitles) {
+			matched = append(matched, article)
+		}
+	}
+	return matched, nil
+}
+
 // matchesConditions checks if an article matches the rule conditions
 func matchesConditions(article models.Article, conditions []Condition, feedCategories map[int64]string, feedTitles map[int64]string) bool {
 	// If no conditions, apply to all articles
@@ -188,6 +230,12 @@ func evaluateCondition(article models.Article, condition Condition, feedCategori
 			}
 		}
 
+	case "article_author":
+		result = MatchText(article.Author, condition.Operator, condition.Value)
+
+	case "article_category":
+		result = MatchCategories(article.Categories, condition.Operator, condition.Values, condition.Value)
+
 	case "published_after":
 		if condition.Value == "" {
 			result = true
@@ -273,6 +321,39 @@ func matchMultiSelect(fieldValue string, values []string, singleValue string) bo
 	return true
 }
 
+// MatchText matches a text field using the "contains" (default) or "exact" operator, case-insensitively.
+// An empty value matches everything.
+func MatchText(fieldValue, operator, value string) bool {
+	if value == "" {
+		return true
+	}
+	lowerField := strings.ToLower(fieldValue)
+	lowerValue := strings.ToLower(value)
+	if operator == "exact" {
+		return lowerField == lowerValue
+	}
+	return strings.Contains(lowerField, lowerValue)
+}
+
+// MatchCategories checks whether any of an article's categories matches any selected value,
+// or singleValue if none are selected. Without values it matches everything.
+func MatchCategories(categories []string, operator string, values []string, singleValue string) bool {
+	if len(values) == 0 {
+		if singleValue == "" {
+			return true
+		}
+		values = []string{singleValue}
+	}
+	for _, category := range categories {
+		for _, val := range values {
+			if MatchText(category, operator, val) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // applyAction applies an action to an article
 func (e *Engine) applyAction(articleID int64, action string) error {
 	switch action {
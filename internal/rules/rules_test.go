@@ -100,3 +100,32 @@ func TestEngine_ApplyRule(t *testing.T) {
 		t.Errorf("Expected 0 articles to be processed, got %d", count)
 	}
 }
+
+func TestEvaluateCondition_AuthorAndCategory(t *testing.T) {
+	article := models.Article{
+		Title:      "Release notes",
+		Author:     "Jane Doe, John Roe",
+		Categories: []string{"Go", "Release"},
+	}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		want      bool
+	}{
+		{"author contains", Condition{Field: "article_author", Operator: "contains", Value: "jane"}, true},
+		{"author exact mismatch", Condition{Field: "article_author", Operator: "exact", Value: "Jane Doe"}, false},
+		{"category single value", Condition{Field: "article_category", Operator: "exact", Value: "go"}, true},
+		{"category multi values", Condition{Field: "article_category", Values: []string{"rust", "release"}}, true},
+		{"category no match", Condition{Field: "article_category", Values: []string{"python"}}, false},
+		{"category negated", Condition{Field: "article_category", Value: "python", Negate: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evaluateCondition(article, tt.condition, nil, nil); got != tt.want {
+				t.Errorf("evaluateCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
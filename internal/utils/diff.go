@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"html"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// Kinds of lines in a diff
+const (
+	DiffEqual   = ' '
+	DiffRemoved = '-'
+	DiffAdded   = '+'
+)
+
+// maxDiffCells bounds the work of the line diff; larger inputs are shown as fully replaced.
+const maxDiffCells = 4_000_000
+
+// DiffLine is one line of a diff.
+type DiffLine struct {
+	Kind byte // DiffEqual, DiffRemoved or DiffAdded
+	Text string
+}
+
+// blockTags end a line when converting HTML to text.
+var blockTags = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "blockquote": true, "pre": true, "section": true,
+	"article": true, "header": true, "footer": true, "figure": true, "figcaption": true, "hr": true,
+	"ul": true, "ol": true, "table": true, "dt": true, "dd": true,
+}
+
+// HTMLToText returns the text of an HTML fragment, one line per block element.
+// Whitespace inside lines is collapsed and empty lines are dropped.
+func HTMLToText(content string) string {
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(content))
+	var b strings.Builder
+	skip := 0
+	for {
+		switch tokenizer.Next() {
+		case nethtml.ErrorToken:
+			var lines []string
+			for _, line := range strings.Split(b.String(), "\n") {
+				if line = strings.Join(strings.Fields(line), " "); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			return strings.Join(lines, "\n")
+		case nethtml.TextToken:
+			if skip == 0 {
+				b.Write(tokenizer.Text())
+			}
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			switch tag := string(name); {
+			case tag == "script" || tag == "style":
+				skip++
+			case blockTags[tag]:
+				b.WriteByte('\n')
+			}
+		case nethtml.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch tag := string(name); {
+			case (tag == "script" || tag == "style") && skip > 0:
+				skip--
+			case blockTags[tag]:
+				b.WriteByte('\n')
+			}
+		}
+	}
+}
+
+// DiffLines returns a line diff turning a into b, based on their longest common subsequence.
+func DiffLines(a, b []string) []DiffLine {
+	if len(a)*len(b) > maxDiffCells {
+		diff := make([]DiffLine, 0, len(a)+len(b))
+		for _, line := range a {
+			diff = append(diff, DiffLine{Kind: DiffRemoved, Text: line})
+		}
+		for _, line := range b {
+			diff = append(diff, DiffLine{Kind: DiffAdded, Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{Kind: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Kind: DiffRemoved, Text: a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Kind: DiffAdded, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{Kind: DiffRemoved, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{Kind: DiffAdded, Text: b[j]})
+	}
+	return diff
+}
+
+// FormatTextDiff renders a diff as text, prefixing each line with "-", "+" or a space.
+func FormatTextDiff(diff []DiffLine) string {
+	var b strings.Builder
+	for _, line := range diff {
+		b.WriteByte(line.Kind)
+		b.WriteByte(' ')
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// FormatHTMLDiff renders a diff as HTML paragraphs, with removed lines in <del> and added lines in <ins>.
+func FormatHTMLDiff(diff []DiffLine) string {
+	var b strings.Builder
+	b.WriteString(`<div class="diff">`)
+	for _, line := range diff {
+		text := html.EscapeString(line.Text)
+		switch line.Kind {
+		case DiffRemoved:
+			b.WriteString(`<p class="diff-removed"><del>` + text + `</del></p>`)
+		case DiffAdded:
+			b.WriteString(`<p class="diff-added"><ins>` + text + `</ins></p>`)
+		default:
+			b.WriteString(`<p>` + text + `</p>`)
+		}
+	}
+	b.WriteString(`</div>`)
+	return b.String()
+}
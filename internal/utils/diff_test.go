@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLToText(t *testing.T) {
+	got := HTMLToText(`<h1>Title</h1><p>First   paragraph with <b>bold</b> &amp; more.</p><script>var x = 1;</script><ul><li>One</li><li>Two</li></ul>`)
+	want := "Title\nFirst paragraph with bold & more.\nOne\nTwo"
+	if got != want {
+		t.Errorf("HTMLToText = %q, want %q", got, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"intro", "old claim", "outro"}
+	b := []string{"intro", "corrected claim", "outro", "update"}
+
+	diff := DiffLines(a, b)
+	text := FormatTextDiff(diff)
+	want := "  intro\n- old claim\n+ corrected claim\n  outro\n+ update\n"
+	if text != want {
+		t.Errorf("FormatTextDiff =\n%s\nwant\n%s", text, want)
+	}
+
+	html := FormatHTMLDiff(DiffLines([]string{"a <b>"}, []string{"a <i>"}))
+	if !strings.Contains(html, "<del>a &lt;b&gt;</del>") || !strings.Contains(html, "<ins>a &lt;i&gt;</ins>") {
+		t.Errorf("FormatHTMLDiff = %s", html)
+	}
+}
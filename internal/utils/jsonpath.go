@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type jsonPathStepKind int
+
+const (
+	jsonPathChild jsonPathStepKind = iota
+	jsonPathIndex
+	jsonPathWildcard
+	jsonPathDescendant
+)
+
+type jsonPathStep struct {
+	kind  jsonPathStepKind
+	name  string
+	index int
+}
+
+// JSONPath is a compiled JSONPath expression. The supported subset is the root ($), children
+// (.name and ['name']), array indexes ([0], [-1] from the end), wildcards (.* and [*]) and
+// recursive descent (..name). A path without the leading $ is relative to the value it is applied to.
+type JSONPath struct {
+	steps []jsonPathStep
+}
+
+// CompileJSONPath parses a JSONPath expression.
+func CompileJSONPath(path string) (*JSONPath, error) {
+	p := strings.TrimSpace(path)
+	if p == "" {
+		return nil, fmt.Errorf("empty JSONPath")
+	}
+	p = strings.TrimPrefix(p, "$")
+
+	var steps []jsonPathStep
+	for i := 0; i < len(p); {
+		switch {
+		case strings.HasPrefix(p[i:], ".."):
+			name, next := readJSONPathName(p, i+2)
+			if name == "" {
+				return nil, fmt.Errorf("missing name after '..' at offset %d in %q", i, path)
+			}
+			steps = append(steps, jsonPathStep{kind: jsonPathDescendant, name: name})
+			i = next
+		case p[i] == '.':
+			name, next := readJSONPathName(p, i+1)
+			if name == "" {
+				return nil, fmt.Errorf("missing name after '.' at offset %d in %q", i, path)
+			}
+			steps = append(steps, nameStep(name))
+			i = next
+		case p[i] == '[':
+			end := closingBracket(p, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' at offset %d in %q", i, path)
+			}
+			step, err := bracketStep(strings.TrimSpace(p[i+1 : end]))
+			if err != nil {
+				return nil, fmt.Errorf("%w in %q", err, path)
+			}
+			steps = append(steps, step)
+			i = end + 1
+		case i == 0:
+			// Relative path starting with a name, e.g. "title" or "author.name"
+			name, next := readJSONPathName(p, 0)
+			if name == "" || next == i {
+				return nil, fmt.Errorf("unexpected %q at offset %d in %q", p[i], i, path)
+			}
+			steps = append(steps, nameStep(name))
+			i = next
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d in %q", p[i], i, path)
+		}
+	}
+	return &JSONPath{steps: steps}, nil
+}
+
+// readJSONPathName reads a dot-notation name starting at i and returns it with the offset after it.
+func readJSONPathName(p string, i int) (string, int) {
+	end := i
+	for end < len(p) && p[end] != '.' && p[end] != '[' && p[end] != ']' {
+		end++
+	}
+	return strings.TrimSpace(p[i:end]), end
+}
+
+func nameStep(name string) jsonPathStep {
+	if name == "*" {
+		return jsonPathStep{kind: jsonPathWildcard}
+	}
+	return jsonPathStep{kind: jsonPathChild, name: name}
+}
+
+// closingBracket returns the offset of the ']' matching the '[' at start, skipping quoted names.
+func closingBracket(p string, start int) int {
+	var quote byte
+	for i := start + 1; i < len(p); i++ {
+		switch {
+		case quote != 0:
+			if p[i] == quote {
+				quote = 0
+			}
+		case p[i] == '\'' || p[i] == '"':
+			quote = p[i]
+		case p[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func bracketStep(content string) (jsonPathStep, error) {
+	if content == "*" {
+		return jsonPathStep{kind: jsonPathWildcard}, nil
+	}
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		return jsonPathStep{kind: jsonPathChild, name: content[1 : len(content)-1]}, nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return jsonPathStep{}, fmt.Errorf("unsupported selector [%s]", content)
+	}
+	return jsonPathStep{kind: jsonPathIndex, index: index}, nil
+}
+
+// Find returns all values the path selects in data, a value decoded by encoding/json.
+func (jp *JSONPath) Find(data any) []any {
+	current := []any{data}
+	for _, step := range jp.steps {
+		var next []any
+		for _, value := range current {
+			next = step.apply(value, next)
+		}
+		current = next
+		if len(current) == 0 {
+			break
+		}
+	}
+	return current
+}
+
+func (s jsonPathStep) apply(value any, out []any) []any {
+	switch s.kind {
+	case jsonPathChild:
+		if obj, ok := value.(map[string]any); ok {
+			if child, ok := obj[s.name]; ok {
+				out = append(out, child)
+			}
+		}
+	case jsonPathIndex:
+		if arr, ok := value.([]any); ok {
+			i := s.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				out = append(out, arr[i])
+			}
+		}
+	case jsonPathWildcard:
+		out = appendJSONChildren(value, out)
+	case jsonPathDescendant:
+		out = appendJSONDescendants(value, s.name, out)
+	}
+	return out
+}
+
+// appendJSONChildren appends the elements of an array or the values of an object, in key order.
+func appendJSONChildren(value any, out []any) []any {
+	switch v := value.(type) {
+	case []any:
+		out = append(out, v...)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			out = append(out, v[key])
+		}
+	}
+	return out
+}
+
+// appendJSONDescendants appends the members called name of value and everything below it, or all descendants for "*".
+func appendJSONDescendants(value any, name string, out []any) []any {
+	if obj, ok := value.(map[string]any); ok && name != "*" {
+		if child, ok := obj[name]; ok {
+			out = append(out, child)
+		}
+	}
+	for _, child := range appendJSONChildren(value, nil) {
+		if name == "*" {
+			out = append(out, child)
+		}
+		out = appendJSONDescendants(child, name, out)
+	}
+	return out
+}
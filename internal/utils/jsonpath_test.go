@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONPathFind(t *testing.T) {
+	var doc any
+	json.Unmarshal([]byte(`{
+		"data": {"posts": [
+			{"title": "One", "meta": {"author": {"name": "Ann"}}, "tags": ["a", "b"]},
+			{"title": "Two", "meta": {"author": {"name": "Bob"}}, "the key": 2}
+		]},
+		"count": 2
+	}`), &doc)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$.count", `[2]`},
+		{"$.data.posts[0].title", `["One"]`},
+		{"$.data.posts[-1].title", `["Two"]`},
+		{"$.data.posts[*].title", `["One","Two"]`},
+		{"$['data']['posts'][1]['the key']", `[2]`},
+		{"$..name", `["Ann","Bob"]`},
+		{"$.data.posts[0].tags.*", `["a","b"]`},
+		{"data.posts[1].title", `["Two"]`},
+		{"$.missing.title", `null`},
+		{"$.data.posts[5]", `null`},
+	}
+	for _, tt := range tests {
+		jp, err := CompileJSONPath(tt.path)
+		if err != nil {
+			t.Errorf("CompileJSONPath(%q) error: %v", tt.path, err)
+			continue
+		}
+		got, _ := json.Marshal(jp.Find(doc))
+		if string(got) != tt.want {
+			t.Errorf("Find(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompileJSONPath_Invalid(t *testing.T) {
+	for _, path := range []string{"", "$.", "$.a[", "$.a[foo]", "$..", "$.a]", "]", "$]", " ]"} {
+		if _, err := CompileJSONPath(path); err == nil {
+			t.Errorf("CompileJSONPath(%q) succeeded, want error", path)
+		}
+	}
+}
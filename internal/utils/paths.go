@@ -152,6 +152,20 @@ func GetMediaCacheDir() (string, error) {
 	return cacheDir, nil
 }
 
+// GetMailDir returns the full path to the directory local mailboxes are read from
+func GetMailDir() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	mailDir := filepath.Join(dataDir, "mail")
+	err = os.MkdirAll(mailDir, 0755)
+	if err != nil {
+		return "", err
+	}
+	return mailDir, nil
+}
+
 // IsWindows returns true if the current platform is Windows
 func IsWindows() bool {
 	return runtime.GOOS == "windows"
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TitleSimilarity returns how similar two titles are, from 0 (nothing in common) to 1 (same text).
+// Case, punctuation and whitespace are ignored. The score is the Dice coefficient over
+// character bigrams, so it also works for languages written without spaces.
+func TitleSimilarity(a, b string) float64 {
+	bigramsA := titleBigrams(a)
+	bigramsB := titleBigrams(b)
+	if len(bigramsA) == 0 || len(bigramsB) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(bigramsA))
+	for _, bg := range bigramsA {
+		counts[bg]++
+	}
+	shared := 0
+	for _, bg := range bigramsB {
+		if counts[bg] > 0 {
+			counts[bg]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(bigramsA)+len(bigramsB))
+}
+
+// NormalizeTitle lower-cases a title and keeps only its letters and digits.
+func NormalizeTitle(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func titleBigrams(title string) []string {
+	runes := []rune(NormalizeTitle(title))
+	if len(runes) == 1 {
+		return []string{string(runes)}
+	}
+	bigrams := make([]string, 0, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		bigrams = append(bigrams, string(runes[i:i+2]))
+	}
+	return bigrams
+}
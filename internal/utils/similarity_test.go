@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"Go 1.22 is released", "Go 1.22 is released", 1, 1},
+		{"Go 1.22 is released!", "go 1.22 IS RELEASED", 1, 1},
+		{"Apple unveils new iPhone at September event", "Apple unveils new iPhone at its September event", 0.9, 1},
+		{"Apple unveils new iPhone", "Rust 2024 edition stabilised", 0, 0.3},
+		{"日本の首相が新しい政策を発表", "日本の首相が新しい政策を発表した", 0.9, 1},
+		{"", "anything", 0, 0},
+	}
+	for _, tt := range tests {
+		got := TitleSimilarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("TitleSimilarity(%q, %q) = %.2f, want between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
@@ -37,6 +37,34 @@ func URLsMatch(url1, url2 string) bool {
 	return normalizeURLForMatching(url1) == normalizeURLForMatching(url2)
 }
 
+// NormalizeURLForDuplicates rewrites a URL to https with a lower-cased host without "www.", so
+// copies of an article linked over http or through the www host compare equal.
+func NormalizeURLForDuplicates(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return rawURL
+	}
+	parsed.Scheme = "https"
+	parsed.Host = strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	return parsed.String()
+}
+
+// URLHost returns the lower-cased host name of a URL without port, or "" if it has none.
+func URLHost(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// NormalizeURL returns the normalized form of a URL used to identify an article:
+// scheme, host and path plus only the query parameters that look significant.
+// Tracking parameters such as utm_* are dropped.
+func NormalizeURL(rawURL string) string {
+	return normalizeURLForMatching(rawURL)
+}
+
 // normalizeURLForMatching normalizes URLs for comparison by preserving important query parameters
 // and removing tracking parameters and other non-essential parameters.
 func normalizeURLForMatching(rawURL string) string {
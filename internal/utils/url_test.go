@@ -140,3 +140,17 @@ func TestURLsMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeURLForDuplicates(t *testing.T) {
+	tests := map[string]string{
+		"http://www.Example.com/post/1?id=2": "https://example.com/post/1?id=2",
+		"https://example.com/post/1":         "https://example.com/post/1",
+		"ftp://www.example.com/file":         "ftp://www.example.com/file",
+		"not a url":                          "not a url",
+	}
+	for in, want := range tests {
+		if got := NormalizeURLForDuplicates(in); got != want {
+			t.Errorf("NormalizeURLForDuplicates(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
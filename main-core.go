@@ -26,17 +26,22 @@ import (
 	chat "MrRSS/internal/handlers/chat"
 	handlers "MrRSS/internal/handlers/core"
 	discovery "MrRSS/internal/handlers/discovery"
+	extraction "MrRSS/internal/handlers/extraction"
 	feedhandlers "MrRSS/internal/handlers/feed"
 	freshrssHandler "MrRSS/internal/handlers/freshrss"
 	media "MrRSS/internal/handlers/media"
 	networkhandlers "MrRSS/internal/handlers/network"
 	opml "MrRSS/internal/handlers/opml"
+	outputhandlers "MrRSS/internal/handlers/output"
+	podcast "MrRSS/internal/handlers/podcast"
+	policy "MrRSS/internal/handlers/policy"
 	rules "MrRSS/internal/handlers/rules"
 	script "MrRSS/internal/handlers/script"
 	settings "MrRSS/internal/handlers/settings"
 	summary "MrRSS/internal/handlers/summary"
 	translationhandlers "MrRSS/internal/handlers/translation"
 	update "MrRSS/internal/handlers/update"
+	websub "MrRSS/internal/handlers/websub"
 	window "MrRSS/internal/handlers/window"
 	"MrRSS/internal/network"
 	"MrRSS/internal/translation"
@@ -157,6 +162,17 @@ func main() {
 	apiMux.HandleFunc("/api/feeds/discover-all/progress", func(w http.ResponseWriter, r *http.Request) { discovery.HandleGetBatchDiscoveryProgress(h, w, r) })
 	apiMux.HandleFunc("/api/feeds/discover-all/clear", func(w http.ResponseWriter, r *http.Request) { discovery.HandleClearBatchDiscovery(h, w, r) })
 	apiMux.HandleFunc("/api/feeds/reorder", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleReorderFeed(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/health", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleFeedHealth(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/health/history", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleFeedFetchHistory(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/refresh-prediction", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleRefreshPrediction(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/resume", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleResumeFeed(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/url-changes", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleFeedURLChanges(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/backfill", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleBackfillFeed(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/backfill/progress", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleBackfillProgress(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/backfill/cancel", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleCancelBackfill(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/preview-xpath", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandlePreviewXPathFeed(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/mail-groups", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleMailGroups(h, w, r) })
+	apiMux.HandleFunc("/api/feeds/add-mail", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleAddMailFeeds(h, w, r) })
 	apiMux.HandleFunc("/api/articles", func(w http.ResponseWriter, r *http.Request) { article.HandleArticles(h, w, r) })
 	apiMux.HandleFunc("/api/articles/images", func(w http.ResponseWriter, r *http.Request) { article.HandleImageGalleryArticles(h, w, r) })
 	apiMux.HandleFunc("/api/articles/filter", func(w http.ResponseWriter, r *http.Request) { article.HandleFilteredArticles(h, w, r) })
@@ -174,6 +190,9 @@ func main() {
 	apiMux.HandleFunc("/api/articles/toggle-hide", func(w http.ResponseWriter, r *http.Request) { article.HandleToggleHideArticle(h, w, r) })
 	apiMux.HandleFunc("/api/articles/toggle-read-later", func(w http.ResponseWriter, r *http.Request) { article.HandleToggleReadLater(h, w, r) })
 	apiMux.HandleFunc("/api/articles/content", func(w http.ResponseWriter, r *http.Request) { article.HandleGetArticleContent(h, w, r) })
+	apiMux.HandleFunc("/api/articles/copies", func(w http.ResponseWriter, r *http.Request) { article.HandleArticleCopies(h, w, r) })
+	apiMux.HandleFunc("/api/articles/revisions", func(w http.ResponseWriter, r *http.Request) { article.HandleArticleRevisions(h, w, r) })
+	apiMux.HandleFunc("/api/articles/revisions/diff", func(w http.ResponseWriter, r *http.Request) { article.HandleArticleRevisionDiff(h, w, r) })
 	apiMux.HandleFunc("/api/articles/fetch-full", func(w http.ResponseWriter, r *http.Request) { article.HandleFetchFullArticle(h, w, r) })
 	apiMux.HandleFunc("/api/articles/unread-counts", func(w http.ResponseWriter, r *http.Request) { article.HandleGetUnreadCounts(h, w, r) })
 	apiMux.HandleFunc("/api/articles/mark-all-read", func(w http.ResponseWriter, r *http.Request) { article.HandleMarkAllAsRead(h, w, r) })
@@ -192,12 +211,28 @@ func main() {
 	apiMux.HandleFunc("/api/install-update", func(w http.ResponseWriter, r *http.Request) { update.HandleInstallUpdate(h, w, r) })
 	apiMux.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) { update.HandleVersion(h, w, r) })
 	apiMux.HandleFunc("/api/rules/apply", func(w http.ResponseWriter, r *http.Request) { rules.HandleApplyRule(h, w, r) })
+	apiMux.HandleFunc("/api/outputs", func(w http.ResponseWriter, r *http.Request) { outputhandlers.HandleOutputFeeds(h, w, r) })
+	apiMux.HandleFunc("/api/outputs/update", func(w http.ResponseWriter, r *http.Request) { outputhandlers.HandleUpdateOutputFeed(h, w, r) })
+	apiMux.HandleFunc("/api/outputs/delete", func(w http.ResponseWriter, r *http.Request) { outputhandlers.HandleDeleteOutputFeed(h, w, r) })
+	apiMux.HandleFunc("/api/outputs/regenerate-token", func(w http.ResponseWriter, r *http.Request) { outputhandlers.HandleRegenerateToken(h, w, r) })
+	apiMux.HandleFunc(outputhandlers.FeedPath, func(w http.ResponseWriter, r *http.Request) { outputhandlers.HandleFeed(h, w, r) })
 	apiMux.HandleFunc("/api/scripts/dir", func(w http.ResponseWriter, r *http.Request) { script.HandleGetScriptsDir(h, w, r) })
 	apiMux.HandleFunc("/api/scripts/open", func(w http.ResponseWriter, r *http.Request) { script.HandleOpenScriptsDir(h, w, r) })
 	apiMux.HandleFunc("/api/scripts/list", func(w http.ResponseWriter, r *http.Request) { script.HandleListScripts(h, w, r) })
 	apiMux.HandleFunc("/api/media/proxy", func(w http.ResponseWriter, r *http.Request) { media.HandleMediaProxy(h, w, r) })
 	apiMux.HandleFunc("/api/media/cleanup", func(w http.ResponseWriter, r *http.Request) { media.HandleMediaCacheCleanup(h, w, r) })
 	apiMux.HandleFunc("/api/media/info", func(w http.ResponseWriter, r *http.Request) { media.HandleMediaCacheInfo(h, w, r) })
+	apiMux.HandleFunc("/api/podcast/episode", func(w http.ResponseWriter, r *http.Request) { podcast.HandleEpisode(h, w, r) })
+	apiMux.HandleFunc("/api/podcast/progress", func(w http.ResponseWriter, r *http.Request) { podcast.HandleProgress(h, w, r) })
+	apiMux.HandleFunc("/api/podcast/downloads", func(w http.ResponseWriter, r *http.Request) { podcast.HandleDownloads(h, w, r) })
+	apiMux.HandleFunc("/api/podcast/downloads/queue", func(w http.ResponseWriter, r *http.Request) { podcast.HandleQueueDownload(h, w, r) })
+	apiMux.HandleFunc("/api/podcast/downloads/remove", func(w http.ResponseWriter, r *http.Request) { podcast.HandleRemoveDownload(h, w, r) })
+	apiMux.HandleFunc("/api/podcast/downloads/file", func(w http.ResponseWriter, r *http.Request) { podcast.HandleDownloadFile(h, w, r) })
+	apiMux.HandleFunc("/api/extraction-rules", func(w http.ResponseWriter, r *http.Request) { extraction.HandleRules(h, w, r) })
+	apiMux.HandleFunc("/api/extraction-rules/delete", func(w http.ResponseWriter, r *http.Request) { extraction.HandleDeleteRule(h, w, r) })
+	apiMux.HandleFunc("/api/extraction-rules/import", func(w http.ResponseWriter, r *http.Request) { extraction.HandleImportRules(h, w, r) })
+	apiMux.HandleFunc("/api/refresh-policies", func(w http.ResponseWriter, r *http.Request) { policy.HandlePolicies(h, w, r) })
+	apiMux.HandleFunc("/api/refresh-policies/delete", func(w http.ResponseWriter, r *http.Request) { policy.HandleDeletePolicy(h, w, r) })
 	apiMux.HandleFunc("/api/window/state", func(w http.ResponseWriter, r *http.Request) { window.HandleGetWindowState(h, w, r) })
 	apiMux.HandleFunc("/api/window/save", func(w http.ResponseWriter, r *http.Request) { window.HandleSaveWindowState(h, w, r) })
 	apiMux.HandleFunc("/api/network/detect", func(w http.ResponseWriter, r *http.Request) { networkhandlers.HandleDetectNetwork(h, w, r) })
@@ -205,6 +240,9 @@ func main() {
 	apiMux.HandleFunc("/api/browser/open", func(w http.ResponseWriter, r *http.Request) { browser.HandleOpenURL(h, w, r) })
 	apiMux.HandleFunc("/api/freshrss/sync", func(w http.ResponseWriter, r *http.Request) { freshrssHandler.HandleSync(h, w, r) })
 	apiMux.HandleFunc("/api/freshrss/test-connection", func(w http.ResponseWriter, r *http.Request) { freshrssHandler.HandleTestConnection(h, w, r) })
+	// WebSub push subscriptions need a publicly reachable callback, so they are only offered in server mode
+	apiMux.HandleFunc(feed.WebSubCallbackPath, func(w http.ResponseWriter, r *http.Request) { websub.HandleCallback(h, w, r) })
+	apiMux.HandleFunc("/api/websub/subscriptions", func(w http.ResponseWriter, r *http.Request) { websub.HandleSubscriptions(h, w, r) })
 
 	// Static Files
 	log.Println("Setting up static files...")
@@ -228,6 +266,7 @@ func main() {
 
 	log.Println("Starting background scheduler...")
 	go h.StartBackgroundScheduler(bgCtx)
+	go fetcher.StartWebSubRenewal(bgCtx)
 
 	// Start Network Speed Detection (optional but good to have)
 	go func() {